@@ -1,18 +1,27 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
 	"assetManager/internal/auth"
 	"assetManager/internal/config"
 	"assetManager/internal/database"
 	"assetManager/internal/handlers"
+	"assetManager/internal/jobs"
+	"assetManager/internal/lifecycle"
 	"assetManager/internal/middleware"
+	"assetManager/internal/models"
+	"assetManager/internal/notify"
 	"assetManager/internal/repository"
+	"assetManager/internal/storage"
+	"assetManager/internal/uniqueness"
 )
 
 func main() {
@@ -32,12 +41,49 @@ func main() {
 	}
 	defer db.Close()
 
+	transitions, err := lifecycle.New(cfg.Lifecycle.Transitions)
+	if err != nil {
+		log.Fatalf("Invalid lifecycle configuration: %v", err)
+	}
+
+	uniqueRules, err := uniqueness.New(cfg.Uniqueness.Rules)
+	if err != nil {
+		log.Fatalf("Invalid uniqueness configuration: %v", err)
+	}
+
+	alertsAt, err := jobs.ParseTimeOfDay(cfg.Alerts.RunAt)
+	if err != nil {
+		log.Fatalf("Invalid alerts configuration: %v", err)
+	}
+	if _, err := repository.AddPeriod(time.Now(), cfg.Alerts.Within, 1); err != nil {
+		log.Fatalf("Invalid alerts configuration: %v", err)
+	}
+
+	loansAt, err := jobs.ParseTimeOfDay(cfg.Loans.RunAt)
+	if err != nil {
+		log.Fatalf("Invalid loans configuration: %v", err)
+	}
+	if _, err := repository.AddPeriod(time.Now(), cfg.Loans.RemindBefore, 1); err != nil {
+		log.Fatalf("Invalid loans configuration: %v", err)
+	}
+
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		log.Fatalf("Invalid notifications configuration: %v", err)
+	}
+
+	attachmentStore, err := storage.New(cfg.Attachments)
+	if err != nil {
+		log.Fatalf("Invalid attachments configuration: %v", err)
+	}
+
 	// Initialize JWT service
 	jwtService := auth.NewJWTService(cfg.JWT.Secret, cfg.JWT.ExpiryHours)
 
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db.DB)
 	assetTypeRepo := repository.NewAssetTypeRepository(db.DB)
+	assetTypePropertyRepo := repository.NewAssetTypePropertyRepository(db.DB)
 	assetRepo := repository.NewAssetRepository(db.DB)
 	propertyRepo := repository.NewPropertyRepository(db.DB)
 	assetPropertyRepo := repository.NewAssetPropertyRepository(db.DB)
@@ -46,17 +92,43 @@ func main() {
 	personAttributeRepo := repository.NewPersonAttributeRepository(db.DB)
 	assignmentRepo := repository.NewAssetAssignmentRepository(db.DB)
 	reportRepo := repository.NewReportRepository(db.DB)
+	auditLogRepo := repository.NewAuditLogRepository(db.DB)
+	importRepo := repository.NewImportRepository(db.DB)
+	locationRepo := repository.NewLocationRepository(db.DB)
+	assetLocationRepo := repository.NewAssetLocationRepository(db.DB)
+	assetContractRepo := repository.NewAssetContractRepository(db.DB)
+	depreciationRepo := repository.NewDepreciationRepository(db.DB)
+	attachmentRepo := repository.NewAttachmentRepository(db.DB)
+	reservationRepo := repository.NewReservationRepository(db.DB)
+
+	// Initialize audit recorder
+	recorder := audit.NewRecorder(auditLogRepo)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(userRepo, jwtService)
-	userHandler := handlers.NewUserHandler(userRepo)
-	assetTypeHandler := handlers.NewAssetTypeHandler(assetTypeRepo)
-	assetHandler := handlers.NewAssetHandler(assetRepo, assetPropertyRepo)
-	propertyHandler := handlers.NewPropertyHandler(propertyRepo)
-	personHandler := handlers.NewPersonHandler(personRepo, personAttributeRepo)
-	attributeHandler := handlers.NewAttributeHandler(attributeRepo)
-	assignmentHandler := handlers.NewAssignmentHandler(assignmentRepo, personRepo)
+	userHandler := handlers.NewUserHandler(userRepo, recorder)
+	assetTypeHandler := handlers.NewAssetTypeHandler(assetTypeRepo, assetTypePropertyRepo, propertyRepo, recorder)
+	assetHandler := handlers.NewAssetHandler(assetRepo, assetPropertyRepo, propertyRepo, assetTypePropertyRepo, transitions, uniqueRules, recorder)
+	propertyHandler := handlers.NewPropertyHandler(propertyRepo, recorder)
+	personHandler := handlers.NewPersonHandler(personRepo, personAttributeRepo, attributeRepo, uniqueRules, recorder)
+	attributeHandler := handlers.NewAttributeHandler(attributeRepo, recorder)
+	assignmentHandler := handlers.NewAssignmentHandler(assignmentRepo, personRepo, assetRepo, transitions, recorder)
 	reportHandler := handlers.NewReportHandler(reportRepo)
+	auditHandler := handlers.NewAuditHandler(auditLogRepo)
+	importHandler := handlers.NewImportHandler(importRepo, assetRepo, assetTypeRepo, assetTypePropertyRepo, propertyRepo, personRepo, uniqueRules, transitions, recorder)
+	locationHandler := handlers.NewLocationHandler(locationRepo, assetLocationRepo, recorder)
+	contractHandler := handlers.NewContractHandler(assetContractRepo, assetRepo, cfg.Alerts.Within, recorder)
+	depreciationHandler := handlers.NewDepreciationHandler(depreciationRepo)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentRepo, attachmentStore, cfg.Attachments.MaxSizeMB<<20, recorder)
+	labelHandler := handlers.NewLabelHandler(assetRepo, assetTypeRepo, cfg.Labels.LookupURL)
+	maintenanceHandler := handlers.NewMaintenanceHandler(assetRepo, personRepo)
+	offboardingHandler := handlers.NewOffboardingHandler(personRepo, assignmentRepo, transitions, recorder)
+	loanHandler := handlers.NewLoanHandler(assignmentRepo, personRepo, transitions, recorder)
+	reservationHandler := handlers.NewReservationHandler(reservationRepo, assignmentRepo, personRepo, transitions, recorder)
+
+	// Background jobs
+	go jobs.RunDaily(context.Background(), "expiry-alerts", alertsAt, jobs.ExpiryAlerts(assetContractRepo, cfg.Alerts.Within))
+	go jobs.RunDaily(context.Background(), "loan-reminders", loansAt, jobs.LoanReminders(assignmentRepo, notifier, cfg.Loans.RemindBefore))
 
 	// Setup router
 	router := gin.Default()
@@ -65,83 +137,174 @@ func main() {
 	// Public routes
 	router.POST("/api/auth/login", authHandler.Login)
 
+	// Calendar feeds authenticate with the key in their address, as calendar applications cannot log in
+	router.GET("/api/calendar/assets/:feed", reservationHandler.AssetCalendar)
+	router.GET("/api/calendar/persons/:feed", reservationHandler.PersonCalendar)
+
 	// Protected routes
 	api := router.Group("/api")
-	api.Use(middleware.AuthMiddleware(jwtService))
+	api.Use(middleware.AuthMiddleware(jwtService, userRepo))
+
+	// Permission checks, declared per route below
+	canView := middleware.RequirePermission(auth.PermissionViewInventory)
+	canEdit := middleware.RequirePermission(auth.PermissionEditInventory)
+	canReport := middleware.RequirePermission(auth.PermissionRunReports)
+	canConfigure := middleware.RequirePermission(auth.PermissionManageConfig)
+	canManageUsers := middleware.RequirePermission(auth.PermissionManageUsers)
+	canViewAudit := middleware.RequirePermission(auth.PermissionViewAudit)
 	{
 		// Auth
 		api.GET("/auth/me", authHandler.Me)
 		api.POST("/auth/change-password", authHandler.ChangePassword)
 
 		// Users
-		api.GET("/users", userHandler.GetAll)
-		api.GET("/users/:id", userHandler.GetByID)
-		api.POST("/users", userHandler.Create)
-		api.PUT("/users/:id", userHandler.Update)
-		api.POST("/users/:id/reset-password", userHandler.ResetPassword)
-		api.DELETE("/users/:id", userHandler.Delete)
+		api.GET("/users", canManageUsers, userHandler.GetAll)
+		api.GET("/users/:id", canManageUsers, userHandler.GetByID)
+		api.POST("/users", canManageUsers, userHandler.Create)
+		api.PUT("/users/:id", canManageUsers, userHandler.Update)
+		api.POST("/users/:id/reset-password", canManageUsers, userHandler.ResetPassword)
+		api.DELETE("/users/:id", canManageUsers, userHandler.Delete)
 
 		// Asset Types
-		api.GET("/asset-types", assetTypeHandler.GetAll)
-		api.GET("/asset-types/:id", assetTypeHandler.GetByID)
-		api.POST("/asset-types", assetTypeHandler.Create)
-		api.PUT("/asset-types/:id", assetTypeHandler.Update)
-		api.DELETE("/asset-types/:id", assetTypeHandler.Delete)
+		api.GET("/asset-types", canView, assetTypeHandler.GetAll)
+		api.GET("/asset-types/:id", canView, assetTypeHandler.GetByID)
+		api.POST("/asset-types", canConfigure, assetTypeHandler.Create)
+		api.PUT("/asset-types/:id", canConfigure, assetTypeHandler.Update)
+		api.PUT("/asset-types/:id/properties", canConfigure, assetTypeHandler.SetProperties)
+		api.DELETE("/asset-types/:id", canConfigure, assetTypeHandler.Delete)
 
 		// Assets
-		api.GET("/assets", assetHandler.GetAll)
-		api.GET("/assets/with-assignments", assetHandler.GetWithAssignments)
-		api.GET("/assets/search", assetHandler.Search)
-		api.GET("/assets/:id", assetHandler.GetByID)
-		api.GET("/assets/by-type/:typeId", assetHandler.GetByAssetType)
-		api.POST("/assets", assetHandler.Create)
-		api.PUT("/assets/:id", assetHandler.Update)
-		api.DELETE("/assets/:id", assetHandler.Delete)
-		api.GET("/assets/:id/properties", assetHandler.GetProperties)
-		api.POST("/assets/:id/properties", assetHandler.SetProperty)
-		api.DELETE("/assets/:id/properties/:propId", assetHandler.DeleteProperty)
+		api.GET("/assets", canView, assetHandler.GetAll)
+		api.GET("/assets/with-assignments", canView, assetHandler.GetWithAssignments)
+		api.GET("/assets/search", canView, assetHandler.Search)
+		api.GET("/assets/by-tag/*tag", canView, assetHandler.GetByTag)
+		api.GET("/assets/:id", canView, assetHandler.GetByID)
+		api.GET("/assets/by-type/:typeId", canView, assetHandler.GetByAssetType)
+		api.POST("/assets", canEdit, assetHandler.Create)
+		api.PUT("/assets/:id", canEdit, assetHandler.Update)
+		api.DELETE("/assets/:id", canEdit, assetHandler.Delete)
+		api.GET("/assets/:id/properties", canView, assetHandler.GetProperties)
+		api.POST("/assets/:id/properties", canEdit, assetHandler.SetProperty)
+		api.DELETE("/assets/:id/properties/:propId", canEdit, assetHandler.DeleteProperty)
+		api.GET("/assets/:id/status-history", canView, assetHandler.GetStatusHistory)
+		api.POST("/assets/:id/status", canEdit, assetHandler.ChangeStatus)
+		api.GET("/asset-statuses", canView, assetHandler.GetLifecycle)
+		api.GET("/assets/:id/audit", canViewAudit, auditHandler.GetByAssetID)
+		api.GET("/assets/:id/location", canView, locationHandler.GetCurrentByAssetID)
+		api.GET("/assets/:id/location-history", canView, locationHandler.GetHistoryByAssetID)
+		api.POST("/assets/:id/location", canEdit, locationHandler.MoveAsset)
+		api.DELETE("/assets/:id/location", canEdit, locationHandler.RemoveAsset)
+		api.GET("/assets/:id/contracts", canView, contractHandler.GetByAssetID)
+		api.POST("/assets/:id/contracts", canEdit, contractHandler.Create)
+		api.GET("/assets/:id/book-value", canView, depreciationHandler.GetByAssetID)
+		api.GET("/assets/:id/attachments", canView, attachmentHandler.List(models.AttachmentEntityAsset))
+		api.POST("/assets/:id/attachments", canEdit, attachmentHandler.Upload(models.AttachmentEntityAsset))
+		api.GET("/assets/:id/label", canView, labelHandler.GetByAssetID)
+
+		// Labels
+		api.GET("/labels/layouts", canView, labelHandler.GetLayouts)
+		api.POST("/labels", canView, labelHandler.Print)
+
+		// Attachments
+		api.GET("/attachments/:id/download", canView, attachmentHandler.Download)
+		api.DELETE("/attachments/:id", canEdit, attachmentHandler.Delete)
+
+		// Warranties, support contracts and leases
+		api.PUT("/contracts/:id", canEdit, contractHandler.Update)
+		api.DELETE("/contracts/:id", canEdit, contractHandler.Delete)
+		api.GET("/alerts/expiring", canView, contractHandler.GetExpiring)
+
+		// Locations
+		api.GET("/locations", canView, locationHandler.GetAll)
+		api.GET("/locations/:id", canView, locationHandler.GetByID)
+		api.GET("/locations/:id/assets", canView, locationHandler.GetAssets)
+		api.POST("/locations", canEdit, locationHandler.Create)
+		api.PUT("/locations/:id", canEdit, locationHandler.Update)
+		api.DELETE("/locations/:id", canEdit, locationHandler.Delete)
 
 		// Properties (configuration)
-		api.GET("/properties", propertyHandler.GetAll)
-		api.GET("/properties/:id", propertyHandler.GetByID)
-		api.POST("/properties", propertyHandler.Create)
-		api.PUT("/properties/:id", propertyHandler.Update)
-		api.DELETE("/properties/:id", propertyHandler.Delete)
+		api.GET("/properties", canView, propertyHandler.GetAll)
+		api.GET("/properties/:id", canView, propertyHandler.GetByID)
+		api.POST("/properties", canConfigure, propertyHandler.Create)
+		api.PUT("/properties/:id", canConfigure, propertyHandler.Update)
+		api.DELETE("/properties/:id", canConfigure, propertyHandler.Delete)
 
 		// Persons
-		api.GET("/persons", personHandler.GetAll)
-		api.GET("/persons/search", personHandler.Search)
-		api.GET("/persons/:id", personHandler.GetByID)
-		api.POST("/persons", personHandler.Create)
-		api.PUT("/persons/:id", personHandler.Update)
-		api.DELETE("/persons/:id", personHandler.Delete)
-		api.GET("/persons/:id/attributes", personHandler.GetAttributes)
-		api.POST("/persons/:id/attributes", personHandler.SetAttribute)
-		api.DELETE("/persons/:id/attributes/:attrId", personHandler.DeleteAttribute)
+		api.GET("/persons", canView, personHandler.GetAll)
+		api.GET("/persons/search", canView, personHandler.Search)
+		api.GET("/stock-pools", canView, personHandler.GetStockPools)
+		api.GET("/persons/:id", canView, personHandler.GetByID)
+		api.POST("/persons", canEdit, personHandler.Create)
+		api.PUT("/persons/:id", canEdit, personHandler.Update)
+		api.DELETE("/persons/:id", canEdit, personHandler.Delete)
+		api.POST("/persons/:id/merge", canEdit, personHandler.Merge)
+		api.POST("/persons/:id/offboard", canEdit, offboardingHandler.Offboard)
+		api.GET("/persons/:id/offboarding", canView, offboardingHandler.GetChecklist)
+		api.GET("/persons/:id/attributes", canView, personHandler.GetAttributes)
+		api.POST("/persons/:id/attributes", canEdit, personHandler.SetAttribute)
+		api.DELETE("/persons/:id/attributes/:attrId", canEdit, personHandler.DeleteAttribute)
+		api.GET("/persons/:id/attachments", canView, attachmentHandler.List(models.AttachmentEntityPerson))
+		api.POST("/persons/:id/attachments", canEdit, attachmentHandler.Upload(models.AttachmentEntityPerson))
 
 		// Attributes (configuration)
-		api.GET("/attributes", attributeHandler.GetAll)
-		api.GET("/attributes/:id", attributeHandler.GetByID)
-		api.POST("/attributes", attributeHandler.Create)
-		api.PUT("/attributes/:id", attributeHandler.Update)
-		api.DELETE("/attributes/:id", attributeHandler.Delete)
+		api.GET("/attributes", canView, attributeHandler.GetAll)
+		api.GET("/attributes/:id", canView, attributeHandler.GetByID)
+		api.POST("/attributes", canConfigure, attributeHandler.Create)
+		api.PUT("/attributes/:id", canConfigure, attributeHandler.Update)
+		api.DELETE("/attributes/:id", canConfigure, attributeHandler.Delete)
 
 		// Assignments
-		api.GET("/assignments/asset/:assetId", assignmentHandler.GetByAssetID)
-		api.GET("/assignments/asset/:assetId/current", assignmentHandler.GetCurrentByAssetID)
-		api.GET("/assignments/person/:personId", assignmentHandler.GetByPersonID)
-		api.GET("/assignments/person/:personId/current", assignmentHandler.GetCurrentByPersonID)
-		api.POST("/assignments", assignmentHandler.Create)
-		api.POST("/assignments/assign", assignmentHandler.AssignAsset)
-		api.POST("/assignments/unassign/:assetId", assignmentHandler.UnassignAsset)
-		api.PUT("/assignments/:id", assignmentHandler.Update)
-		api.POST("/assignments/:id/end", assignmentHandler.EndAssignment)
-		api.DELETE("/assignments/:id", assignmentHandler.Delete)
+		api.GET("/assignments/asset/:assetId", canView, assignmentHandler.GetByAssetID)
+		api.GET("/assignments/asset/:assetId/current", canView, assignmentHandler.GetCurrentByAssetID)
+		api.GET("/assignments/asset/:assetId/timeline", canView, assignmentHandler.GetTimeline)
+		api.GET("/assignments/person/:personId", canView, assignmentHandler.GetByPersonID)
+		api.GET("/assignments/person/:personId/current", canView, assignmentHandler.GetCurrentByPersonID)
+		api.GET("/assignments/pending", canView, assignmentHandler.GetPending)
+		api.POST("/assignments", canEdit, assignmentHandler.Create)
+		api.POST("/assignments/assign", canEdit, assignmentHandler.AssignAsset)
+		api.POST("/assignments/unassign/:assetId", canEdit, assignmentHandler.UnassignAsset)
+		api.PUT("/assignments/:id", canEdit, assignmentHandler.Update)
+		api.POST("/assignments/:id/end", canEdit, assignmentHandler.EndAssignment)
+		api.POST("/assignments/:id/cancel", canEdit, assignmentHandler.CancelPending)
+		api.DELETE("/assignments/:id", canEdit, assignmentHandler.Delete)
+		api.GET("/assignments/:id/attachments", canView, attachmentHandler.List(models.AttachmentEntityAssignment))
+		api.POST("/assignments/:id/attachments", canEdit, attachmentHandler.Upload(models.AttachmentEntityAssignment))
+
+		// Loan desk
+		api.GET("/loans", canView, loanHandler.GetActive)
+		api.POST("/loans/check-out", canEdit, loanHandler.CheckOut)
+		api.POST("/loans/check-in/:assetId", canEdit, loanHandler.CheckIn)
+
+		// Reservations
+		api.GET("/reservations", canView, reservationHandler.List)
+		api.GET("/reservations/:id", canView, reservationHandler.GetByID)
+		api.POST("/reservations", canEdit, reservationHandler.Create)
+		api.POST("/reservations/:id/cancel", canEdit, reservationHandler.Cancel)
+		api.POST("/reservations/:id/pickup", canEdit, reservationHandler.Pickup)
+		api.GET("/assets/:id/calendar", canView, reservationHandler.AssetFeedURL)
+		api.GET("/persons/:id/calendar", canView, reservationHandler.PersonFeedURL)
+		api.POST("/assets/:id/calendar/regenerate", canEdit, reservationHandler.RegenerateAssetFeedURL)
+		api.POST("/persons/:id/calendar/regenerate", canEdit, reservationHandler.RegeneratePersonFeedURL)
 
 		// Reports
 		reports := api.Group("/reports")
-		reports.POST("/custom", reportHandler.ExecuteCustomReport)
-		reports.GET("/multiple-assets", reportHandler.ExecuteMultipleAssetsReport)
+		reports.POST("/custom", canReport, reportHandler.ExecuteCustomReport)
+		reports.GET("/multiple-assets", canReport, reportHandler.ExecuteMultipleAssetsReport)
+		reports.GET("/assets", canReport, reportHandler.GetAssetListing)
+		reports.GET("/persons", canReport, reportHandler.GetPersonListing)
+		reports.GET("/book-values", canReport, depreciationHandler.GetBookValues)
+		reports.GET("/depreciation", canReport, depreciationHandler.GetReport)
+		reports.GET("/upcoming-changes", canReport, assignmentHandler.GetUpcoming)
+		reports.GET("/overdue-loans", canReport, loanHandler.GetOverdue)
+
+		// Imports
+		api.POST("/import/assets", canEdit, importHandler.ImportAssets)
+
+		// Data maintenance
+		api.GET("/maintenance/duplicates", canReport, maintenanceHandler.GetDuplicates)
+
+		// Audit log
+		api.GET("/audit", canViewAudit, auditHandler.GetAll)
 	}
 
 	// Start server
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/config"
+	"assetManager/internal/datatype"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <command>\n\n", os.Args[0])
+	fmt.Fprintln(os.Stderr, "Commands:")
+	fmt.Fprintln(os.Stderr, "  check-values  Report property and attribute values that do not match their data type")
+	fmt.Fprintln(os.Stderr, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+func main() {
+	configPath := flag.String("config", "config.yaml", "Path to config file")
+	fix := flag.Bool("fix", false, "check-values: rewrite valid values that are not in canonical form")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Connect to database
+	db, err := sqlx.Connect("mysql", cfg.Database.DSN())
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "check-values":
+		invalid, err := checkValues(ctx, db, *fix)
+		if err != nil {
+			log.Fatalf("Failed to check values: %v", err)
+		}
+		if invalid > 0 {
+			db.Close()
+			os.Exit(1)
+		}
+	default:
+		usage()
+		os.Exit(2)
+	}
+}
+
+// checkValues scans all stored property and attribute values and prints those that do not
+// match their definition. Values that are valid but not canonical are rewritten if fix is set.
+// It returns the number of invalid values.
+func checkValues(ctx context.Context, db *sqlx.DB, fix bool) (int, error) {
+	assetPropertyRepo := repository.NewAssetPropertyRepository(db)
+	personAttributeRepo := repository.NewPersonAttributeRepository(db)
+
+	propertyValues, err := assetPropertyRepo.GetAllValues(ctx)
+	if err != nil {
+		return 0, err
+	}
+	attributeValues, err := personAttributeRepo.GetAllValues(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sets := []struct {
+		table  string
+		owner  string
+		values []models.CustomValue
+		update func(id int64, value string) error
+	}{
+		{"assets_properties", "asset", propertyValues, func(id int64, value string) error {
+			return assetPropertyRepo.Update(ctx, &models.AssetProperty{BaseModel: models.BaseModel{ID: id}, Value: value})
+		}},
+		{"persons_attributes", "person", attributeValues, func(id int64, value string) error {
+			return personAttributeRepo.Update(ctx, &models.PersonAttribute{BaseModel: models.BaseModel{ID: id}, Value: value})
+		}},
+	}
+
+	invalid, rewritten := 0, 0
+	for _, set := range sets {
+		for _, v := range set.values {
+			canonical, err := datatype.Canonical(v.DataType, v.EnumOptions, v.Value)
+			if err != nil {
+				invalid++
+				fmt.Printf("%s id=%d %s=%d %q (%s): %v\n", set.table, v.ID, set.owner, v.OwnerID, v.FieldName, v.DataType, err)
+				continue
+			}
+			if canonical == v.Value {
+				continue
+			}
+			if !fix {
+				fmt.Printf("%s id=%d %s=%d %q (%s): %q is not in canonical form %q\n", set.table, v.ID, set.owner, v.OwnerID, v.FieldName, v.DataType, v.Value, canonical)
+				continue
+			}
+			if err := set.update(v.ID, canonical); err != nil {
+				return invalid, err
+			}
+			rewritten++
+		}
+	}
+
+	fmt.Printf("Checked %d values: %d invalid", len(propertyValues)+len(attributeValues), invalid)
+	if fix {
+		fmt.Printf(", %d rewritten in canonical form", rewritten)
+	}
+	fmt.Println()
+	return invalid, nil
+}
@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// Change holds the old and new value of a single field
+type Change struct {
+	Old interface{} `json:"Old"`
+	New interface{} `json:"New"`
+}
+
+// ignoredFields are bookkeeping fields that change on every write and carry no information
+var ignoredFields = map[string]bool{
+	"ID":        true,
+	"CreatedAt": true,
+	"UpdatedAt": true,
+	"DeletedAt": true,
+}
+
+// Diff compares two values by their JSON representation and returns the fields that differ.
+// A nil before describes a create, a nil after describes a delete. On updates, fields that only
+// one side carries (such as joined names) are not reported.
+func Diff(before, after interface{}) (map[string]Change, error) {
+	oldFields, hasOld, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, hasNew, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, oldValue := range oldFields {
+		newValue, exists := newFields[key]
+		if ignoredFields[key] || (hasNew && !exists) {
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes[key] = Change{Old: oldValue, New: newValue}
+		}
+	}
+	if !hasOld {
+		for key, newValue := range newFields {
+			if ignoredFields[key] {
+				continue
+			}
+			changes[key] = Change{Old: nil, New: newValue}
+		}
+	}
+
+	return changes, nil
+}
+
+// toFields flattens a value into its top-level JSON fields. It reports false for nil values.
+func toFields(v interface{}) (map[string]interface{}, bool, error) {
+	fields := make(map[string]interface{})
+	if v == nil {
+		return fields, false, nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return fields, false, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, false, err
+	}
+	return fields, true, nil
+}
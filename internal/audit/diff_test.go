@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func TestDiff_Create(t *testing.T) {
+	asset := &models.Asset{Name: "Laptop", SerialNumber: "SN1"}
+
+	changes, err := Diff(nil, asset)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if got := changes["Name"]; got.Old != nil || got.New != "Laptop" {
+		t.Errorf("Expected Name change nil -> Laptop, got %v -> %v", got.Old, got.New)
+	}
+	if _, exists := changes["CreatedAt"]; exists {
+		t.Error("Expected bookkeeping fields to be ignored")
+	}
+}
+
+func TestDiff_Update(t *testing.T) {
+	before := &models.Asset{Name: "Laptop", Model: "X1", AssetTypeName: "Laptops"}
+	after := &models.Asset{Name: "Laptop", Model: "X2"}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if len(changes) != 1 {
+		t.Fatalf("Expected exactly one change, got %v", changes)
+	}
+	if got := changes["Model"]; got.Old != "X1" || got.New != "X2" {
+		t.Errorf("Expected Model change X1 -> X2, got %v -> %v", got.Old, got.New)
+	}
+}
+
+func TestDiff_Delete(t *testing.T) {
+	var after *models.Person
+	before := &models.Person{Name: "Jane"}
+
+	changes, err := Diff(before, after)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+
+	if got := changes["Name"]; got.Old != "Jane" || got.New != nil {
+		t.Errorf("Expected Name change Jane -> nil, got %v -> %v", got.Old, got.New)
+	}
+}
+
+func TestRelatedAssetID(t *testing.T) {
+	ap := &models.AssetProperty{AssetID: 42, PropertyID: 7}
+
+	id := relatedAssetID(models.AuditEntityAssetProperty, 1, nil, ap)
+	if id == nil || *id != 42 {
+		t.Errorf("Expected asset ID 42, got %v", id)
+	}
+
+	id = relatedAssetID(models.AuditEntityAsset, 5)
+	if id == nil || *id != 5 {
+		t.Errorf("Expected asset ID 5, got %v", id)
+	}
+
+	if id := relatedAssetID(models.AuditEntityPerson, 3, &models.Person{Name: "Jane"}); id != nil {
+		t.Errorf("Expected no asset ID for persons, got %v", *id)
+	}
+}
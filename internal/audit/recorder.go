@@ -0,0 +1,119 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/middleware"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+const (
+	// writeAttempts is how often an entry is written before its failure is given up on
+	writeAttempts = 4
+	// retryDelay is the pause before the second attempt, doubled before every further one
+	retryDelay = 100 * time.Millisecond
+)
+
+// Recorder writes audit log entries for mutations performed through the API
+type Recorder struct {
+	repo *repository.AuditLogRepository
+}
+
+// NewRecorder creates a new audit recorder
+func NewRecorder(repo *repository.AuditLogRepository) *Recorder {
+	return &Recorder{repo: repo}
+}
+
+// RecordCreate records the creation of an entity
+func (r *Recorder) RecordCreate(c *gin.Context, entityType models.AuditEntityType, entityID int64, after interface{}) {
+	r.record(c, entityType, entityID, models.AuditActionCreate, nil, after)
+}
+
+// RecordUpdate records the update of an entity. Nothing is written if no field changed.
+func (r *Recorder) RecordUpdate(c *gin.Context, entityType models.AuditEntityType, entityID int64, before, after interface{}) {
+	r.record(c, entityType, entityID, models.AuditActionUpdate, before, after)
+}
+
+// RecordDelete records the deletion of an entity
+func (r *Recorder) RecordDelete(c *gin.Context, entityType models.AuditEntityType, entityID int64, before interface{}) {
+	r.record(c, entityType, entityID, models.AuditActionDelete, before, nil)
+}
+
+// RecordChanges records an action with an explicit set of changes, for mutations that are not
+// represented by a model (such as a password reset)
+func (r *Recorder) RecordChanges(c *gin.Context, entityType models.AuditEntityType, entityID int64, action models.AuditAction, changes map[string]Change) {
+	r.write(c, entityType, entityID, action, changes, relatedAssetID(entityType, entityID))
+}
+
+func (r *Recorder) record(c *gin.Context, entityType models.AuditEntityType, entityID int64, action models.AuditAction, before, after interface{}) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		log.Printf("audit: failed to diff %s %d: %v", entityType, entityID, err)
+		return
+	}
+	if action == models.AuditActionUpdate && len(changes) == 0 {
+		return
+	}
+
+	r.write(c, entityType, entityID, action, changes, relatedAssetID(entityType, entityID, before, after))
+}
+
+func (r *Recorder) write(c *gin.Context, entityType models.AuditEntityType, entityID int64, action models.AuditAction, changes map[string]Change, assetID *int64) {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		log.Printf("audit: failed to encode changes for %s %d: %v", entityType, entityID, err)
+		return
+	}
+
+	entry := &models.AuditLog{
+		Username:   middleware.GetUsername(c),
+		EntityType: entityType,
+		EntityID:   entityID,
+		AssetID:    assetID,
+		Action:     action,
+		Changes:    data,
+	}
+	if userID := middleware.GetUserID(c); userID != 0 {
+		entry.UserID = &userID
+	}
+
+	// The mutation has been committed, so a failed write is retried rather than failing a request
+	// that already succeeded
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err = r.repo.Create(context.Background(), entry)
+		if err == nil {
+			return
+		}
+		if attempt == writeAttempts {
+			log.Printf("audit: failed to record %s %s %d after %d attempts: %v (changes: %s)", action, entityType, entityID, attempt, err, data)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// relatedAssetID determines which asset an entry belongs to so it shows up in the asset changelog
+func relatedAssetID(entityType models.AuditEntityType, entityID int64, values ...interface{}) *int64 {
+	if entityType == models.AuditEntityAsset {
+		return &entityID
+	}
+	for _, v := range values {
+		fields, ok, err := toFields(v)
+		if err != nil || !ok {
+			continue
+		}
+		if id, ok := fields["AssetID"].(float64); ok && id != 0 {
+			assetID := int64(id)
+			return &assetID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// NewFeedKey returns a random key that grants read access to a feed, such as the calendar of an
+// asset, to clients that cannot send a bearer token
+func NewFeedKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// ValidFeedKey reports whether key matches the key of a feed, in constant time
+func ValidFeedKey(key, feedKey string) bool {
+	return key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(feedKey)) == 1
+}
@@ -16,8 +16,9 @@ var (
 
 // Claims represents JWT claims
 type Claims struct {
-	UserID   int64  `json:"UserID"`
-	Username string `json:"Username"`
+	UserID   int64       `json:"UserID"`
+	Username string      `json:"Username"`
+	Role     models.Role `json:"Role"`
 	jwt.RegisteredClaims
 }
 
@@ -47,6 +48,7 @@ func (s *JWTService) GenerateToken(user *models.User, remember bool) (string, in
 	claims := &Claims{
 		UserID:   user.ID,
 		Username: user.Username,
+		Role:     user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -14,6 +14,7 @@ func TestJWTService_GenerateAndValidate(t *testing.T) {
 	user := &models.User{
 		BaseModel: models.BaseModel{ID: 1},
 		Username:  "testuser",
+		Role:      models.RoleEditor,
 	}
 
 	// Generate token
@@ -43,6 +44,10 @@ func TestJWTService_GenerateAndValidate(t *testing.T) {
 	if claims.Username != user.Username {
 		t.Errorf("Expected Username %s, got %s", user.Username, claims.Username)
 	}
+
+	if claims.Role != user.Role {
+		t.Errorf("Expected Role %s, got %s", user.Role, claims.Role)
+	}
 }
 
 func TestJWTService_InvalidToken(t *testing.T) {
@@ -94,3 +99,26 @@ func TestJWTService_RememberMe(t *testing.T) {
 		t.Error("Expected remember token to have longer expiry")
 	}
 }
+
+func TestFeedKey(t *testing.T) {
+	key, err := NewFeedKey()
+	if err != nil {
+		t.Fatalf("Failed to generate feed key: %v", err)
+	}
+	if len(key) != 64 {
+		t.Errorf("Expected a key of 64 characters, got %d", len(key))
+	}
+	if !ValidFeedKey(key, key) {
+		t.Error("Expected the key to be valid for its feed")
+	}
+	other, err := NewFeedKey()
+	if err != nil {
+		t.Fatalf("Failed to generate feed key: %v", err)
+	}
+	if other == key || ValidFeedKey(other, key) {
+		t.Error("Expected another key to be invalid")
+	}
+	if ValidFeedKey("", "") {
+		t.Error("Expected an empty key to be invalid")
+	}
+}
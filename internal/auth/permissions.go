@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"assetManager/internal/models"
+)
+
+// Permission represents an action a route requires the caller to be allowed to perform
+type Permission string
+
+const (
+	// PermissionViewInventory allows reading assets, persons, assignments and configuration lists
+	PermissionViewInventory Permission = "inventory:view"
+	// PermissionEditInventory allows creating, updating and deleting assets, persons and assignments
+	PermissionEditInventory Permission = "inventory:edit"
+	// PermissionRunReports allows executing reports
+	PermissionRunReports Permission = "reports:run"
+	// PermissionManageConfig allows changing asset types, properties and attributes
+	PermissionManageConfig Permission = "config:manage"
+	// PermissionManageUsers allows managing application users
+	PermissionManageUsers Permission = "users:manage"
+	// PermissionViewAudit allows reading the audit log
+	PermissionViewAudit Permission = "audit:view"
+)
+
+// rolePermissions maps each role to the permissions it grants
+var rolePermissions = map[models.Role][]Permission{
+	models.RoleAdmin: {
+		PermissionViewInventory,
+		PermissionEditInventory,
+		PermissionRunReports,
+		PermissionManageConfig,
+		PermissionManageUsers,
+		PermissionViewAudit,
+	},
+	models.RoleEditor: {
+		PermissionViewInventory,
+		PermissionEditInventory,
+		PermissionRunReports,
+	},
+	models.RoleAuditor: {
+		PermissionViewInventory,
+		PermissionRunReports,
+		PermissionViewAudit,
+	},
+}
+
+// HasPermission reports whether the given role grants the permission
+func HasPermission(role models.Role, permission Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
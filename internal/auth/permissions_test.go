@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       models.Role
+		permission Permission
+		want       bool
+	}{
+		{"admin manages users", models.RoleAdmin, PermissionManageUsers, true},
+		{"admin manages config", models.RoleAdmin, PermissionManageConfig, true},
+		{"editor edits inventory", models.RoleEditor, PermissionEditInventory, true},
+		{"editor cannot manage config", models.RoleEditor, PermissionManageConfig, false},
+		{"editor cannot manage users", models.RoleEditor, PermissionManageUsers, false},
+		{"auditor views inventory", models.RoleAuditor, PermissionViewInventory, true},
+		{"auditor runs reports", models.RoleAuditor, PermissionRunReports, true},
+		{"auditor cannot edit inventory", models.RoleAuditor, PermissionEditInventory, false},
+		{"auditor views audit log", models.RoleAuditor, PermissionViewAudit, true},
+		{"editor cannot view audit log", models.RoleEditor, PermissionViewAudit, false},
+		{"unknown role has no permissions", models.Role(""), PermissionViewInventory, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.role, tt.permission); got != tt.want {
+				t.Errorf("HasPermission(%q, %q) = %v, want %v", tt.role, tt.permission, got, tt.want)
+			}
+		})
+	}
+}
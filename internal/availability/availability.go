@@ -0,0 +1,82 @@
+// Package availability checks whether a pool of interchangeable assets can serve the
+// reservations made for any asset of the pool.
+package availability
+
+import (
+	"sort"
+	"time"
+)
+
+// Interval is the half-open time range [Start, End)
+type Interval struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t lies within the interval
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Overlaps reports whether the intervals share any moment
+func (i Interval) Overlaps(o Interval) bool {
+	return i.Start.Before(o.End) && o.Start.Before(i.End)
+}
+
+// Shortfall returns the first moment within window at which the demand, the number of demand
+// intervals containing it, exceeds the assets of the pool not blocked at that moment. Blocked
+// holds the times each asset is unavailable, keyed by asset ID; assets missing from it are free
+// throughout. The check is per moment, so it does not ensure one asset can serve a demand
+// interval from start to end.
+func Shortfall(window Interval, assets []int64, blocked map[int64][]Interval, demand []Interval) (time.Time, bool) {
+	// Free and demanded counts only change where an interval starts or ends
+	points := []time.Time{window.Start}
+	add := func(i Interval) {
+		for _, t := range []time.Time{i.Start, i.End} {
+			if window.Contains(t) {
+				points = append(points, t)
+			}
+		}
+	}
+	for _, intervals := range blocked {
+		for _, i := range intervals {
+			add(i)
+		}
+	}
+	for _, i := range demand {
+		add(i)
+	}
+	sort.Slice(points, func(a, b int) bool { return points[a].Before(points[b]) })
+
+	for _, t := range points {
+		needed := 0
+		for _, i := range demand {
+			if i.Contains(t) {
+				needed++
+			}
+		}
+		if needed == 0 {
+			continue
+		}
+		free := 0
+		for _, id := range assets {
+			if !blockedAt(blocked[id], t) {
+				free++
+			}
+		}
+		if needed > free {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// blockedAt reports whether any of the intervals contains t
+func blockedAt(intervals []Interval, t time.Time) bool {
+	for _, i := range intervals {
+		if i.Contains(t) {
+			return true
+		}
+	}
+	return false
+}
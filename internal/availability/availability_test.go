@@ -0,0 +1,50 @@
+package availability
+
+import (
+	"testing"
+	"time"
+)
+
+func hour(h int) time.Time {
+	return time.Date(2026, 3, 2, h, 0, 0, 0, time.UTC)
+}
+
+func interval(from, to int) Interval {
+	return Interval{Start: hour(from), End: hour(to)}
+}
+
+func TestOverlaps(t *testing.T) {
+	if !interval(9, 12).Overlaps(interval(11, 14)) {
+		t.Error("Expected 9-12 to overlap 11-14")
+	}
+	if interval(9, 12).Overlaps(interval(12, 14)) {
+		t.Error("Expected adjacent intervals not to overlap")
+	}
+}
+
+func TestShortfall(t *testing.T) {
+	window := interval(9, 17)
+	assets := []int64{1, 2}
+
+	tests := []struct {
+		name    string
+		blocked map[int64][]Interval
+		demand  []Interval
+		want    time.Time
+		short   bool
+	}{
+		{"no demand", nil, nil, time.Time{}, false},
+		{"two of two", nil, []Interval{interval(9, 17), interval(10, 12)}, time.Time{}, false},
+		{"three of two", nil, []Interval{interval(9, 17), interval(10, 12), interval(11, 13)}, hour(11), true},
+		{"back to back", nil, []Interval{interval(9, 17), interval(10, 12), interval(12, 14)}, time.Time{}, false},
+		{"blocked asset", map[int64][]Interval{2: {interval(13, 20)}}, []Interval{interval(9, 17), interval(10, 14)}, hour(13), true},
+		{"blocked before demand", map[int64][]Interval{2: {interval(6, 10)}}, []Interval{interval(9, 17), interval(10, 14)}, time.Time{}, false},
+		{"demand outside window", nil, []Interval{interval(9, 17), interval(10, 12), interval(17, 19)}, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, short := Shortfall(window, assets, tt.blocked, tt.demand)
+		if short != tt.short || !got.Equal(tt.want) {
+			t.Errorf("%s: Shortfall = %v, %v, want %v, %v", tt.name, got, short, tt.want, tt.short)
+		}
+	}
+}
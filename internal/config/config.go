@@ -9,9 +9,16 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `yaml:"server"`
-	Database DatabaseConfig `yaml:"database"`
-	JWT      JWTConfig      `yaml:"jwt"`
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	JWT           JWTConfig           `yaml:"jwt"`
+	Lifecycle     LifecycleConfig     `yaml:"lifecycle"`
+	Alerts        AlertsConfig        `yaml:"alerts"`
+	Attachments   AttachmentsConfig   `yaml:"attachments"`
+	Labels        LabelsConfig        `yaml:"labels"`
+	Uniqueness    UniquenessConfig    `yaml:"uniqueness"`
+	Loans         LoansConfig         `yaml:"loans"`
+	Notifications NotificationsConfig `yaml:"notifications"`
 }
 
 type ServerConfig struct {
@@ -32,6 +39,80 @@ type JWTConfig struct {
 	ExpiryHours int    `yaml:"expiry_hours"`
 }
 
+// LifecycleConfig overrides the allowed asset status transitions. Each listed status replaces
+// the default list of statuses an asset may move to from it.
+type LifecycleConfig struct {
+	Transitions map[string][]string `yaml:"transitions"`
+}
+
+// AlertsConfig controls the daily job raising alerts for expiring warranties, support contracts
+// and leases
+type AlertsConfig struct {
+	Within string `yaml:"within"` // How far ahead to look, such as "30d", "2w" or "3m"
+	RunAt  string `yaml:"run_at"` // Time of day the job runs, as HH:MM
+}
+
+// AttachmentsConfig controls where uploaded files are stored and how large they may be
+type AttachmentsConfig struct {
+	MaxSizeMB int64              `yaml:"max_size_mb"`
+	Storage   string             `yaml:"storage"` // "local" or "s3"
+	Local     LocalStorageConfig `yaml:"local"`
+	S3        S3StorageConfig    `yaml:"s3"`
+}
+
+// LocalStorageConfig stores attachments in a directory on the server
+type LocalStorageConfig struct {
+	Path string `yaml:"path"`
+}
+
+// S3StorageConfig stores attachments in a bucket of AWS S3 or an S3-compatible store such as MinIO
+type S3StorageConfig struct {
+	Endpoint  string `yaml:"endpoint"` // Such as https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Region    string `yaml:"region"`
+	Bucket    string `yaml:"bucket"`
+	AccessKey string `yaml:"access_key"`
+	SecretKey string `yaml:"secret_key"`
+	PathStyle bool   `yaml:"path_style"` // Address the bucket in the URL path, as MinIO needs
+}
+
+// LabelsConfig controls the content of printed asset labels
+type LabelsConfig struct {
+	LookupURL string `yaml:"lookup_url"` // URL encoded in label barcodes, {id} is replaced by the asset ID
+}
+
+// UniquenessConfig lists the fields whose values must be unique among non-deleted records
+type UniquenessConfig struct {
+	Rules []UniquenessRule `yaml:"rules"`
+}
+
+// UniquenessRule makes a field of assets or persons unique
+type UniquenessRule struct {
+	Entity string `yaml:"entity"` // "asset" or "person"
+	Field  string `yaml:"field"`  // Column, such as serial_number or email
+	Scope  string `yaml:"scope"`  // "global" (the default) or "asset_type" for assets of the same type
+}
+
+// LoansConfig controls the daily job reminding borrowers of loans that are due soon or overdue
+type LoansConfig struct {
+	RemindBefore string `yaml:"remind_before"` // How long before the due date to remind, such as "1d"
+	RunAt        string `yaml:"run_at"`        // Time of day the job runs, as HH:MM
+}
+
+// NotificationsConfig selects how notifications such as loan reminders reach persons
+type NotificationsConfig struct {
+	Backend string     `yaml:"backend"` // "log" (the default) or "smtp"
+	SMTP    SMTPConfig `yaml:"smtp"`
+}
+
+// SMTPConfig sends notifications as mail through an SMTP server
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"` // Empty to send without authentication
+	Password string `yaml:"password"`
+	From     string `yaml:"from"` // Sender address, such as "Asset Manager <assets@example.com>"
+}
+
 func (d *DatabaseConfig) DSN() string {
 	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true",
 		d.User, d.Password, d.Host, d.Port, d.Name)
@@ -52,6 +133,32 @@ func DefaultConfig() *Config {
 		JWT: JWTConfig{
 			ExpiryHours: 24,
 		},
+		Alerts: AlertsConfig{
+			Within: "30d",
+			RunAt:  "06:00",
+		},
+		Attachments: AttachmentsConfig{
+			MaxSizeMB: 25,
+			Storage:   "local",
+			Local:     LocalStorageConfig{Path: "data/attachments"},
+		},
+		Labels: LabelsConfig{
+			LookupURL: "http://localhost:8085/#/assets/{id}",
+		},
+		Uniqueness: UniquenessConfig{
+			Rules: []UniquenessRule{
+				{Entity: "asset", Field: "serial_number", Scope: "asset_type"},
+				{Entity: "person", Field: "email"},
+			},
+		},
+		Loans: LoansConfig{
+			RemindBefore: "1d",
+			RunAt:        "08:00",
+		},
+		Notifications: NotificationsConfig{
+			Backend: "log",
+			SMTP:    SMTPConfig{Port: 587},
+		},
 	}
 }
 
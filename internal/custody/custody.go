@@ -0,0 +1,62 @@
+// Package custody reconstructs who held an asset over time from its assignment history.
+package custody
+
+import (
+	"sort"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+// Timeline orders the assignments of an asset into custody periods, inserting a gap wherever
+// nobody held the asset: between its creation at start and the first assignment, between
+// assignments, and after the last one ended if that was before now. A zero start begins the
+// timeline with the first assignment. Overlapping assignments leave no gap between them.
+func Timeline(start time.Time, assignments []models.AssetAssignment, now time.Time) []models.CustodyPeriod {
+	sorted := make([]models.AssetAssignment, len(assignments))
+	copy(sorted, assignments)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].EffectiveFrom.Time.Before(sorted[j].EffectiveFrom.Time)
+	})
+
+	periods := make([]models.CustodyPeriod, 0, len(sorted))
+	// held is the end of custody so far; open means some assignment has not ended
+	held, open := start, false
+	for i, aa := range sorted {
+		from := aa.EffectiveFrom.Time
+		if (i > 0 || !start.IsZero()) && !open && held.Before(from) {
+			periods = append(periods, models.CustodyPeriod{From: held, To: models.NewNullTime(from), Gap: true})
+		}
+		periods = append(periods, models.CustodyPeriod{
+			From:          from,
+			To:            aa.EffectiveTo,
+			AssignmentID:  aa.ID,
+			PersonID:      aa.PersonID,
+			PersonName:    aa.PersonName,
+			PersonIsStock: aa.PersonIsStock,
+			Notes:         aa.Notes,
+		})
+		switch {
+		case !aa.EffectiveTo.Valid:
+			open = true
+		case i == 0 && start.IsZero(), aa.EffectiveTo.Time.After(held):
+			held = aa.EffectiveTo.Time
+		}
+	}
+
+	if !open && (len(sorted) > 0 || !start.IsZero()) && held.Before(now) {
+		periods = append(periods, models.CustodyPeriod{From: held, Gap: true})
+	}
+	return periods
+}
+
+// Gaps returns the number of gaps in the periods
+func Gaps(periods []models.CustodyPeriod) int {
+	gaps := 0
+	for _, p := range periods {
+		if p.Gap {
+			gaps++
+		}
+	}
+	return gaps
+}
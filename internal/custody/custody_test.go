@@ -0,0 +1,90 @@
+package custody
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+func day(d int) time.Time {
+	return time.Date(2026, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func assignment(id int64, from, to int) models.AssetAssignment {
+	aa := models.AssetAssignment{BaseModel: models.BaseModel{ID: id}, PersonID: id * 10, EffectiveFrom: models.NewNullTime(day(from))}
+	if to > 0 {
+		aa.EffectiveTo = models.NewNullTime(day(to))
+	}
+	return aa
+}
+
+// summary describes each period as its assignment ID, or 0 for gaps, with its bounds
+type summary struct {
+	assignmentID int64
+	from, to     time.Time
+}
+
+func summarize(periods []models.CustodyPeriod) []summary {
+	result := make([]summary, len(periods))
+	for i, p := range periods {
+		result[i] = summary{p.AssignmentID, p.From, p.To.Time}
+	}
+	return result
+}
+
+func TestTimeline(t *testing.T) {
+	assignments := []models.AssetAssignment{
+		assignment(3, 10, 12),
+		assignment(1, 2, 5),
+		assignment(2, 5, 8),
+	}
+	periods := Timeline(day(1), assignments, day(20))
+	want := []summary{
+		{0, day(1), day(2)},
+		{1, day(2), day(5)},
+		{2, day(5), day(8)},
+		{0, day(8), day(10)},
+		{3, day(10), day(12)},
+		{0, day(12), time.Time{}},
+	}
+	if got := summarize(periods); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+	if gaps := Gaps(periods); gaps != 3 {
+		t.Errorf("Expected 3 gaps, got %d", gaps)
+	}
+	for _, p := range periods {
+		if p.Gap != (p.AssignmentID == 0) {
+			t.Errorf("Expected only periods without an assignment to be gaps, got %+v", p)
+		}
+	}
+}
+
+func TestTimelineOpenAndOverlapping(t *testing.T) {
+	assignments := []models.AssetAssignment{
+		assignment(1, 2, 9),
+		assignment(2, 4, 6), // Within the first assignment
+		assignment(3, 9, 0), // Still held
+	}
+	periods := Timeline(time.Time{}, assignments, day(20))
+	want := []summary{
+		{1, day(2), day(9)},
+		{2, day(4), day(6)},
+		{3, day(9), time.Time{}},
+	}
+	if got := summarize(periods); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestTimelineNeverAssigned(t *testing.T) {
+	periods := Timeline(day(1), nil, day(20))
+	if len(periods) != 1 || !periods[0].Gap || !periods[0].From.Equal(day(1)) || periods[0].To.Valid {
+		t.Errorf("Expected one open gap since creation, got %+v", periods)
+	}
+	if periods := Timeline(time.Time{}, nil, day(20)); len(periods) != 0 {
+		t.Errorf("Expected no periods without a start, got %+v", periods)
+	}
+}
@@ -0,0 +1,178 @@
+// Package datatype parses and compares property and attribute values, which are stored
+// as text, according to their declared models.DataType.
+package datatype
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+const (
+	// DateLayout is the canonical text form of date values
+	DateLayout = "2006-01-02"
+	// DatetimeLayout is the canonical text form of datetime values, as used by datetime-local inputs
+	DatetimeLayout = "2006-01-02T15:04:05"
+)
+
+// datetimeLayouts are the accepted datetime forms, including the one sent by datetime-local
+// inputs; a plain date means midnight
+var datetimeLayouts = []string{
+	DatetimeLayout,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	DateLayout,
+}
+
+// Parse converts a text value to int64, float64, bool, time.Time or string according to the data type
+func Parse(dataType models.DataType, raw string) (interface{}, error) {
+	value := strings.TrimSpace(raw)
+
+	switch dataType {
+	case models.DataTypeInt:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, invalid(dataType, raw)
+		}
+		return n, nil
+	case models.DataTypeDecimal:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, invalid(dataType, raw)
+		}
+		return f, nil
+	case models.DataTypeBoolean:
+		switch strings.ToLower(value) {
+		case "true", "1", "yes", "on":
+			return true, nil
+		case "false", "0", "no", "off":
+			return false, nil
+		}
+		return nil, invalid(dataType, raw)
+	case models.DataTypeDate:
+		t, err := time.Parse(DateLayout, value)
+		if err != nil {
+			return nil, invalid(dataType, raw)
+		}
+		return t, nil
+	case models.DataTypeDatetime:
+		for _, layout := range datetimeLayouts {
+			if t, err := time.Parse(layout, value); err == nil {
+				return t, nil
+			}
+		}
+		return nil, invalid(dataType, raw)
+	default:
+		return raw, nil
+	}
+}
+
+// Canonical validates a value against its data type and returns it in canonical text form:
+// integers and decimals without padding, booleans as true or false, dates as DateLayout and
+// datetimes as DatetimeLayout in UTC. Enum values must be one of the JSON encoded options.
+// Empty values are valid and stay empty.
+func Canonical(dataType models.DataType, enumOptions, raw string) (string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return "", nil
+	}
+
+	if dataType == models.DataTypeEnum {
+		options, err := EnumOptions(enumOptions)
+		if err != nil {
+			return "", err
+		}
+		for _, option := range options {
+			if option == raw {
+				return raw, nil
+			}
+		}
+		return "", fmt.Errorf("%q is not one of the allowed options %s", raw, strings.Join(options, ", "))
+	}
+
+	value, err := Parse(dataType, raw)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := value.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		if dataType == models.DataTypeDate {
+			return v.Format(DateLayout), nil
+		}
+		// DatetimeLayout has no offset, so datetimes given with one are converted to UTC first
+		return v.UTC().Format(DatetimeLayout), nil
+	default:
+		return raw, nil
+	}
+}
+
+// EnumOptions decodes the JSON array of options of an enum property or attribute
+func EnumOptions(enumOptions string) ([]string, error) {
+	if strings.TrimSpace(enumOptions) == "" {
+		return nil, nil
+	}
+	var options []string
+	if err := json.Unmarshal([]byte(enumOptions), &options); err != nil {
+		return nil, fmt.Errorf("enum options are not a JSON array of strings: %v", err)
+	}
+	return options, nil
+}
+
+// Compare orders two parsed values of the same data type, returning -1, 0 or 1.
+// Strings compare case-insensitively.
+func Compare(a, b interface{}) int {
+	switch x := a.(type) {
+	case int64:
+		if y, ok := b.(int64); ok {
+			return compareOrdered(x, y)
+		}
+	case float64:
+		if y, ok := b.(float64); ok {
+			return compareOrdered(x, y)
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			return compareOrdered(boolRank(x), boolRank(y))
+		}
+	case time.Time:
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return compareOrdered(strings.ToLower(fmt.Sprint(a)), strings.ToLower(fmt.Sprint(b)))
+}
+
+func invalid(dataType models.DataType, raw string) error {
+	return fmt.Errorf("invalid %s value %q", dataType, raw)
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func compareOrdered[T int | int64 | float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,94 @@
+package datatype
+
+import (
+	"testing"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		dataType models.DataType
+		raw      string
+		want     interface{}
+		wantErr  bool
+	}{
+		{models.DataTypeInt, " 16 ", int64(16), false},
+		{models.DataTypeInt, "16GB", nil, true},
+		{models.DataTypeDecimal, "2.5", 2.5, false},
+		{models.DataTypeDecimal, "NaN", nil, true},
+		{models.DataTypeDecimal, "Inf", nil, true},
+		{models.DataTypeDecimal, "-infinity", nil, true},
+		{models.DataTypeBoolean, "Yes", true, false},
+		{models.DataTypeBoolean, "maybe", nil, true},
+		{models.DataTypeDate, "2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), false},
+		{models.DataTypeDate, "15/01/2024", nil, true},
+		{models.DataTypeDatetime, "2024-01-15T10:30", time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC), false},
+		{models.DataTypeString, "anything", "anything", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.dataType, tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%s, %q) error = %v, wantErr %v", tt.dataType, tt.raw, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && Compare(got, tt.want) != 0 {
+			t.Errorf("Parse(%s, %q) = %v, want %v", tt.dataType, tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	sixteen, _ := Parse(models.DataTypeInt, "16")
+	eight, _ := Parse(models.DataTypeInt, "8")
+	if Compare(sixteen, eight) != 1 {
+		t.Error("Expected 16 > 8 when compared as int")
+	}
+
+	feb, _ := Parse(models.DataTypeDate, "2024-02-01")
+	dec, _ := Parse(models.DataTypeDate, "2023-12-31")
+	if Compare(feb, dec) != 1 {
+		t.Error("Expected 2024-02-01 after 2023-12-31")
+	}
+
+	if Compare("apple", "Banana") != -1 {
+		t.Error("Expected strings to compare case-insensitively")
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		dataType models.DataType
+		raw      string
+		want     string
+		wantErr  bool
+	}{
+		{models.DataTypeInt, "007", "7", false},
+		{models.DataTypeInt, "banana", "", true},
+		{models.DataTypeDecimal, "2.50", "2.5", false},
+		{models.DataTypeDecimal, "infinity", "", true},
+		{models.DataTypeBoolean, "Yes", "true", false},
+		{models.DataTypeDate, "2024-01-15", "2024-01-15", false},
+		{models.DataTypeDate, "2024-02-30", "", true},
+		{models.DataTypeDatetime, "2024-01-15 10:30", "2024-01-15T10:30:00", false},
+		{models.DataTypeDatetime, "2024-01-15T10:30:00+02:00", "2024-01-15T08:30:00", false},
+		{models.DataTypeDatetime, "2024-01-15T23:30:00-05:00", "2024-01-16T04:30:00", false},
+		{models.DataTypeEnum, "Blue", "Blue", false},
+		{models.DataTypeEnum, "Green", "", true},
+		{models.DataTypeString, " as is ", " as is ", false},
+		{models.DataTypeInt, "  ", "", false},
+	}
+
+	for _, tt := range tests {
+		got, err := Canonical(tt.dataType, `["Red", "Blue"]`, tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Canonical(%s, %q) error = %v, wantErr %v", tt.dataType, tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Canonical(%s, %q) = %q, want %q", tt.dataType, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package depreciation calculates how the value of assets decreases over their useful life.
+package depreciation
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+// Schedule describes the depreciation of one asset. Depreciation is charged monthly from the
+// start date and the asset is worth nothing at the end of its useful life.
+type Schedule struct {
+	Method           models.DepreciationMethod
+	Cost             float64
+	UsefulLifeMonths int
+	Start            time.Time
+}
+
+// MonthsElapsed returns the number of full months from start to asOf, or 0 if asOf is before
+// start. A month ending on a day the later month does not have ends on its last day.
+func MonthsElapsed(start, asOf time.Time) int {
+	months := (asOf.Year()-start.Year())*12 + int(asOf.Month()) - int(start.Month())
+	if asOf.Day() < start.Day() && asOf.Day() != daysIn(asOf) {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	return months
+}
+
+// daysIn returns the number of days in the month of t
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// monthlyCharges returns the depreciation charged in each month of the useful life, in cents.
+// The charges always add up to the cost.
+func (s Schedule) monthlyCharges() []int64 {
+	if s.UsefulLifeMonths <= 0 {
+		return nil
+	}
+	cost := int64(math.Round(s.Cost * 100))
+	life := int64(s.UsefulLifeMonths)
+	charges := make([]int64, life)
+	remaining := cost
+	for m := int64(0); m < life; m++ {
+		var charge int64
+		switch s.Method {
+		case models.DepreciationDecliningBalance:
+			// Double-declining balance, switching to straight-line over the remaining months once
+			// that charges more so the asset is fully depreciated at the end of its life
+			declining := int64(math.Round(float64(remaining) * 2 / float64(life)))
+			straight := int64(math.Round(float64(remaining) / float64(life-m)))
+			charge = max(declining, straight)
+		default:
+			charge = cost*(m+1)/life - cost*m/life
+		}
+		if m == life-1 || charge > remaining {
+			charge = remaining
+		}
+		charges[m] = charge
+		remaining -= charge
+	}
+	return charges
+}
+
+// Accumulated returns the total depreciation charged up to asOf
+func (s Schedule) Accumulated(asOf time.Time) float64 {
+	charges := s.monthlyCharges()
+	elapsed := min(MonthsElapsed(s.Start, asOf), len(charges))
+	var total int64
+	for _, charge := range charges[:elapsed] {
+		total += charge
+	}
+	return float64(total) / 100
+}
+
+// Years returns the depreciation charged in each year of the useful life, counted from the start
+// date, with the book value at the end of the year
+func (s Schedule) Years() []models.DepreciationYear {
+	charges := s.monthlyCharges()
+	cost := int64(math.Round(s.Cost * 100))
+	var years []models.DepreciationYear
+	for from := 0; from < len(charges); from += 12 {
+		to := min(from+12, len(charges))
+		var charged int64
+		for _, charge := range charges[from:to] {
+			charged += charge
+		}
+		cost -= charged
+		years = append(years, models.DepreciationYear{
+			Year:         from/12 + 1,
+			EndsAt:       s.Start.AddDate(0, to, 0),
+			Depreciation: float64(charged) / 100,
+			BookValue:    float64(cost) / 100,
+		})
+	}
+	return years
+}
+
+// For returns the schedule of an asset from its cost, purchase date and the depreciation method
+// and useful life of its type
+func For(bv *models.BookValue) Schedule {
+	return Schedule{
+		Method:           bv.Method,
+		Cost:             bv.PurchaseCost,
+		UsefulLifeMonths: bv.UsefulLifeMonths,
+		Start:            bv.PurchasedAt.Time,
+	}
+}
+
+// Apply calculates the book value of an asset as of a date
+func Apply(bv *models.BookValue, asOf time.Time) {
+	s := For(bv)
+	bv.AsOf = asOf
+	bv.MonthsElapsed = MonthsElapsed(s.Start, asOf)
+	bv.AccumulatedDepreciation = s.Accumulated(asOf)
+	bv.BookValue = round(bv.PurchaseCost - bv.AccumulatedDepreciation)
+	bv.FullyDepreciated = s.UsefulLifeMonths > 0 && bv.MonthsElapsed >= s.UsefulLifeMonths
+}
+
+// Summarize totals book values by asset type and currency, ordered by asset type name
+func Summarize(values []models.BookValue) []models.DepreciationSummary {
+	type key struct {
+		assetTypeID int64
+		currency    string
+	}
+	type totals struct {
+		summary                    models.DepreciationSummary
+		cost, accumulated, current int64
+	}
+	groups := make(map[key]*totals)
+	var order []key
+	for _, bv := range values {
+		k := key{bv.AssetTypeID, bv.Currency}
+		g, ok := groups[k]
+		if !ok {
+			g = &totals{summary: models.DepreciationSummary{
+				AssetTypeID:   bv.AssetTypeID,
+				AssetTypeName: bv.AssetTypeName,
+				Currency:      bv.Currency,
+			}}
+			groups[k] = g
+			order = append(order, k)
+		}
+		g.summary.AssetCount++
+		g.cost += cents(bv.PurchaseCost)
+		g.accumulated += cents(bv.AccumulatedDepreciation)
+		g.current += cents(bv.BookValue)
+	}
+
+	summaries := make([]models.DepreciationSummary, 0, len(order))
+	for _, k := range order {
+		g := groups[k]
+		g.summary.PurchaseCost = float64(g.cost) / 100
+		g.summary.AccumulatedDepreciation = float64(g.accumulated) / 100
+		g.summary.BookValue = float64(g.current) / 100
+		summaries = append(summaries, g.summary)
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].AssetTypeName != summaries[j].AssetTypeName {
+			return summaries[i].AssetTypeName < summaries[j].AssetTypeName
+		}
+		return summaries[i].Currency < summaries[j].Currency
+	})
+	return summaries
+}
+
+// cents converts an amount to cents
+func cents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// round rounds an amount to cents
+func round(amount float64) float64 {
+	return float64(cents(amount)) / 100
+}
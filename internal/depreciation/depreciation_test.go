@@ -0,0 +1,100 @@
+package depreciation
+
+import (
+	"testing"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestMonthsElapsed(t *testing.T) {
+	tests := []struct {
+		start, asOf time.Time
+		want        int
+	}{
+		{date(2024, 1, 15), date(2024, 1, 20), 0},
+		{date(2024, 1, 15), date(2024, 2, 14), 0},
+		{date(2024, 1, 15), date(2024, 2, 15), 1},
+		{date(2024, 1, 15), date(2025, 1, 15), 12},
+		{date(2024, 1, 31), date(2024, 2, 29), 1},
+		{date(2024, 1, 31), date(2024, 2, 28), 0},
+		{date(2024, 3, 1), date(2024, 1, 1), 0},
+	}
+	for _, tt := range tests {
+		if got := MonthsElapsed(tt.start, tt.asOf); got != tt.want {
+			t.Errorf("MonthsElapsed(%s, %s) = %d, want %d", tt.start.Format("2006-01-02"), tt.asOf.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+func TestStraightLine(t *testing.T) {
+	s := Schedule{Method: models.DepreciationStraightLine, Cost: 1000, UsefulLifeMonths: 36, Start: date(2024, 1, 1)}
+
+	if got := s.Accumulated(date(2024, 1, 31)); got != 0 {
+		t.Errorf("Expected no depreciation in the first month, got %v", got)
+	}
+	if got := s.Accumulated(date(2025, 1, 1)); got != 333.33 {
+		t.Errorf("Expected 333.33 after one year, got %v", got)
+	}
+	if got := s.Accumulated(date(2030, 1, 1)); got != 1000 {
+		t.Errorf("Expected the full cost after the useful life, got %v", got)
+	}
+
+	years := s.Years()
+	if len(years) != 3 {
+		t.Fatalf("Expected 3 years, got %d", len(years))
+	}
+	if years[2].BookValue != 0 || !years[2].EndsAt.Equal(date(2027, 1, 1)) {
+		t.Errorf("Expected the last year to end at 0 on 2027-01-01, got %+v", years[2])
+	}
+}
+
+func TestDecliningBalance(t *testing.T) {
+	s := Schedule{Method: models.DepreciationDecliningBalance, Cost: 1200, UsefulLifeMonths: 12, Start: date(2024, 1, 1)}
+
+	if got := s.Accumulated(date(2024, 2, 1)); got != 200 {
+		t.Errorf("Expected twice the straight-line charge in the first month, got %v", got)
+	}
+	straight := Schedule{Method: models.DepreciationStraightLine, Cost: 1200, UsefulLifeMonths: 12, Start: date(2024, 1, 1)}
+	if s.Accumulated(date(2024, 7, 1)) <= straight.Accumulated(date(2024, 7, 1)) {
+		t.Error("Expected declining balance to depreciate faster than straight-line early on")
+	}
+	if got := s.Accumulated(date(2025, 1, 1)); got != 1200 {
+		t.Errorf("Expected the full cost after the useful life, got %v", got)
+	}
+}
+
+func TestNoUsefulLife(t *testing.T) {
+	bv := models.BookValue{PurchaseCost: 500, PurchasedAt: models.NewNullTime(date(2020, 1, 1))}
+	Apply(&bv, date(2024, 1, 1))
+	if bv.BookValue != 500 || bv.AccumulatedDepreciation != 0 || bv.FullyDepreciated {
+		t.Errorf("Expected assets without a useful life to keep their cost, got %+v", bv)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	values := []models.BookValue{
+		{AssetTypeID: 2, AssetTypeName: "Phone", Currency: "EUR", PurchaseCost: 300, AccumulatedDepreciation: 100, BookValue: 200},
+		{AssetTypeID: 1, AssetTypeName: "Laptop", Currency: "EUR", PurchaseCost: 1000.10, AccumulatedDepreciation: 0.20, BookValue: 999.90},
+		{AssetTypeID: 1, AssetTypeName: "Laptop", Currency: "EUR", PurchaseCost: 1000.20, AccumulatedDepreciation: 0.10, BookValue: 1000.10},
+		{AssetTypeID: 1, AssetTypeName: "Laptop", Currency: "USD", PurchaseCost: 900, AccumulatedDepreciation: 0, BookValue: 900},
+	}
+	summaries := Summarize(values)
+	if len(summaries) != 3 {
+		t.Fatalf("Expected 3 groups, got %d", len(summaries))
+	}
+	first := summaries[0]
+	if first.AssetTypeName != "Laptop" || first.Currency != "EUR" || first.AssetCount != 2 {
+		t.Errorf("Unexpected first group %+v", first)
+	}
+	if first.PurchaseCost != 2000.30 || first.AccumulatedDepreciation != 0.30 || first.BookValue != 2000 {
+		t.Errorf("Expected totals without rounding errors, got %+v", first)
+	}
+	if summaries[1].Currency != "USD" || summaries[2].AssetTypeName != "Phone" {
+		t.Errorf("Expected groups ordered by type and currency, got %+v", summaries)
+	}
+}
@@ -0,0 +1,41 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+
+	"assetManager/internal/models"
+)
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []models.ReportColumn
+	record  []string
+}
+
+func newCSVWriter(w io.Writer, columns []models.ReportColumn) (*csvWriter, error) {
+	cw := &csvWriter{
+		w:       csv.NewWriter(w),
+		columns: columns,
+		record:  make([]string, len(columns)),
+	}
+	for i, col := range columns {
+		cw.record[i] = col.Label
+	}
+	if err := cw.w.Write(cw.record); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (cw *csvWriter) WriteRow(row map[string]interface{}) error {
+	for i, col := range cw.columns {
+		cw.record[i] = FormatValue(row[col.Key])
+	}
+	return cw.w.Write(cw.record)
+}
+
+func (cw *csvWriter) Close() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
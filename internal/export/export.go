@@ -0,0 +1,168 @@
+package export
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+// Format identifies the file format of an export
+type Format string
+
+const (
+	FormatCSV    Format = "csv"
+	FormatXLSX   Format = "xlsx"
+	FormatNDJSON Format = "ndjson"
+	FormatPDF    Format = "pdf"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format, must be csv, xlsx, ndjson or pdf")
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case FormatCSV, FormatXLSX, FormatNDJSON, FormatPDF:
+		return f, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatPDF:
+		return "application/pdf"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// Filename builds a download file name for a report
+func (f Format) Filename(name string) string {
+	return fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), f)
+}
+
+// Writer writes report rows in a specific format. The header is written when the writer is created.
+type Writer interface {
+	WriteRow(row map[string]interface{}) error
+	Close() error
+}
+
+// NewWriter creates a writer for the format that writes to w
+func NewWriter(format Format, w io.Writer, title string, columns []models.ReportColumn) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w, columns)
+	case FormatXLSX:
+		return newXLSXWriter(w, title, columns)
+	case FormatNDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FormatPDF:
+		return newPDFWriter(w, title, columns), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Write writes all rows in the format
+func Write(format Format, w io.Writer, title string, columns []models.ReportColumn, rows []map[string]interface{}) error {
+	writer, err := NewWriter(format, w, title, columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// ResolveColumns returns the base columns followed by the prop_ and attr_ columns. Custom
+// columns found in the rows but not in base are appended in name order, so the column
+// layout does not depend on the order of the rows.
+func ResolveColumns(base []models.ReportColumn, rows []map[string]interface{}) []models.ReportColumn {
+	known := make(map[string]bool, len(base))
+	for _, col := range base {
+		known[col.Key] = true
+	}
+
+	extra := make(map[string]bool)
+	for _, row := range rows {
+		for key := range row {
+			if !known[key] && IsCustomColumn(key) {
+				extra[key] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		// Properties before attributes, then by name
+		pi, pj := strings.HasPrefix(keys[i], "prop_"), strings.HasPrefix(keys[j], "prop_")
+		if pi != pj {
+			return pi
+		}
+		return strings.ToLower(keys[i]) < strings.ToLower(keys[j])
+	})
+
+	columns := append([]models.ReportColumn{}, base...)
+	for _, key := range keys {
+		columns = append(columns, models.ReportColumn{Key: key, Label: CustomColumnLabel(key)})
+	}
+	return columns
+}
+
+// IsCustomColumn reports whether a result key holds a property or attribute value
+func IsCustomColumn(key string) bool {
+	return strings.HasPrefix(key, "prop_") || strings.HasPrefix(key, "attr_")
+}
+
+// CustomColumnLabel returns the display name of a prop_ or attr_ column
+func CustomColumnLabel(key string) string {
+	if i := strings.Index(key, "_"); i >= 0 && IsCustomColumn(key) {
+		return key[i+1:]
+	}
+	return key
+}
+
+// FormatValue renders a result value as text
+func FormatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		if val.Hour() == 0 && val.Minute() == 0 && val.Second() == 0 && val.Nanosecond() == 0 {
+			return val.Format("2006-01-02")
+		}
+		return val.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
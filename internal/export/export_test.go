@@ -0,0 +1,109 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"assetManager/internal/models"
+)
+
+var testColumns = []models.ReportColumn{
+	{Key: "id", Label: "ID"},
+	{Key: "name", Label: "Name"},
+	{Key: "purchased_at", Label: "Purchased At"},
+}
+
+var testRows = []map[string]interface{}{
+	{"id": int64(2), "name": "ThinkPad", "purchased_at": time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), "prop_RAM": "16"},
+	{"id": int64(1), "name": "Pixel", "purchased_at": nil, "attr_Department": "IT"},
+}
+
+func TestResolveColumns(t *testing.T) {
+	columns := ResolveColumns(testColumns, testRows)
+
+	var keys []string
+	for _, col := range columns {
+		keys = append(keys, col.Key)
+	}
+	want := "id,name,purchased_at,prop_RAM,attr_Department"
+	if got := strings.Join(keys, ","); got != want {
+		t.Errorf("Expected columns %s, got %s", want, got)
+	}
+	if label := columns[3].Label; label != "RAM" {
+		t.Errorf("Expected display name RAM, got %s", label)
+	}
+}
+
+func TestWrite_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(FormatCSV, &buf, "Assets", testColumns, testRows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := "ID,Name,Purchased At\n2,ThinkPad,2024-01-15\n1,Pixel,\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestWrite_NDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(FormatNDJSON, &buf, "Assets", testColumns, testRows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := `{"id":2,"name":"ThinkPad","purchased_at":"2024-01-15"}` + "\n" +
+		`{"id":1,"name":"Pixel","purchased_at":null}` + "\n"
+	if buf.String() != want {
+		t.Errorf("Expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestWrite_XLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(FormatXLSX, &buf, "Assets", testColumns, testRows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatalf("Failed to open workbook: %v", err)
+	}
+	defer f.Close()
+
+	rows, err := f.GetRows("Assets")
+	if err != nil {
+		t.Fatalf("Failed to read rows: %v", err)
+	}
+	if len(rows) != 3 || rows[0][2] != "Purchased At" || rows[1][1] != "ThinkPad" {
+		t.Errorf("Unexpected rows %v", rows)
+	}
+}
+
+func TestWrite_PDF(t *testing.T) {
+	var rows []map[string]interface{}
+	for i := 0; i < 200; i++ {
+		rows = append(rows, testRows[i%2])
+	}
+
+	var buf bytes.Buffer
+	if err := Write(FormatPDF, &buf, "Assets", testColumns, rows); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Error("Expected a PDF document")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("XLSX"); err != nil || f != FormatXLSX {
+		t.Errorf("Expected xlsx, got %q (%v)", f, err)
+	}
+	if _, err := ParseFormat("xml"); err != ErrUnsupportedFormat {
+		t.Errorf("Expected unsupported format error, got %v", err)
+	}
+}
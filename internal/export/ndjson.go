@@ -0,0 +1,50 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+// ndjsonWriter writes one JSON object per line with keys in column order
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns []models.ReportColumn
+}
+
+func newNDJSONWriter(w io.Writer, columns []models.ReportColumn) *ndjsonWriter {
+	return &ndjsonWriter{w: bufio.NewWriter(w), columns: columns}
+}
+
+func (nw *ndjsonWriter) WriteRow(row map[string]interface{}) error {
+	nw.w.WriteByte('{')
+	for i, col := range nw.columns {
+		if i > 0 {
+			nw.w.WriteByte(',')
+		}
+		key, err := json.Marshal(col.Key)
+		if err != nil {
+			return err
+		}
+		value := row[col.Key]
+		if t, ok := value.(time.Time); ok {
+			value = FormatValue(t)
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		nw.w.Write(key)
+		nw.w.WriteByte(':')
+		nw.w.Write(data)
+	}
+	nw.w.WriteString("}\n")
+	return nil
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.w.Flush()
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"io"
+
+	"github.com/go-pdf/fpdf"
+
+	"assetManager/internal/models"
+)
+
+const (
+	pdfFontSize    = 7
+	pdfRowHeight   = 5
+	pdfMinColWidth = 12
+	pdfMaxColWidth = 60
+)
+
+// pdfWriter renders rows as a landscape table. Rows are buffered because column widths
+// depend on the content; the document is generated on Close.
+type pdfWriter struct {
+	w       io.Writer
+	title   string
+	columns []models.ReportColumn
+	rows    [][]string
+}
+
+func newPDFWriter(w io.Writer, title string, columns []models.ReportColumn) *pdfWriter {
+	return &pdfWriter{w: w, title: title, columns: columns}
+}
+
+func (pw *pdfWriter) WriteRow(row map[string]interface{}) error {
+	values := make([]string, len(pw.columns))
+	for i, col := range pw.columns {
+		values[i] = FormatValue(row[col.Key])
+	}
+	pw.rows = append(pw.rows, values)
+	return nil
+}
+
+func (pw *pdfWriter) Close() error {
+	pdf := fpdf.New("L", "mm", "A4", "")
+	pdf.SetTitle(pw.title, true)
+	pdf.SetMargins(10, 10, 10)
+	pdf.SetAutoPageBreak(false, 10)
+	// Core fonts use cp1252, so UTF-8 text has to be translated
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	widths := pw.columnWidths(pdf, tr, pageWidth-left-right)
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", pdfFontSize)
+		pdf.SetFillColor(230, 230, 230)
+		for i, col := range pw.columns {
+			pdf.CellFormat(widths[i], pdfRowHeight, fit(pdf, tr(col.Label), widths[i]), "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", pdfFontSize)
+	}
+
+	pdf.AddPage()
+	if pw.title != "" {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 8, tr(pw.title), "", 1, "L", false, 0, "")
+	}
+	header()
+
+	for _, row := range pw.rows {
+		// Start a new page with a repeated header when the row no longer fits
+		if pdf.GetY()+pdfRowHeight > pageHeight-bottom {
+			pdf.AddPage()
+			header()
+		}
+		for i, value := range row {
+			pdf.CellFormat(widths[i], pdfRowHeight, fit(pdf, tr(value), widths[i]), "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(pw.w)
+}
+
+// columnWidths sizes columns by their widest value and scales them to the available width
+func (pw *pdfWriter) columnWidths(pdf *fpdf.Fpdf, tr func(string) string, available float64) []float64 {
+	widths := make([]float64, len(pw.columns))
+	if len(widths) == 0 {
+		return widths
+	}
+
+	pdf.SetFont("Helvetica", "B", pdfFontSize)
+	for i, col := range pw.columns {
+		widths[i] = pdf.GetStringWidth(tr(col.Label)) + 2
+	}
+	pdf.SetFont("Helvetica", "", pdfFontSize)
+	for _, row := range pw.rows {
+		for i, value := range row {
+			if w := pdf.GetStringWidth(tr(value)) + 2; w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	total := 0.0
+	for i := range widths {
+		widths[i] = max(pdfMinColWidth, min(pdfMaxColWidth, widths[i]))
+		total += widths[i]
+	}
+	scale := available / total
+	for i := range widths {
+		widths[i] *= scale
+	}
+	return widths
+}
+
+// fit truncates translated (single byte) text so that it fits into a cell of the given width
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	limit := width - 2
+	if pdf.GetStringWidth(text) <= limit {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > limit {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
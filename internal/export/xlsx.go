@@ -0,0 +1,95 @@
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"assetManager/internal/models"
+)
+
+// xlsxWriter streams rows into a worksheet; the workbook is written out on Close
+type xlsxWriter struct {
+	w       io.Writer
+	file    *excelize.File
+	stream  *excelize.StreamWriter
+	columns []models.ReportColumn
+	row     int
+}
+
+func newXLSXWriter(w io.Writer, title string, columns []models.ReportColumn) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	sheet := sheetName(title)
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	bold, err := file.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	header := make([]interface{}, len(columns))
+	for i, col := range columns {
+		header[i] = excelize.Cell{StyleID: bold, Value: col.Label}
+	}
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxWriter{w: w, file: file, stream: stream, columns: columns, row: 1}, nil
+}
+
+func (xw *xlsxWriter) WriteRow(row map[string]interface{}) error {
+	xw.row++
+	values := make([]interface{}, len(xw.columns))
+	for i, col := range xw.columns {
+		switch v := row[col.Key].(type) {
+		case int64, float64, bool:
+			values[i] = v
+		case time.Time:
+			values[i] = FormatValue(v)
+		default:
+			values[i] = FormatValue(v)
+		}
+	}
+	cell, err := excelize.CoordinatesToCellName(1, xw.row)
+	if err != nil {
+		return err
+	}
+	return xw.stream.SetRow(cell, values)
+}
+
+func (xw *xlsxWriter) Close() error {
+	defer xw.file.Close()
+	if err := xw.stream.Flush(); err != nil {
+		return err
+	}
+	return xw.file.Write(xw.w)
+}
+
+// sheetName makes a title usable as a worksheet name
+func sheetName(title string) string {
+	if title == "" {
+		return "Report"
+	}
+	invalid := map[rune]bool{':': true, '\\': true, '/': true, '?': true, '*': true, '[': true, ']': true}
+	var name []rune
+	for _, r := range title {
+		if !invalid[r] {
+			name = append(name, r)
+		}
+	}
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	return string(name)
+}
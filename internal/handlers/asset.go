@@ -2,26 +2,51 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/middleware"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
+	"assetManager/internal/validation"
 )
 
 // AssetHandler handles asset endpoints
 type AssetHandler struct {
-	repo         *repository.AssetRepository
-	propertyRepo *repository.AssetPropertyRepository
+	repo            *repository.AssetRepository
+	propertyRepo    *repository.AssetPropertyRepository
+	propertyDefRepo *repository.PropertyRepository
+	schemaRepo      *repository.AssetTypePropertyRepository
+	transitions     lifecycle.Transitions
+	unique          uniqueness.Rules
+	recorder        *audit.Recorder
+}
+
+// assetRequest is the body of asset create and update requests. Properties optionally carries
+// property values to write together with the asset; an empty value clears a property.
+type assetRequest struct {
+	models.Asset
+	Properties []models.AssetProperty `json:"Properties"`
 }
 
 // NewAssetHandler creates a new asset handler
-func NewAssetHandler(repo *repository.AssetRepository, propertyRepo *repository.AssetPropertyRepository) *AssetHandler {
+func NewAssetHandler(repo *repository.AssetRepository, propertyRepo *repository.AssetPropertyRepository, propertyDefRepo *repository.PropertyRepository, schemaRepo *repository.AssetTypePropertyRepository, transitions lifecycle.Transitions, unique uniqueness.Rules, recorder *audit.Recorder) *AssetHandler {
 	return &AssetHandler{
-		repo:         repo,
-		propertyRepo: propertyRepo,
+		repo:            repo,
+		propertyRepo:    propertyRepo,
+		propertyDefRepo: propertyDefRepo,
+		schemaRepo:      schemaRepo,
+		transitions:     transitions,
+		unique:          unique,
+		recorder:        recorder,
 	}
 }
 
@@ -40,10 +65,21 @@ func (h *AssetHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, assets)
 }
 
-// GetWithAssignments returns all assets with current assignment info
+// GetWithAssignments returns all assets with current assignment info, optionally only those in
+// the comma-separated statuses of the status query parameter. With as_of the assets, their status
+// and assignment are reconstructed as they were at that time.
 func (h *AssetHandler) GetWithAssignments(c *gin.Context) {
 	includeDeleted := c.Query("include_deleted") == "true"
-	assets, err := h.repo.GetWithCurrentAssignment(context.Background(), includeDeleted)
+	statuses, err := parseStatuses(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid status filter: " + err.Error()})
+		return
+	}
+	asOf, ok := parseAsOfTime(c)
+	if !ok {
+		return
+	}
+	assets, err := h.repo.GetWithCurrentAssignment(context.Background(), includeDeleted, statuses, asOf)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assets"})
 		return
@@ -71,6 +107,27 @@ func (h *AssetHandler) GetByID(c *gin.Context) {
 	c.JSON(http.StatusOK, asset)
 }
 
+// GetByTag returns the asset with a tag, for lookups by barcode scanners. The tag is matched
+// case-insensitively and may contain slashes.
+func (h *AssetHandler) GetByTag(c *gin.Context) {
+	tag := strings.TrimSpace(strings.TrimPrefix(c.Param("tag"), "/"))
+	if tag == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Tag required"})
+		return
+	}
+
+	asset, err := h.repo.GetByTag(context.Background(), tag)
+	if err == repository.ErrAssetNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch asset"})
+		return
+	}
+	c.JSON(http.StatusOK, asset)
+}
+
 // GetByAssetType returns assets by asset type
 func (h *AssetHandler) GetByAssetType(c *gin.Context) {
 	typeID, err := strconv.ParseInt(c.Param("typeId"), 10, 64)
@@ -111,22 +168,60 @@ func (h *AssetHandler) Search(c *gin.Context) {
 	c.JSON(http.StatusOK, assets)
 }
 
-// Create creates a new asset
+// Create creates a new asset with its property values. The values are checked against the
+// schema of the asset type and defaults are filled in for properties without a value.
 func (h *AssetHandler) Create(c *gin.Context) {
-	var asset models.Asset
-	if err := c.ShouldBindJSON(&asset); err != nil {
+	var req assetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
 		return
 	}
+	asset := req.Asset
+	if asset.Status != "" && !asset.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid status"})
+		return
+	}
+	if err := validation.Tag(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err := validation.PurchaseCost(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	schema, err := h.schemaRepo.GetByAssetTypeID(context.Background(), asset.AssetTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create asset"})
+		return
+	}
+	properties, fieldErrs := validation.AssetProperties(schema, nil, req.Properties)
+	if len(fieldErrs) > 0 {
+		respondInvalidValues(c, "Invalid property values", fieldErrs)
+		return
+	}
 
-	if err := h.repo.Create(context.Background(), &asset); err != nil {
+	err = h.repo.CreateWithProperties(context.Background(), &asset, properties, h.unique)
+	if respondUnique(c, err) {
+		return
+	}
+	if err == repository.ErrDuplicateTag {
+		c.JSON(http.StatusConflict, gin.H{"Error": "Tag already in use by another asset"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create asset"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityAsset, asset.ID, asset)
+	for _, ap := range properties {
+		h.recorder.RecordCreate(c, models.AuditEntityAssetProperty, ap.ID, ap)
+	}
 	c.JSON(http.StatusCreated, asset)
 }
 
-// Update updates an asset
+// Update updates an asset and the property values in the request. The resulting values are
+// checked against the schema of the asset type, so required properties cannot be left empty.
 func (h *AssetHandler) Update(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -134,20 +229,206 @@ func (h *AssetHandler) Update(c *gin.Context) {
 		return
 	}
 
-	var asset models.Asset
-	if err := c.ShouldBindJSON(&asset); err != nil {
+	var req assetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
 		return
 	}
+	asset := req.Asset
 	asset.ID = id
+	if err := validation.Tag(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err := validation.PurchaseCost(&asset); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+	// The status only changes through ChangeStatus so every transition is checked and recorded
+	asset.Status = before.Status
+	if asset.Tag == "" {
+		asset.Tag = before.Tag
+	}
+
+	schema, err := h.schemaRepo.GetByAssetTypeID(context.Background(), asset.AssetTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset"})
+		return
+	}
+	current, err := h.propertyRepo.GetByAssetID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset"})
+		return
+	}
+	existing := make(map[int64]string, len(current))
+	currentByProperty := make(map[int64]models.AssetProperty, len(current))
+	for _, ap := range current {
+		existing[ap.PropertyID] = ap.Value
+		currentByProperty[ap.PropertyID] = ap
+	}
+	properties, fieldErrs := validation.AssetProperties(schema, existing, req.Properties)
+	if len(fieldErrs) > 0 {
+		respondInvalidValues(c, "Invalid property values", fieldErrs)
+		return
+	}
 
-	if err := h.repo.Update(context.Background(), &asset); err != nil {
+	err = h.repo.UpdateWithProperties(context.Background(), &asset, properties, h.unique)
+	if respondUnique(c, err) {
+		return
+	}
+	if err == repository.ErrDuplicateTag {
+		c.JSON(http.StatusConflict, gin.H{"Error": "Tag already in use by another asset"})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAsset, id, before, asset)
+	for _, ap := range properties {
+		old, exists := currentByProperty[ap.PropertyID]
+		switch {
+		case !exists && ap.Value != "":
+			h.recorder.RecordCreate(c, models.AuditEntityAssetProperty, ap.ID, ap)
+		case exists && ap.Value == "":
+			h.recorder.RecordDelete(c, models.AuditEntityAssetProperty, old.ID, old)
+		case exists && ap.Value != old.Value:
+			h.recorder.RecordUpdate(c, models.AuditEntityAssetProperty, ap.ID, old, ap)
+		}
+	}
 	c.JSON(http.StatusOK, asset)
 }
 
+// GetLifecycle returns the asset statuses and the transitions allowed between them
+func (h *AssetHandler) GetLifecycle(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"Statuses":    models.AssetStatuses,
+		"Transitions": h.transitions,
+	})
+}
+
+// ChangeStatus moves an asset to a new lifecycle status, recording the transition with its reason
+func (h *AssetHandler) ChangeStatus(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var req struct {
+		Status models.AssetStatus `json:"Status"`
+		Reason string             `json:"Reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid status"})
+		return
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Reason required"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+
+	change := &models.AssetStatusChange{
+		AssetID:  id,
+		ToStatus: req.Status,
+		Reason:   strings.TrimSpace(req.Reason),
+		Username: middleware.GetUsername(c),
+	}
+	if userID := middleware.GetUserID(c); userID != 0 {
+		change.UserID = &userID
+	}
+	if err := h.repo.ChangeStatus(context.Background(), change, h.transitions); err != nil {
+		if errors.Is(err, lifecycle.ErrTransitionNotAllowed) {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Status cannot change from " + string(before.Status) + " to " + string(req.Status)})
+			return
+		}
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to change status"})
+		return
+	}
+
+	after := *before
+	after.Status = change.ToStatus
+	h.recorder.RecordUpdate(c, models.AuditEntityAsset, id, before, after)
+	c.JSON(http.StatusOK, change)
+}
+
+// statusChangedBy returns a status change carrying the user making the request, for status
+// changes that come with another change of an asset
+func statusChangedBy(c *gin.Context) models.AssetStatusChange {
+	changedBy := models.AssetStatusChange{Username: middleware.GetUsername(c)}
+	if userID := middleware.GetUserID(c); userID != 0 {
+		changedBy.UserID = &userID
+	}
+	return changedBy
+}
+
+// recordStatusChange records a status change that came with another change of the asset, if any
+func recordStatusChange(c *gin.Context, recorder *audit.Recorder, change *models.AssetStatusChange) {
+	if change == nil {
+		return
+	}
+	recorder.RecordChanges(c, models.AuditEntityAsset, change.AssetID, models.AuditActionUpdate, map[string]audit.Change{
+		"Status": {Old: change.FromStatus, New: change.ToStatus},
+	})
+}
+
+// GetStatusHistory returns the status transitions of an asset
+func (h *AssetHandler) GetStatusHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	history, err := h.repo.GetStatusHistory(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch status history"})
+		return
+	}
+	if len(history) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// parseStatuses parses a comma-separated list of asset statuses
+func parseStatuses(value string) ([]models.AssetStatus, error) {
+	var statuses []models.AssetStatus
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		status := models.AssetStatus(part)
+		if !status.IsValid() {
+			return nil, fmt.Errorf("unknown status %q", part)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
 // Delete deletes an asset
 func (h *AssetHandler) Delete(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -156,10 +437,17 @@ func (h *AssetHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete asset"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityAsset, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Asset deleted"})
 }
 
@@ -198,10 +486,51 @@ func (h *AssetHandler) SetProperty(c *gin.Context) {
 	}
 	ap.AssetID = id
 
+	property, err := h.propertyDefRepo.GetByID(context.Background(), ap.PropertyID)
+	if err == repository.ErrPropertyNotFound {
+		respondInvalidValues(c, "Invalid property value", []models.FieldError{
+			{FieldID: ap.PropertyID, Value: ap.Value, Error: "property does not exist"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set property"})
+		return
+	}
+	ap.PropertyName = property.Name
+
+	asset, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+	schema, err := h.schemaRepo.GetByAssetTypeID(context.Background(), asset.AssetTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set property"})
+		return
+	}
+	checked, fieldErr := validation.AssetProperty(schema, ap)
+	if fieldErr != nil {
+		respondInvalidValues(c, "Invalid property value", []models.FieldError{*fieldErr})
+		return
+	}
+	ap.Value = checked.Value
+
+	before, err := h.propertyRepo.GetByAssetAndPropertyID(context.Background(), id, ap.PropertyID)
+	if err != nil && err != repository.ErrAssetPropertyNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set property"})
+		return
+	}
+
 	if err := h.propertyRepo.Upsert(context.Background(), &ap); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set property"})
 		return
 	}
+	if before == nil {
+		h.recorder.RecordCreate(c, models.AuditEntityAssetProperty, ap.ID, ap)
+	} else {
+		h.recorder.RecordUpdate(c, models.AuditEntityAssetProperty, ap.ID, before, ap)
+	}
 	c.JSON(http.StatusOK, ap)
 }
 
@@ -213,9 +542,35 @@ func (h *AssetHandler) DeleteProperty(c *gin.Context) {
 		return
 	}
 
+	before, err := h.propertyRepo.GetByID(context.Background(), propID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Property not found"})
+		return
+	}
+
+	asset, err := h.repo.GetByID(context.Background(), before.AssetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+	schema, err := h.schemaRepo.GetByAssetTypeID(context.Background(), asset.AssetTypeID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete property"})
+		return
+	}
+	for _, atp := range schema {
+		if atp.PropertyID == before.PropertyID && atp.IsRequired {
+			respondInvalidValues(c, "Invalid property value", []models.FieldError{
+				{Field: atp.PropertyName, FieldID: atp.PropertyID, Value: before.Value, Error: "value is required"},
+			})
+			return
+		}
+	}
+
 	if err := h.propertyRepo.Delete(context.Background(), propID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete property"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityAssetProperty, propID, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Property deleted"})
 }
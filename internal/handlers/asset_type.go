@@ -4,21 +4,34 @@ import (
 	"context"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
+	"assetManager/internal/labels"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
+	"assetManager/internal/tags"
+	"assetManager/internal/validation"
 )
 
 // AssetTypeHandler handles asset type endpoints
 type AssetTypeHandler struct {
-	repo *repository.AssetTypeRepository
+	repo         *repository.AssetTypeRepository
+	schemaRepo   *repository.AssetTypePropertyRepository
+	propertyRepo *repository.PropertyRepository
+	recorder     *audit.Recorder
 }
 
 // NewAssetTypeHandler creates a new asset type handler
-func NewAssetTypeHandler(repo *repository.AssetTypeRepository) *AssetTypeHandler {
-	return &AssetTypeHandler{repo: repo}
+func NewAssetTypeHandler(repo *repository.AssetTypeRepository, schemaRepo *repository.AssetTypePropertyRepository, propertyRepo *repository.PropertyRepository, recorder *audit.Recorder) *AssetTypeHandler {
+	return &AssetTypeHandler{
+		repo:         repo,
+		schemaRepo:   schemaRepo,
+		propertyRepo: propertyRepo,
+		recorder:     recorder,
+	}
 }
 
 // GetAll returns all asset types
@@ -35,7 +48,7 @@ func (h *AssetTypeHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, assetTypes)
 }
 
-// GetByID returns an asset type by ID
+// GetByID returns an asset type by ID with the properties that apply to it
 func (h *AssetTypeHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -43,12 +56,94 @@ func (h *AssetTypeHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	assetType, err := h.repo.GetByID(context.Background(), id)
+	schema, err := h.getSchema(context.Background(), id)
+	if err == repository.ErrAssetTypeNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset type not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch asset type"})
+		return
+	}
+	c.JSON(http.StatusOK, schema)
+}
+
+// SetProperties replaces the properties that apply to an asset type, with their required flag,
+// display order and default value
+func (h *AssetTypeHandler) SetProperties(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var properties []models.AssetTypeProperty
+	if err := c.ShouldBindJSON(&properties); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+
+	before, err := h.getSchema(context.Background(), id)
+	if err == repository.ErrAssetTypeNotFound {
 		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset type not found"})
 		return
 	}
-	c.JSON(http.StatusOK, assetType)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset type properties"})
+		return
+	}
+
+	var fieldErrs []models.FieldError
+	for i := range properties {
+		atp := &properties[i]
+		property, err := h.propertyRepo.GetByID(context.Background(), atp.PropertyID)
+		if err == repository.ErrPropertyNotFound {
+			fieldErrs = append(fieldErrs, models.FieldError{FieldID: atp.PropertyID, Error: "property does not exist"})
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset type properties"})
+			return
+		}
+		atp.PropertyName = property.Name
+		atp.DataType = property.DataType
+		atp.EnumOptions = property.EnumOptions
+	}
+	if len(fieldErrs) > 0 {
+		respondInvalidValues(c, "Invalid asset type properties", fieldErrs)
+		return
+	}
+	properties, fieldErrs = validation.Schema(properties)
+	if len(fieldErrs) > 0 {
+		respondInvalidValues(c, "Invalid asset type properties", fieldErrs)
+		return
+	}
+
+	if err := h.schemaRepo.Replace(context.Background(), id, properties); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset type properties"})
+		return
+	}
+
+	after, err := h.getSchema(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch asset type"})
+		return
+	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAssetType, id, before, after)
+	c.JSON(http.StatusOK, after)
+}
+
+// getSchema loads an asset type with the properties that apply to it
+func (h *AssetTypeHandler) getSchema(ctx context.Context, id int64) (*models.AssetTypeSchema, error) {
+	assetType, err := h.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	properties, err := h.schemaRepo.GetByAssetTypeID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.AssetTypeSchema{AssetType: *assetType, Properties: properties}, nil
 }
 
 // Create creates a new asset type
@@ -58,11 +153,15 @@ func (h *AssetTypeHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
 		return
 	}
+	if !validTagPattern(c, &assetType) || !validDepreciation(c, &assetType) || !validLabelTemplate(c, &assetType) {
+		return
+	}
 
 	if err := h.repo.Create(context.Background(), &assetType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create asset type"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssetType, assetType.ID, assetType)
 	c.JSON(http.StatusCreated, assetType)
 }
 
@@ -80,11 +179,21 @@ func (h *AssetTypeHandler) Update(c *gin.Context) {
 		return
 	}
 	assetType.ID = id
+	if !validTagPattern(c, &assetType) || !validDepreciation(c, &assetType) || !validLabelTemplate(c, &assetType) {
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset type not found"})
+		return
+	}
 
 	if err := h.repo.Update(context.Background(), &assetType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update asset type"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAssetType, id, before, assetType)
 	c.JSON(http.StatusOK, assetType)
 }
 
@@ -96,9 +205,79 @@ func (h *AssetTypeHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset type not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete asset type"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityAssetType, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Asset type deleted"})
 }
+
+// validTagPattern checks the tag pattern of an asset type. It writes a 400 response and returns
+// false if the pattern is invalid.
+func validTagPattern(c *gin.Context, assetType *models.AssetType) bool {
+	assetType.TagPattern = strings.TrimSpace(assetType.TagPattern)
+	if assetType.TagPattern == "" {
+		return true
+	}
+	if _, err := tags.Parse(assetType.TagPattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid tag pattern: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// validDepreciation checks the depreciation method and useful life of an asset type, defaulting
+// the method to straight-line. It writes a 400 response and returns false if they are invalid.
+func validDepreciation(c *gin.Context, assetType *models.AssetType) bool {
+	if assetType.DepreciationMethod == "" {
+		assetType.DepreciationMethod = models.DepreciationStraightLine
+	}
+	if !assetType.DepreciationMethod.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Depreciation method must be straight_line or declining_balance"})
+		return false
+	}
+	if assetType.UsefulLifeMonths < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Useful life must not be negative"})
+		return false
+	}
+	return true
+}
+
+// validLabelTemplate checks the label template of an asset type, defaulting to a QR code of the
+// lookup URL with the asset name. It writes a 400 response and returns false if it is invalid.
+func validLabelTemplate(c *gin.Context, assetType *models.AssetType) bool {
+	if assetType.LabelCode == "" {
+		assetType.LabelCode = models.LabelCodeQR
+	}
+	if assetType.LabelContent == "" {
+		assetType.LabelContent = models.LabelContentURL
+	}
+	assetType.LabelText = strings.TrimSpace(strings.ReplaceAll(assetType.LabelText, "\r\n", "\n"))
+	if assetType.LabelText == "" {
+		assetType.LabelText = "{name}"
+	}
+	if !assetType.LabelCode.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Label code must be qr or code128"})
+		return false
+	}
+	if !assetType.LabelContent.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Label content must be url, id or tag"})
+		return false
+	}
+	if len(assetType.LabelText) > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Label text must not be longer than 500 characters"})
+		return false
+	}
+	if err := labels.CheckText(assetType.LabelText); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Label text uses an " + err.Error() + ", use {tag}, {name}, {model}, {serial}, {order}, {license}, {type} or {id}"})
+		return false
+	}
+	return true
+}
@@ -8,21 +8,42 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
+	"assetManager/internal/custody"
+	"assetManager/internal/export"
+	"assetManager/internal/lifecycle"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
 )
 
+// upcomingChangeColumns are the columns of the upcoming changes report
+var upcomingChangeColumns = []models.ReportColumn{
+	{Key: "effective_at", Label: "Effective At"},
+	{Key: "kind", Label: "Change"},
+	{Key: "asset_tag", Label: "Tag"},
+	{Key: "asset_name", Label: "Asset"},
+	{Key: "from_person", Label: "From"},
+	{Key: "to_person", Label: "To"},
+	{Key: "notes", Label: "Notes"},
+}
+
 // AssignmentHandler handles asset assignment endpoints
 type AssignmentHandler struct {
-	repo       *repository.AssetAssignmentRepository
-	personRepo *repository.PersonRepository
+	repo        *repository.AssetAssignmentRepository
+	personRepo  *repository.PersonRepository
+	assetRepo   *repository.AssetRepository
+	transitions lifecycle.Transitions
+	recorder    *audit.Recorder
 }
 
 // NewAssignmentHandler creates a new assignment handler
-func NewAssignmentHandler(repo *repository.AssetAssignmentRepository, personRepo *repository.PersonRepository) *AssignmentHandler {
+func NewAssignmentHandler(repo *repository.AssetAssignmentRepository, personRepo *repository.PersonRepository, assetRepo *repository.AssetRepository, transitions lifecycle.Transitions, recorder *audit.Recorder) *AssignmentHandler {
 	return &AssignmentHandler{
-		repo:       repo,
-		personRepo: personRepo,
+		repo:        repo,
+		personRepo:  personRepo,
+		assetRepo:   assetRepo,
+		transitions: transitions,
+		recorder:    recorder,
 	}
 }
 
@@ -46,6 +67,34 @@ func (h *AssignmentHandler) GetByAssetID(c *gin.Context) {
 	c.JSON(http.StatusOK, assignments)
 }
 
+// GetTimeline returns the custody timeline of an asset from its creation, with the gaps in which
+// nobody was recorded to hold it
+func (h *AssignmentHandler) GetTimeline(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("assetId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	asset, err := h.assetRepo.GetByID(context.Background(), assetID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	}
+	assignments, err := h.repo.GetHistoryByAssetID(context.Background(), assetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assignments"})
+		return
+	}
+
+	periods := custody.Timeline(asset.CreatedAt, assignments, time.Now())
+	c.JSON(http.StatusOK, models.CustodyTimeline{
+		AssetID: assetID,
+		Periods: periods,
+		Gaps:    custody.Gaps(periods),
+	})
+}
+
 // GetCurrentByAssetID returns the current assignment for an asset
 func (h *AssignmentHandler) GetCurrentByAssetID(c *gin.Context) {
 	assetID, err := strconv.ParseInt(c.Param("assetId"), 10, 64)
@@ -86,15 +135,19 @@ func (h *AssignmentHandler) GetByPersonID(c *gin.Context) {
 	c.JSON(http.StatusOK, assignments)
 }
 
-// GetCurrentByPersonID returns current assignments for a person
+// GetCurrentByPersonID returns current assignments for a person, or those active at as_of
 func (h *AssignmentHandler) GetCurrentByPersonID(c *gin.Context) {
 	personID, err := strconv.ParseInt(c.Param("personId"), 10, 64)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid person ID"})
 		return
 	}
+	asOf, ok := parseAsOfTime(c)
+	if !ok {
+		return
+	}
 
-	assignments, err := h.repo.GetCurrentByPersonID(context.Background(), personID)
+	assignments, err := h.repo.GetCurrentByPersonID(context.Background(), personID, asOf)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assignments"})
 		return
@@ -125,19 +178,44 @@ func (h *AssignmentHandler) AssignAsset(c *gin.Context) {
 		effectiveDate = *req.EffectiveDate
 	}
 
-	if err := h.repo.AssignAsset(context.Background(), req.AssetID, req.PersonID, req.Notes, effectiveDate); err != nil {
+	previous, assignment, change, err := h.repo.AssignAsset(context.Background(), req.AssetID, req.PersonID, req.Notes, effectiveDate, h.transitions, statusChangedBy(c))
+	if err != nil {
+		if respondReserved(c, err) {
+			return
+		}
 		if err == repository.ErrOverlappingAssignment {
 			c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
 			return
 		}
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		if err == repository.ErrAssetNotAssignable {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be assigned"})
+			return
+		}
+		if err == repository.ErrPersonNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+			return
+		}
+		if err == repository.ErrPersonInactive {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot be assigned assets"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to assign asset"})
 		return
 	}
+	h.recordReassignment(c, previous, assignment, change)
 
-	c.JSON(http.StatusOK, gin.H{"Message": "Asset assigned successfully"})
+	if effectiveDate.After(time.Now()) {
+		c.JSON(http.StatusOK, gin.H{"Message": "Assignment scheduled", "Assignment": assignment})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Message": "Asset assigned successfully", "StatusChange": change})
 }
 
-// UnassignAsset unassigns an asset (assigns to 'Unassigned' person)
+// UnassignAsset returns an asset to a stock pool, the default pool unless StockPoolID is given
 func (h *AssignmentHandler) UnassignAsset(c *gin.Context) {
 	assetID, err := strconv.ParseInt(c.Param("assetId"), 10, 64)
 	if err != nil {
@@ -147,6 +225,7 @@ func (h *AssignmentHandler) UnassignAsset(c *gin.Context) {
 
 	var req struct {
 		EffectiveDate string `json:"EffectiveDate"`
+		StockPoolID   int64  `json:"StockPoolID"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
@@ -164,19 +243,130 @@ func (h *AssignmentHandler) UnassignAsset(c *gin.Context) {
 		effectiveDate = parsed
 	}
 
-	// Get the 'Unassigned' person
-	unassigned, err := h.personRepo.GetUnassigned(context.Background())
+	pool, err := h.personRepo.GetStockPool(context.Background(), req.StockPoolID)
+	if err == repository.ErrPersonNotFound {
+		if req.StockPoolID != 0 {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Stock pool not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"Error": "No stock pool exists"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find Unassigned person"})
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find stock pool"})
 		return
 	}
 
-	if err := h.repo.AssignAsset(context.Background(), assetID, unassigned.ID, "Unassigned", effectiveDate); err != nil {
+	previous, assignment, change, err := h.repo.UnassignAsset(context.Background(), assetID, pool.ID, "Returned to "+pool.Name, effectiveDate, h.transitions, statusChangedBy(c))
+	if err != nil {
+		if err == repository.ErrOverlappingAssignment {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
+			return
+		}
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to unassign asset"})
 		return
 	}
+	h.recordReassignment(c, previous, assignment, change)
+
+	if effectiveDate.After(time.Now()) {
+		c.JSON(http.StatusOK, gin.H{"Message": "Return to " + pool.Name + " scheduled", "Assignment": assignment})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"Message": "Asset returned to " + pool.Name, "StatusChange": change})
+}
+
+// GetPending returns the scheduled assignments that have not started yet
+func (h *AssignmentHandler) GetPending(c *gin.Context) {
+	assignments, err := h.repo.GetPending(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch pending assignments"})
+		return
+	}
+	if len(assignments) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, assignments)
+}
+
+// CancelPending cancels a scheduled assignment that has not started yet; the assignment it would
+// have ended continues in its place
+func (h *AssignmentHandler) CancelPending(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	cancelled, previous, err := h.repo.CancelPending(context.Background(), id)
+	switch err {
+	case nil:
+	case repository.ErrAssetAssignmentNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+		return
+	case repository.ErrAssignmentNotPending:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Only assignments that have not started can be cancelled"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to cancel assignment"})
+		return
+	}
+	h.recorder.RecordDelete(c, models.AuditEntityAssignment, cancelled.ID, cancelled)
+	if previous != nil {
+		if extended, err := h.repo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, extended)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Message": "Scheduled assignment cancelled"})
+}
+
+// GetUpcoming returns the changes of who holds which asset scheduled between now and the end of
+// the within period, 30d by default. format=csv, xlsx or pdf downloads them as a document.
+func (h *AssignmentHandler) GetUpcoming(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+	now := time.Now()
+	until, err := repository.AddPeriod(now, c.DefaultQuery("within", "30d"), 1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid within period, use for example 30d, 2w, 3m or 1y"})
+		return
+	}
+
+	changes, err := h.repo.GetUpcoming(context.Background(), now, until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch upcoming changes"})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"Message": "Asset unassigned successfully"})
+	if format == "" || format == "json" {
+		if len(changes) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, changes)
+		return
+	}
+	rows := make([]map[string]interface{}, len(changes))
+	for i, change := range changes {
+		rows[i] = map[string]interface{}{
+			"effective_at": change.EffectiveAt,
+			"kind":         string(change.Kind),
+			"asset_tag":    change.AssetTag,
+			"asset_name":   change.AssetName,
+			"from_person":  change.FromPersonName,
+			"to_person":    change.ToPersonName,
+			"notes":        change.Notes,
+		}
+	}
+	f, _ := export.ParseFormat(format)
+	writeExport(c, "upcoming-changes", f, upcomingChangeColumns, rows)
 }
 
 // EndAssignment ends an assignment
@@ -200,10 +390,31 @@ func (h *AssignmentHandler) EndAssignment(c *gin.Context) {
 		endDate = *req.EndDate
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+		return
+	}
+
 	if err := h.repo.EndAssignment(context.Background(), id, endDate); err != nil {
+		if err == repository.ErrAssetAssignmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+			return
+		}
+		if err == repository.ErrInvalidAssignmentEnd {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Assignments must end after they start"})
+			return
+		}
+		if err == repository.ErrOverlappingAssignment {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to end assignment"})
 		return
 	}
+	if after, err := h.repo.GetByID(context.Background(), id); err == nil {
+		h.recorder.RecordUpdate(c, models.AuditEntityAssignment, id, before, after)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"Message": "Assignment ended"})
 }
@@ -217,13 +428,33 @@ func (h *AssignmentHandler) Create(c *gin.Context) {
 	}
 
 	if err := h.repo.Create(context.Background(), &aa); err != nil {
+		if respondReserved(c, err) {
+			return
+		}
 		if err == repository.ErrOverlappingAssignment {
 			c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
 			return
 		}
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		if err == repository.ErrAssetNotAssignable {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be assigned"})
+			return
+		}
+		if err == repository.ErrPersonNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+			return
+		}
+		if err == repository.ErrPersonInactive {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot be assigned assets"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create assignment"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssignment, aa.ID, aa)
 
 	c.JSON(http.StatusCreated, aa)
 }
@@ -243,14 +474,50 @@ func (h *AssignmentHandler) Update(c *gin.Context) {
 	}
 	aa.ID = id
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+		return
+	}
+	// Assignments cannot be moved to another asset
+	aa.AssetID = before.AssetID
+
 	if err := h.repo.Update(context.Background(), &aa); err != nil {
+		if respondReserved(c, err) {
+			return
+		}
 		if err == repository.ErrOverlappingAssignment {
 			c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
 			return
 		}
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		if err == repository.ErrAssetAssignmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+			return
+		}
+		if err == repository.ErrAssetNotAssignable {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be assigned"})
+			return
+		}
+		if err == repository.ErrPersonNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+			return
+		}
+		if err == repository.ErrPersonInactive {
+			c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot be assigned assets"})
+			return
+		}
+		if err == repository.ErrInvalidAssignmentEnd {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Assignments must end after they start"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update assignment"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAssignment, id, before, aa)
 
 	c.JSON(http.StatusOK, aa)
 }
@@ -263,10 +530,33 @@ func (h *AssignmentHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
+		if err == repository.ErrAssetAssignmentNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Assignment not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete assignment"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityAssignment, id, before)
 
 	c.JSON(http.StatusOK, gin.H{"Message": "Assignment deleted"})
 }
+
+// recordReassignment records the end of the previous assignment (if any), the new assignment and
+// the status change of the asset (if any)
+func (h *AssignmentHandler) recordReassignment(c *gin.Context, previous, assignment *models.AssetAssignment, change *models.AssetStatusChange) {
+	if previous != nil {
+		if ended, err := h.repo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, ended)
+		}
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssignment, assignment.ID, assignment)
+	recordStatusChange(c, h.recorder, change)
+}
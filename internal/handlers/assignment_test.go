@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// testDSNEnv names the environment variable holding the DSN of a migrated test database
+const testDSNEnv = "ASSET_MANAGER_TEST_DSN"
+
+// openTestDB connects to the integration test database or skips the test
+func openTestDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skipf("Requires database connection - set %s to run integration tests", testDSNEnv)
+	}
+	db, err := sqlx.Connect("mysql", dsn)
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// TestAssignAsset_Concurrent reassigns one asset from many goroutines at once and checks
+// that the assignment history never has overlaps and ends with exactly one current owner.
+func TestAssignAsset_Concurrent(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	recorder := audit.NewRecorder(repository.NewAuditLogRepository(db))
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Concurrency test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Concurrency laptop " + suffix}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+
+	const personCount = 5
+	var personIDs []int64
+	for i := 0; i < personCount; i++ {
+		person := &models.Person{Name: fmt.Sprintf("Concurrency person %d %s", i, suffix)}
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+		personIDs = append(personIDs, person.ID)
+	}
+
+	handler := NewAssignmentHandler(assignmentRepo, personRepo, assetRepo, lifecycle.DefaultTransitions(), recorder)
+	router := gin.New()
+	router.POST("/api/assignments/assign", handler.AssignAsset)
+
+	const requests = 40
+	var wg sync.WaitGroup
+	statuses := make(chan int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(map[string]interface{}{
+				"AssetID":  asset.ID,
+				"PersonID": personIDs[i%personCount],
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/assignments/assign", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			statuses <- w.Code
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	succeeded := 0
+	for status := range statuses {
+		switch status {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusConflict:
+			// A request that computed its effective date before an already committed one is rejected
+		default:
+			t.Errorf("Unexpected status %d", status)
+		}
+	}
+	if succeeded == 0 {
+		t.Fatal("Expected at least one assignment to succeed")
+	}
+
+	history, err := assignmentRepo.GetHistoryByAssetID(ctx, asset.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch history: %v", err)
+	}
+	if len(history) != succeeded {
+		t.Errorf("Expected %d assignments in history, got %d", succeeded, len(history))
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if history[i].EffectiveFrom.Time.Equal(history[j].EffectiveFrom.Time) {
+			return history[i].ID < history[j].ID
+		}
+		return history[i].EffectiveFrom.Time.Before(history[j].EffectiveFrom.Time)
+	})
+
+	open := 0
+	for i, aa := range history {
+		if !aa.EffectiveTo.Valid {
+			open++
+			continue
+		}
+		if aa.EffectiveTo.Time.Before(aa.EffectiveFrom.Time) {
+			t.Errorf("Assignment %d ends before it starts", aa.ID)
+		}
+		if i+1 < len(history) && aa.EffectiveTo.Time.After(history[i+1].EffectiveFrom.Time) {
+			t.Errorf("Assignment %d overlaps assignment %d", aa.ID, history[i+1].ID)
+		}
+	}
+	if open != 1 {
+		t.Errorf("Expected exactly one open assignment, got %d", open)
+	}
+	if last := history[len(history)-1]; last.EffectiveTo.Valid {
+		t.Errorf("Expected the latest assignment %d to be the open one", last.ID)
+	}
+}
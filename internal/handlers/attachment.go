@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/middleware"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+	"assetManager/internal/storage"
+)
+
+// attachmentEntityNames names the records files can be attached to in error messages
+var attachmentEntityNames = map[models.AttachmentEntity]string{
+	models.AttachmentEntityAsset:      "Asset",
+	models.AttachmentEntityPerson:     "Person",
+	models.AttachmentEntityAssignment: "Assignment",
+}
+
+// AttachmentHandler handles file attachment endpoints
+type AttachmentHandler struct {
+	repo     *repository.AttachmentRepository
+	store    storage.Backend
+	maxSize  int64
+	recorder *audit.Recorder
+}
+
+// NewAttachmentHandler creates a new attachment handler. Uploads larger than maxSize bytes are
+// rejected.
+func NewAttachmentHandler(repo *repository.AttachmentRepository, store storage.Backend, maxSize int64, recorder *audit.Recorder) *AttachmentHandler {
+	return &AttachmentHandler{
+		repo:     repo,
+		store:    store,
+		maxSize:  maxSize,
+		recorder: recorder,
+	}
+}
+
+// List returns a handler listing the attachments of the asset, person or assignment identified
+// by the id parameter
+func (h *AttachmentHandler) List(entityType models.AttachmentEntity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+			return
+		}
+
+		attachments, err := h.repo.GetByEntity(context.Background(), entityType, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch attachments"})
+			return
+		}
+		if len(attachments) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, attachments)
+	}
+}
+
+// Upload returns a handler storing the file in the multipart field "file" as an attachment of
+// the asset, person or assignment identified by the id parameter. An optional "description"
+// field describes the file.
+func (h *AttachmentHandler) Upload(entityType models.AttachmentEntity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entityID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+			return
+		}
+
+		// Leave room for the multipart framing and the description
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+1<<20)
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				h.respondTooLarge(c)
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Expected a file in the multipart field \"file\""})
+			return
+		}
+		if fileHeader.Size > h.maxSize {
+			h.respondTooLarge(c)
+			return
+		}
+		if fileHeader.Size == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "The file is empty"})
+			return
+		}
+
+		ctx := context.Background()
+		exists, err := h.repo.EntityExists(ctx, entityType, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to upload attachment"})
+			return
+		}
+		if !exists {
+			c.JSON(http.StatusNotFound, gin.H{"Error": attachmentEntityNames[entityType] + " not found"})
+			return
+		}
+
+		file, err := fileHeader.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Failed to read the uploaded file"})
+			return
+		}
+		defer file.Close()
+
+		// Hash the content and sniff its type, then rewind to store it
+		head := make([]byte, 512)
+		n, err := io.ReadFull(file, head)
+		if err != nil && err != io.ErrUnexpectedEOF {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Failed to read the uploaded file"})
+			return
+		}
+		head = head[:n]
+		hash := sha256.New()
+		hash.Write(head)
+		if _, err := io.Copy(hash, file); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Failed to read the uploaded file"})
+			return
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to upload attachment"})
+			return
+		}
+
+		fileName := attachmentFileName(fileHeader.Filename)
+		sum := hex.EncodeToString(hash.Sum(nil))
+		attachment := models.Attachment{
+			EntityType:     entityType,
+			EntityID:       entityID,
+			FileName:       fileName,
+			ContentType:    storage.DetectContentType(fileName, head),
+			Size:           fileHeader.Size,
+			SHA256:         sum,
+			StorageKey:     storage.ContentKey(sum),
+			Description:    strings.TrimSpace(c.PostForm("description")),
+			UploadedByName: middleware.GetUsername(c),
+		}
+		if userID := middleware.GetUserID(c); userID != 0 {
+			attachment.UploadedBy = &userID
+		}
+
+		if err := h.store.Put(ctx, attachment.StorageKey, file, attachment.Size, attachment.ContentType); err != nil {
+			log.Printf("Failed to store attachment %s: %v", attachment.StorageKey, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to store attachment"})
+			return
+		}
+		if err := h.repo.Create(ctx, &attachment); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to upload attachment"})
+			return
+		}
+		h.recorder.RecordCreate(c, models.AuditEntityAttachment, attachment.ID, attachment)
+		c.JSON(http.StatusCreated, attachment)
+	}
+}
+
+// Download sends the content of an attachment
+func (h *AttachmentHandler) Download(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	attachment, err := h.repo.GetByID(context.Background(), id)
+	if err == repository.ErrAttachmentNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Attachment not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch attachment"})
+		return
+	}
+
+	content, err := h.store.Open(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "The attachment content is missing from storage"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to open attachment %s: %v", attachment.StorageKey, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to read attachment"})
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, content, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}),
+		"ETag":                `"` + attachment.SHA256 + `"`,
+	})
+}
+
+// Delete soft-deletes an attachment
+func (h *AttachmentHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Attachment not found"})
+		return
+	}
+
+	if err := h.repo.Delete(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete attachment"})
+		return
+	}
+	h.recorder.RecordDelete(c, models.AuditEntityAttachment, id, before)
+	c.JSON(http.StatusOK, gin.H{"Message": "Attachment deleted"})
+}
+
+// respondTooLarge rejects an upload above the size limit
+func (h *AttachmentHandler) respondTooLarge(c *gin.Context) {
+	c.JSON(http.StatusRequestEntityTooLarge, gin.H{"Error": fmt.Sprintf("Attachments may be at most %d MB", h.maxSize>>20)})
+}
+
+// attachmentFileName strips any client side directory from an uploaded file name and limits its
+// length to what the database stores
+func attachmentFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == "" {
+		name = "attachment"
+	}
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	return name
+}
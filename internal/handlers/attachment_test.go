@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAttachmentFileName(t *testing.T) {
+	tests := map[string]string{
+		"invoice.pdf":                 "invoice.pdf",
+		"C:\\Users\\me\\handover.pdf": "handover.pdf",
+		"../../etc/passwd":            "passwd",
+		"":                            "attachment",
+	}
+	for in, want := range tests {
+		if got := attachmentFileName(in); got != want {
+			t.Errorf("attachmentFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := attachmentFileName(strings.Repeat("ä", 300)); len([]rune(got)) != 255 {
+		t.Errorf("Expected long names to be cut to 255 characters, got %d", len([]rune(got)))
+	}
+}
@@ -7,18 +7,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
 )
 
 // AttributeHandler handles attribute endpoints
 type AttributeHandler struct {
-	repo *repository.AttributeRepository
+	repo     *repository.AttributeRepository
+	recorder *audit.Recorder
 }
 
 // NewAttributeHandler creates a new attribute handler
-func NewAttributeHandler(repo *repository.AttributeRepository) *AttributeHandler {
-	return &AttributeHandler{repo: repo}
+func NewAttributeHandler(repo *repository.AttributeRepository, recorder *audit.Recorder) *AttributeHandler {
+	return &AttributeHandler{
+		repo:     repo,
+		recorder: recorder,
+	}
 }
 
 // GetAll returns all attributes
@@ -63,6 +68,7 @@ func (h *AttributeHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create attribute"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityAttribute, attribute.ID, attribute)
 	c.JSON(http.StatusCreated, attribute)
 }
 
@@ -81,10 +87,17 @@ func (h *AttributeHandler) Update(c *gin.Context) {
 	}
 	attribute.ID = id
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Attribute not found"})
+		return
+	}
+
 	if err := h.repo.Update(context.Background(), &attribute); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update attribute"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAttribute, id, before, attribute)
 	c.JSON(http.StatusOK, attribute)
 }
 
@@ -96,9 +109,16 @@ func (h *AttributeHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Attribute not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete attribute"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityAttribute, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Attribute deleted"})
 }
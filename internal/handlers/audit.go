@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// AuditHandler handles audit log endpoints
+type AuditHandler struct {
+	repo *repository.AuditLogRepository
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(repo *repository.AuditLogRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// GetAll returns audit log entries filtered by entity, user and date range
+func (h *AuditHandler) GetAll(c *gin.Context) {
+	filter := repository.AuditLogFilter{
+		EntityType: models.AuditEntityType(c.Query("entity_type")),
+	}
+
+	var err error
+	if v := c.Query("entity_id"); v != "" {
+		if filter.EntityID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid entity ID"})
+			return
+		}
+	}
+	if v := c.Query("user_id"); v != "" {
+		if filter.UserID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid user ID"})
+			return
+		}
+	}
+	if v := c.Query("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid limit"})
+			return
+		}
+	}
+	if filter.From, err = parseDateQuery(c.Query("from")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid from date"})
+		return
+	}
+	if filter.To, err = parseDateQuery(c.Query("to")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid to date"})
+		return
+	}
+	// A date-only upper bound includes the whole day
+	if filter.To != nil && len(c.Query("to")) == len("2006-01-02") {
+		end := filter.To.AddDate(0, 0, 1)
+		filter.To = &end
+	}
+
+	entries, err := h.repo.List(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch audit log"})
+		return
+	}
+	if len(entries) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// GetByAssetID returns the changelog of an asset
+func (h *AuditHandler) GetByAssetID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	entries, err := h.repo.GetByAssetID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch audit log"})
+		return
+	}
+	if len(entries) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// parseDateQuery parses an optional RFC3339 or YYYY-MM-DD query value
+func parseDateQuery(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// ContractHandler handles warranty, support contract and lease endpoints
+type ContractHandler struct {
+	repo          *repository.AssetContractRepository
+	assetRepo     *repository.AssetRepository
+	defaultWithin string
+	recorder      *audit.Recorder
+}
+
+// NewContractHandler creates a new contract handler. defaultWithin is the period listed by the
+// expiry alerts endpoint when none is requested.
+func NewContractHandler(repo *repository.AssetContractRepository, assetRepo *repository.AssetRepository, defaultWithin string, recorder *audit.Recorder) *ContractHandler {
+	return &ContractHandler{
+		repo:          repo,
+		assetRepo:     assetRepo,
+		defaultWithin: defaultWithin,
+		recorder:      recorder,
+	}
+}
+
+// GetByAssetID returns the contracts of an asset
+func (h *ContractHandler) GetByAssetID(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	contracts, err := h.repo.GetByAssetID(context.Background(), assetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch contracts"})
+		return
+	}
+	if len(contracts) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, contracts)
+}
+
+// Create adds a warranty, support contract or lease to an asset
+func (h *ContractHandler) Create(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	var contract models.AssetContract
+	if err := c.ShouldBindJSON(&contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	contract.AssetID = assetID
+	if !validContract(c, &contract) {
+		return
+	}
+
+	if _, err := h.assetRepo.GetByID(context.Background(), assetID); err != nil {
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create contract"})
+		return
+	}
+
+	if err := h.repo.Create(context.Background(), &contract); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create contract"})
+		return
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssetContract, contract.ID, contract)
+	c.JSON(http.StatusCreated, contract)
+}
+
+// Update updates a contract
+func (h *ContractHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var contract models.AssetContract
+	if err := c.ShouldBindJSON(&contract); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Contract not found"})
+		return
+	}
+	contract.ID = id
+	contract.AssetID = before.AssetID
+	contract.AssetName = before.AssetName
+	if !validContract(c, &contract) {
+		return
+	}
+
+	if err := h.repo.Update(context.Background(), &contract); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update contract"})
+		return
+	}
+	h.recorder.RecordUpdate(c, models.AuditEntityAssetContract, id, before, contract)
+	c.JSON(http.StatusOK, contract)
+}
+
+// Delete deletes a contract
+func (h *ContractHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Contract not found"})
+		return
+	}
+
+	if err := h.repo.Delete(context.Background(), id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete contract"})
+		return
+	}
+	h.recorder.RecordDelete(c, models.AuditEntityAssetContract, id, before)
+	c.JSON(http.StatusOK, gin.H{"Message": "Contract deleted"})
+}
+
+// GetExpiring returns the warranties, support contracts and leases ending between today and
+// the end of the within period, such as within=30d
+func (h *ContractHandler) GetExpiring(c *gin.Context) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	until, err := repository.AddPeriod(today, c.DefaultQuery("within", h.defaultWithin), 1)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid within period, use for example 30d, 2w, 3m or 1y"})
+		return
+	}
+
+	contracts, err := h.repo.GetExpiring(context.Background(), today, until)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch expiring contracts"})
+		return
+	}
+	if len(contracts) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, contracts)
+}
+
+// validContract checks the kind and dates of a contract. It writes a 400 response and returns
+// false if the contract is invalid.
+func validContract(c *gin.Context, contract *models.AssetContract) bool {
+	if !contract.Kind.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Kind must be warranty, support or lease"})
+		return false
+	}
+	if !contract.EndsAt.Valid {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "End date is required"})
+		return false
+	}
+	if contract.StartsAt.Valid && contract.StartsAt.Time.After(contract.EndsAt.Time) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "End date must not be before the start date"})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/depreciation"
+	"assetManager/internal/export"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// Columns of the depreciation exports
+var (
+	bookValueColumns = []models.ReportColumn{
+		{Key: "asset_id", Label: "Asset ID"},
+		{Key: "asset_name", Label: "Asset"},
+		{Key: "asset_type_name", Label: "Asset Type"},
+		{Key: "purchased_at", Label: "Purchased At"},
+		{Key: "purchase_cost", Label: "Purchase Cost"},
+		{Key: "currency", Label: "Currency"},
+		{Key: "method", Label: "Method"},
+		{Key: "useful_life_months", Label: "Useful Life (Months)"},
+		{Key: "accumulated_depreciation", Label: "Accumulated Depreciation"},
+		{Key: "book_value", Label: "Book Value"},
+	}
+	depreciationSummaryColumns = []models.ReportColumn{
+		{Key: "asset_type_name", Label: "Asset Type"},
+		{Key: "currency", Label: "Currency"},
+		{Key: "asset_count", Label: "Assets"},
+		{Key: "purchase_cost", Label: "Purchase Cost"},
+		{Key: "accumulated_depreciation", Label: "Accumulated Depreciation"},
+		{Key: "book_value", Label: "Book Value"},
+	}
+)
+
+// DepreciationHandler handles book value and depreciation report endpoints
+type DepreciationHandler struct {
+	repo *repository.DepreciationRepository
+}
+
+// NewDepreciationHandler creates a new depreciation handler
+func NewDepreciationHandler(repo *repository.DepreciationRepository) *DepreciationHandler {
+	return &DepreciationHandler{repo: repo}
+}
+
+// GetByAssetID returns the book value of an asset as of a date, today by default, with its
+// yearly depreciation schedule
+func (h *DepreciationHandler) GetByAssetID(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return
+	}
+
+	bv, err := h.repo.GetByAssetID(context.Background(), assetID)
+	switch err {
+	case nil:
+	case repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case repository.ErrNotDepreciable:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "The asset needs a purchase cost and purchase date to have a book value"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to calculate book value"})
+		return
+	}
+
+	depreciation.Apply(bv, asOf)
+	bv.Schedule = depreciation.For(bv).Years()
+	c.JSON(http.StatusOK, bv)
+}
+
+// GetBookValues returns the book value of every asset held as of a date
+func (h *DepreciationHandler) GetBookValues(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+	values, asOf, ok := h.bookValues(c)
+	if !ok {
+		return
+	}
+
+	if format == "" || format == "json" {
+		if len(values) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, values)
+		return
+	}
+	rows := make([]map[string]interface{}, len(values))
+	for i, bv := range values {
+		rows[i] = map[string]interface{}{
+			"asset_id":                 bv.AssetID,
+			"asset_name":               bv.AssetName,
+			"asset_type_name":          bv.AssetTypeName,
+			"purchased_at":             bv.PurchasedAt.Time,
+			"purchase_cost":            bv.PurchaseCost,
+			"currency":                 bv.Currency,
+			"method":                   string(bv.Method),
+			"useful_life_months":       bv.UsefulLifeMonths,
+			"accumulated_depreciation": bv.AccumulatedDepreciation,
+			"book_value":               bv.BookValue,
+		}
+	}
+	f, _ := export.ParseFormat(format)
+	writeExport(c, "book-values-"+asOf.Format("2006-01-02"), f, bookValueColumns, rows)
+}
+
+// GetReport returns the purchase cost, accumulated depreciation and book value of the assets held
+// as of a date, grouped by asset type and currency
+func (h *DepreciationHandler) GetReport(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+	values, asOf, ok := h.bookValues(c)
+	if !ok {
+		return
+	}
+	summaries := depreciation.Summarize(values)
+
+	if format == "" || format == "json" {
+		c.JSON(http.StatusOK, gin.H{"AsOf": asOf, "AssetTypes": summaries})
+		return
+	}
+	rows := make([]map[string]interface{}, len(summaries))
+	for i, s := range summaries {
+		rows[i] = map[string]interface{}{
+			"asset_type_name":          s.AssetTypeName,
+			"currency":                 s.Currency,
+			"asset_count":              s.AssetCount,
+			"purchase_cost":            s.PurchaseCost,
+			"accumulated_depreciation": s.AccumulatedDepreciation,
+			"book_value":               s.BookValue,
+		}
+	}
+	f, _ := export.ParseFormat(format)
+	writeExport(c, "depreciation-"+asOf.Format("2006-01-02"), f, depreciationSummaryColumns, rows)
+}
+
+// bookValues calculates the book values as of the requested date. It writes an error response
+// and returns false if that fails.
+func (h *DepreciationHandler) bookValues(c *gin.Context) ([]models.BookValue, time.Time, bool) {
+	asOf, ok := parseAsOf(c)
+	if !ok {
+		return nil, asOf, false
+	}
+	values, err := h.repo.GetAsOf(context.Background(), asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to calculate book values"})
+		return nil, asOf, false
+	}
+	for i := range values {
+		depreciation.Apply(&values[i], asOf)
+	}
+	return values, asOf, true
+}
+
+// parseAsOf reads the as_of date (YYYY-MM-DD) of a query, defaulting to today. It writes a 400
+// response and returns false if the date is invalid.
+func parseAsOf(c *gin.Context) (time.Time, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), true
+	}
+	asOf, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid as_of date, expected YYYY-MM-DD"})
+		return asOf, false
+	}
+	return asOf, true
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/importer"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
+)
+
+// ImportHandler handles bulk import endpoints
+type ImportHandler struct {
+	repo          *repository.ImportRepository
+	assetRepo     *repository.AssetRepository
+	assetTypeRepo *repository.AssetTypeRepository
+	schemaRepo    *repository.AssetTypePropertyRepository
+	propertyRepo  *repository.PropertyRepository
+	personRepo    *repository.PersonRepository
+	unique        uniqueness.Rules
+	transitions   lifecycle.Transitions
+	recorder      *audit.Recorder
+}
+
+// NewImportHandler creates a new import handler
+func NewImportHandler(repo *repository.ImportRepository, assetRepo *repository.AssetRepository, assetTypeRepo *repository.AssetTypeRepository, schemaRepo *repository.AssetTypePropertyRepository, propertyRepo *repository.PropertyRepository, personRepo *repository.PersonRepository, unique uniqueness.Rules, transitions lifecycle.Transitions, recorder *audit.Recorder) *ImportHandler {
+	return &ImportHandler{
+		repo:          repo,
+		assetRepo:     assetRepo,
+		assetTypeRepo: assetTypeRepo,
+		schemaRepo:    schemaRepo,
+		propertyRepo:  propertyRepo,
+		personRepo:    personRepo,
+		unique:        unique,
+		transitions:   transitions,
+		recorder:      recorder,
+	}
+}
+
+// ImportAssets imports assets from an uploaded CSV or XLSX file. With dry_run=true the file is
+// validated and imported in a transaction that is rolled back, so it reports the errors a real
+// run would. A real run imports every row or, if any row is invalid, none.
+func (h *ImportHandler) ImportAssets(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "File upload required"})
+		return
+	}
+
+	format := importer.Format(c.Query("format"))
+	if format == "" {
+		format, err = importer.FormatFromFilename(fileHeader.Filename)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+
+	table, err := importer.Read(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+
+	lookup, err := h.buildLookup(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to load reference data"})
+		return
+	}
+
+	rows, importErrors := importer.MapAssets(table, lookup)
+	if len(importErrors) == 0 {
+		importErrors, err = h.checkUnique(context.Background(), rows)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check for duplicate assets"})
+			return
+		}
+	}
+	if len(importErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"DryRun":    dryRun,
+			"TotalRows": len(table.Rows),
+			"Errors":    importErrors,
+		})
+		return
+	}
+
+	err = h.repo.ImportAssets(context.Background(), rows, time.Now(), dryRun, h.unique, h.transitions, statusChangedBy(c))
+	var rowErr *repository.ImportRowError
+	if errors.As(err, &rowErr) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"DryRun":    dryRun,
+			"TotalRows": len(table.Rows),
+			"Errors":    []models.ImportError{{Row: rowErr.Row, Column: rowErr.Column, Error: importRowMessage(rowErr.Err)}},
+		})
+		return
+	}
+	if respondUnique(c, err) {
+		return
+	}
+	if err == repository.ErrDuplicateTag {
+		c.JSON(http.StatusConflict, gin.H{"Error": "A tag in the file is already in use by another asset"})
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to import assets: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to import assets"})
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"DryRun":    true,
+			"TotalRows": len(rows),
+			"Rows":      rows,
+		})
+		return
+	}
+
+	assetIDs := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		assetIDs = append(assetIDs, row.Asset.ID)
+		h.recorder.RecordCreate(c, models.AuditEntityAsset, row.Asset.ID, row.Asset)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"DryRun":   false,
+		"Imported": len(rows),
+		"AssetIDs": assetIDs,
+	})
+}
+
+// importRowMessage describes why the repository refused a row
+func importRowMessage(err error) string {
+	var conflictErr *repository.ReservationConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return "The asset is reserved for someone else while assigned"
+	case err == repository.ErrPersonInactive:
+		return "Persons who left cannot be assigned assets"
+	case err == repository.ErrPersonNotFound:
+		return "Person not found"
+	default:
+		return err.Error()
+	}
+}
+
+// checkUnique reports rows holding a value the uniqueness rules require to be unique that is
+// already used by an existing asset or by an earlier row of the file
+func (h *ImportHandler) checkUnique(ctx context.Context, rows []models.AssetImportRow) ([]models.ImportError, error) {
+	var errs []models.ImportError
+	firstRows := make(map[string]int)
+	for _, row := range rows {
+		for _, rule := range h.unique.For(uniqueness.EntityAsset) {
+			value := rule.AssetValue(&row.Asset)
+			if value == "" {
+				continue
+			}
+			key := rule.Key(value, row.Asset.AssetTypeID)
+			if first, ok := firstRows[key]; ok {
+				errs = append(errs, models.ImportError{Row: row.Row, Column: rule.Name(), Error: fmt.Sprintf("%q is also used in row %d", value, first)})
+				continue
+			}
+			firstRows[key] = row.Row
+
+			existing, err := h.assetRepo.FindConflict(ctx, rule, &row.Asset)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				errs = append(errs, models.ImportError{Row: row.Row, Column: rule.Name(), Error: rule.Message(value, existing.ID)})
+			}
+		}
+	}
+	return errs, nil
+}
+
+func (h *ImportHandler) buildLookup(ctx context.Context) (*importer.AssetLookup, error) {
+	assetTypes, err := h.assetTypeRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schemas := make(map[int64][]models.AssetTypeProperty, len(assetTypes))
+	for _, at := range assetTypes {
+		if schemas[at.ID], err = h.schemaRepo.GetByAssetTypeID(ctx, at.ID); err != nil {
+			return nil, err
+		}
+	}
+	properties, err := h.propertyRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	persons, err := h.personRepo.GetAll(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	return importer.NewAssetLookup(assetTypes, schemas, properties, persons), nil
+}
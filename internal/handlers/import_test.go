@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// TestImportAssets_InactiveAssignee imports, as a dry run, an asset assigned to a person who left
+// and expects the row to be refused with the rows left as they were.
+func TestImportAssets_InactiveAssignee(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	importRepo := repository.NewImportRepository(db)
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Import test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	leaver := &models.Person{Name: "Departed person " + suffix}
+	if err := personRepo.Create(ctx, leaver, nil); err != nil {
+		t.Fatalf("Failed to create person: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE persons SET is_active = FALSE, left_at = CURDATE() WHERE id = ?`, leaver.ID); err != nil {
+		t.Fatalf("Failed to deactivate person: %v", err)
+	}
+
+	rows := []models.AssetImportRow{{
+		Row:        2,
+		Asset:      models.Asset{AssetTypeID: assetType.ID, Name: "Imported laptop " + suffix},
+		AssigneeID: leaver.ID,
+	}}
+	err := importRepo.ImportAssets(ctx, rows, time.Now(), true, nil, lifecycle.DefaultTransitions(), models.AssetStatusChange{})
+	var rowErr *repository.ImportRowError
+	if !errors.As(err, &rowErr) || rowErr.Row != 2 || !errors.Is(err, repository.ErrPersonInactive) {
+		t.Fatalf("Expected row 2 to be refused for the inactive person, got %v", err)
+	}
+	if rows[0].Asset.ID != 0 {
+		t.Errorf("Expected the dry run to leave the rows as they were, got asset ID %d", rows[0].Asset.ID)
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/labels"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// maxLabelsPerRequest limits the size of a batch of labels
+const maxLabelsPerRequest = 1000
+
+// defaultLabelType is the label template of assets whose type no longer exists
+var defaultLabelType = models.AssetType{
+	LabelCode:    models.LabelCodeQR,
+	LabelContent: models.LabelContentURL,
+	LabelText:    "{name}",
+}
+
+// LabelHandler handles asset label endpoints
+type LabelHandler struct {
+	assetRepo     *repository.AssetRepository
+	assetTypeRepo *repository.AssetTypeRepository
+	lookupURL     string
+}
+
+// NewLabelHandler creates a new label handler. lookupURL is the URL label barcodes encode, with
+// {id} replaced by the asset ID.
+func NewLabelHandler(assetRepo *repository.AssetRepository, assetTypeRepo *repository.AssetTypeRepository, lookupURL string) *LabelHandler {
+	return &LabelHandler{
+		assetRepo:     assetRepo,
+		assetTypeRepo: assetTypeRepo,
+		lookupURL:     lookupURL,
+	}
+}
+
+// GetLayouts returns the supported label sheet layouts
+func (h *LabelHandler) GetLayouts(c *gin.Context) {
+	c.JSON(http.StatusOK, labels.Layouts)
+}
+
+// GetByAssetID returns the label of an asset as a PDF (format=pdf, the default) or as ZPL
+// (format=zpl). layout selects the label paper and skip the number of positions of the
+// sheet to leave empty.
+func (h *LabelHandler) GetByAssetID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+	skip, err := strconv.Atoi(c.DefaultQuery("skip", "0"))
+	if err != nil || skip < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid skip, must be a non-negative number"})
+		return
+	}
+
+	if _, err := h.assetRepo.GetByID(context.Background(), id); err != nil {
+		if err == repository.ErrAssetNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch asset"})
+		return
+	}
+	h.render(c, []int64{id}, c.DefaultQuery("format", "pdf"), c.Query("layout"), skip, fmt.Sprintf("asset-%d-label", id))
+}
+
+// Print returns the labels of several assets in one PDF or ZPL document, in the order given
+func (h *LabelHandler) Print(c *gin.Context) {
+	var req struct {
+		AssetIDs []int64 `json:"AssetIDs"`
+		Format   string  `json:"Format"`
+		Layout   string  `json:"Layout"`
+		Skip     int     `json:"Skip"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if len(req.AssetIDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "At least one asset is required"})
+		return
+	}
+	if len(req.AssetIDs) > maxLabelsPerRequest {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": fmt.Sprintf("At most %d labels can be printed at once", maxLabelsPerRequest)})
+		return
+	}
+	if req.Skip < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid skip, must be a non-negative number"})
+		return
+	}
+	if req.Format == "" {
+		req.Format = "pdf"
+	}
+	h.render(c, req.AssetIDs, req.Format, req.Layout, req.Skip, "asset-labels-"+time.Now().Format("20060102"))
+}
+
+// render writes the labels of the given assets as a download
+func (h *LabelHandler) render(c *gin.Context, ids []int64, format, layoutName string, skip int, name string) {
+	format = strings.ToLower(format)
+	if format != "pdf" && format != "zpl" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid format, must be pdf or zpl"})
+		return
+	}
+	if layoutName == "" && format == "zpl" {
+		layoutName = "roll-57x32"
+	}
+	layout, err := labels.FindLayout(layoutName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Unknown label layout"})
+		return
+	}
+
+	items, err := h.build(context.Background(), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assets"})
+		return
+	}
+	if len(items) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "No assets found"})
+		return
+	}
+
+	var buf bytes.Buffer
+	contentType := "application/pdf"
+	if format == "zpl" {
+		contentType = "application/zpl"
+		err = labels.WriteZPL(&buf, items, layout)
+	} else {
+		err = labels.WritePDF(&buf, items, layout, skip)
+	}
+	if errors.Is(err, labels.ErrUnencodable) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": "The barcode content is too long for the label code, use a QR code or encode the asset ID"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to render labels"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	c.Data(http.StatusOK, contentType, buf.Bytes())
+}
+
+// build loads the given assets and expands the label template of their type. Missing assets
+// are skipped.
+func (h *LabelHandler) build(ctx context.Context, ids []int64) ([]labels.Label, error) {
+	assets, err := h.assetRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	assetTypes, err := h.assetTypeRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	typesByID := make(map[int64]*models.AssetType, len(assetTypes))
+	for i := range assetTypes {
+		typesByID[assetTypes[i].ID] = &assetTypes[i]
+	}
+	assetsByID := make(map[int64]*models.Asset, len(assets))
+	for i := range assets {
+		assetsByID[assets[i].ID] = &assets[i]
+	}
+
+	var items []labels.Label
+	for _, id := range ids {
+		asset, ok := assetsByID[id]
+		if !ok {
+			continue
+		}
+		assetType, ok := typesByID[asset.AssetTypeID]
+		if !ok {
+			assetType = &defaultLabelType
+		}
+		items = append(items, labels.Build(asset, assetType, h.lookupURL))
+	}
+	return items, nil
+}
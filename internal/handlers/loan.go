@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/export"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// overdueLoanColumns are the columns of the overdue loans report
+var overdueLoanColumns = []models.ReportColumn{
+	{Key: "asset_tag", Label: "Tag"},
+	{Key: "asset_name", Label: "Asset"},
+	{Key: "asset_type_name", Label: "Type"},
+	{Key: "person_name", Label: "Borrower"},
+	{Key: "person_email", Label: "Email"},
+	{Key: "checked_out_at", Label: "Checked Out"},
+	{Key: "due_at", Label: "Due"},
+	{Key: "days_overdue", Label: "Days Overdue"},
+	{Key: "reminded_at", Label: "Last Reminded"},
+}
+
+// LoanHandler handles the loan desk: lending assets from stock pools until a due date and taking
+// them back
+type LoanHandler struct {
+	repo        *repository.AssetAssignmentRepository
+	personRepo  *repository.PersonRepository
+	transitions lifecycle.Transitions
+	recorder    *audit.Recorder
+}
+
+// NewLoanHandler creates a new loan handler
+func NewLoanHandler(repo *repository.AssetAssignmentRepository, personRepo *repository.PersonRepository, transitions lifecycle.Transitions, recorder *audit.Recorder) *LoanHandler {
+	return &LoanHandler{
+		repo:        repo,
+		personRepo:  personRepo,
+		transitions: transitions,
+		recorder:    recorder,
+	}
+}
+
+// GetActive returns the loans not yet checked in, soonest due first
+func (h *LoanHandler) GetActive(c *gin.Context) {
+	loans, err := h.repo.GetActiveLoans(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch loans"})
+		return
+	}
+	if len(loans) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, loans)
+}
+
+// CheckOut lends an asset held by a stock pool to a person until DueAt
+func (h *LoanHandler) CheckOut(c *gin.Context) {
+	var req models.CheckOutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if !req.DueAt.Valid || !req.DueAt.Time.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Due date must be in the future"})
+		return
+	}
+
+	person, err := h.personRepo.GetByID(context.Background(), req.PersonID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	}
+	if person.IsStock {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Assets cannot be loaned to stock pools"})
+		return
+	}
+
+	checkOut, err := h.repo.CheckOut(context.Background(), req, h.transitions, statusChangedBy(c))
+	if respondReserved(c, err) {
+		return
+	}
+	switch err {
+	case nil:
+	case repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case repository.ErrAssetNotAssignable:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be loaned"})
+		return
+	case repository.ErrPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	case repository.ErrPersonInactive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot borrow assets"})
+		return
+	case repository.ErrAssetNotInStock:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Only assets held by a stock pool can be checked out"})
+		return
+	case repository.ErrOverlappingAssignment:
+		c.JSON(http.StatusConflict, gin.H{"Error": "The asset is scheduled to move before the due date"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check out asset"})
+		return
+	}
+	if previous := checkOut.Previous; previous != nil {
+		if ended, err := h.repo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, ended)
+		}
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssignment, checkOut.Loan.ID, checkOut.Loan)
+	recordStatusChange(c, h.recorder, checkOut.StatusChange)
+
+	c.JSON(http.StatusCreated, checkOut.Loan)
+}
+
+// CheckIn takes a loaned asset back into a stock pool, the default pool unless StockPoolID is
+// given, recording the condition it was returned in (good unless given) and any notes
+func (h *LoanHandler) CheckIn(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("assetId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	var req models.CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if req.Condition == "" {
+		req.Condition = models.ReturnConditionGood
+	}
+	if !req.Condition.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Condition must be good, fair or damaged"})
+		return
+	}
+
+	pool, err := h.personRepo.GetStockPool(context.Background(), req.StockPoolID)
+	if err == repository.ErrPersonNotFound {
+		if req.StockPoolID != 0 {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Stock pool not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"Error": "No stock pool exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find stock pool"})
+		return
+	}
+
+	checkIn, err := h.repo.CheckIn(context.Background(), assetID, pool, req, h.transitions, statusChangedBy(c))
+	switch err {
+	case nil:
+	case repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case repository.ErrAssetNotOnLoan:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Asset is not on loan"})
+		return
+	case repository.ErrOverlappingAssignment:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to check in asset"})
+		return
+	}
+
+	if ended, err := h.repo.GetByID(context.Background(), checkIn.Loan.ID); err == nil {
+		h.recorder.RecordUpdate(c, models.AuditEntityAssignment, checkIn.Loan.ID, checkIn.Loan, ended)
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityAssignment, checkIn.Stock.ID, checkIn.Stock)
+	recordStatusChange(c, h.recorder, checkIn.StatusChange)
+
+	c.JSON(http.StatusOK, gin.H{"Message": "Asset checked in to " + pool.Name, "StatusChange": checkIn.StatusChange})
+}
+
+// GetOverdue returns the loans that were due back before now, longest overdue first.
+// format=csv, xlsx or pdf downloads them as a document.
+func (h *LoanHandler) GetOverdue(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+
+	now := time.Now()
+	loans, err := h.repo.GetOverdueLoans(context.Background(), now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch overdue loans"})
+		return
+	}
+
+	if format == "" || format == "json" {
+		if len(loans) == 0 {
+			c.Status(http.StatusNoContent)
+			return
+		}
+		c.JSON(http.StatusOK, loans)
+		return
+	}
+	rows := make([]map[string]interface{}, len(loans))
+	for i, loan := range loans {
+		var remindedAt interface{}
+		if loan.RemindedAt.Valid {
+			remindedAt = loan.RemindedAt.Time
+		}
+		rows[i] = map[string]interface{}{
+			"asset_tag":       loan.AssetTag,
+			"asset_name":      loan.AssetName,
+			"asset_type_name": loan.AssetTypeName,
+			"person_name":     loan.PersonName,
+			"person_email":    loan.PersonEmail,
+			"checked_out_at":  loan.CheckedOutAt,
+			"due_at":          loan.DueAt,
+			"days_overdue":    loan.DaysOverdue(now),
+			"reminded_at":     remindedAt,
+		}
+	}
+	f, _ := export.ParseFormat(format)
+	writeExport(c, "overdue-loans", f, overdueLoanColumns, rows)
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// TestCheckOut_DeploysAsset checks out an asset in stock and expects its move to deployed in the
+// changelog of the asset.
+func TestCheckOut_DeploysAsset(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	recorder := audit.NewRecorder(auditRepo)
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Check-out test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Lent camera " + suffix}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	borrower := &models.Person{Name: "Borrowing person " + suffix}
+	if err := personRepo.Create(ctx, borrower, nil); err != nil {
+		t.Fatalf("Failed to create person: %v", err)
+	}
+
+	handler := NewLoanHandler(assignmentRepo, personRepo, lifecycle.DefaultTransitions(), recorder)
+	router := gin.New()
+	router.POST("/api/loans/check-out", handler.CheckOut)
+
+	body, _ := json.Marshal(models.CheckOutRequest{
+		AssetID:  asset.ID,
+		PersonID: borrower.ID,
+		DueAt:    models.NewNullTime(time.Now().Add(24 * time.Hour).Truncate(time.Second)),
+	})
+	req := httptest.NewRequest(http.MethodPost, "/api/loans/check-out", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	lent, err := assetRepo.GetByID(ctx, asset.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch asset: %v", err)
+	}
+	if lent.Status != models.AssetStatusDeployed {
+		t.Errorf("Expected the asset to be deployed, got %s", lent.Status)
+	}
+	assertStatusLogged(t, auditRepo, asset.ID, models.AssetStatusInStock, models.AssetStatusDeployed)
+}
+
+// TestCheckIn_StatusChangeInChangelog checks a loaned asset back in damaged and expects its move
+// to repair in the changelog of the asset.
+func TestCheckIn_StatusChangeInChangelog(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	recorder := audit.NewRecorder(auditRepo)
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Check-in test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Loaned projector " + suffix}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	pool := &models.Person{Name: "Loan desk pool " + suffix, IsStock: true}
+	borrower := &models.Person{Name: "Borrowing person " + suffix}
+	for _, person := range []*models.Person{pool, borrower} {
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+	}
+	transitions := lifecycle.DefaultTransitions()
+	if _, err := assignmentRepo.CheckOut(ctx, models.CheckOutRequest{
+		AssetID:  asset.ID,
+		PersonID: borrower.ID,
+		DueAt:    models.NewNullTime(time.Now().Add(24 * time.Hour).Truncate(time.Second)),
+	}, transitions, models.AssetStatusChange{}); err != nil {
+		t.Fatalf("Failed to check out asset: %v", err)
+	}
+
+	handler := NewLoanHandler(assignmentRepo, personRepo, transitions, recorder)
+	router := gin.New()
+	router.POST("/api/loans/check-in/:assetId", handler.CheckIn)
+
+	body, _ := json.Marshal(models.CheckInRequest{StockPoolID: pool.ID, Condition: models.ReturnConditionDamaged})
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/loans/check-in/%d", asset.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	assertStatusLogged(t, auditRepo, asset.ID, models.AssetStatusDeployed, models.AssetStatusInRepair)
+}
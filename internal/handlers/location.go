@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// LocationHandler handles location and asset placement endpoints
+type LocationHandler struct {
+	repo          *repository.LocationRepository
+	placementRepo *repository.AssetLocationRepository
+	recorder      *audit.Recorder
+}
+
+// NewLocationHandler creates a new location handler
+func NewLocationHandler(repo *repository.LocationRepository, placementRepo *repository.AssetLocationRepository, recorder *audit.Recorder) *LocationHandler {
+	return &LocationHandler{
+		repo:          repo,
+		placementRepo: placementRepo,
+		recorder:      recorder,
+	}
+}
+
+// GetAll returns all locations with their paths, each following its parent
+func (h *LocationHandler) GetAll(c *gin.Context) {
+	locations, err := h.repo.GetAll(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch locations"})
+		return
+	}
+	if len(locations) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetByID returns a location by ID
+func (h *LocationHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	location, err := h.repo.GetByID(context.Background(), id)
+	if err == repository.ErrLocationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Location not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch location"})
+		return
+	}
+	c.JSON(http.StatusOK, location)
+}
+
+// GetAssets returns the assets currently placed at a location. With subtree=true assets at
+// all sub-locations are included.
+func (h *LocationHandler) GetAssets(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	if _, err := h.repo.GetByID(context.Background(), id); err != nil {
+		if err == repository.ErrLocationNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Location not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch location"})
+		return
+	}
+
+	placements, err := h.placementRepo.GetCurrentByLocationID(context.Background(), id, c.Query("subtree") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assets"})
+		return
+	}
+	if len(placements) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, placements)
+}
+
+// Create creates a new location
+func (h *LocationHandler) Create(c *gin.Context) {
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if !validLocation(c, &location) {
+		return
+	}
+
+	err := h.repo.Create(context.Background(), &location)
+	if err == repository.ErrLocationNotFound {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Parent location not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create location"})
+		return
+	}
+
+	created, err := h.repo.GetByID(context.Background(), location.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch location"})
+		return
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityLocation, created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// Update updates a location, including moving it below another parent
+func (h *LocationHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var location models.Location
+	if err := c.ShouldBindJSON(&location); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	location.ID = id
+	if !validLocation(c, &location) {
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err == repository.ErrLocationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Location not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update location"})
+		return
+	}
+
+	err = h.repo.Update(context.Background(), &location)
+	switch err {
+	case nil:
+	case repository.ErrLocationNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Parent location not found"})
+		return
+	case repository.ErrLocationCycle:
+		c.JSON(http.StatusConflict, gin.H{"Error": "A location cannot be moved below itself or one of its sub-locations"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update location"})
+		return
+	}
+
+	after, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch location"})
+		return
+	}
+	h.recorder.RecordUpdate(c, models.AuditEntityLocation, id, before, after)
+	c.JSON(http.StatusOK, after)
+}
+
+// Delete deletes a location without sub-locations or placed assets
+func (h *LocationHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Location not found"})
+		return
+	}
+
+	err = h.repo.Delete(context.Background(), id)
+	if err == repository.ErrLocationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Location not found"})
+		return
+	}
+	if err == repository.ErrLocationInUse {
+		c.JSON(http.StatusConflict, gin.H{"Error": "Locations with sub-locations or placed assets cannot be deleted"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete location"})
+		return
+	}
+	h.recorder.RecordDelete(c, models.AuditEntityLocation, id, before)
+	c.JSON(http.StatusOK, gin.H{"Message": "Location deleted"})
+}
+
+// GetCurrentByAssetID returns the current location of an asset, or null if it is not placed
+func (h *LocationHandler) GetCurrentByAssetID(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	placement, err := h.placementRepo.GetCurrentByAssetID(context.Background(), assetID)
+	if err != nil {
+		if err == repository.ErrAssetLocationNotFound {
+			c.JSON(http.StatusOK, nil)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch asset location"})
+		return
+	}
+	c.JSON(http.StatusOK, placement)
+}
+
+// GetHistoryByAssetID returns the placement history of an asset
+func (h *LocationHandler) GetHistoryByAssetID(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	placements, err := h.placementRepo.GetHistoryByAssetID(context.Background(), assetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch location history"})
+		return
+	}
+	if len(placements) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, placements)
+}
+
+// MoveAsset places an asset at a location, ending its previous placement
+func (h *LocationHandler) MoveAsset(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	var req struct {
+		LocationID    int64      `json:"LocationID"`
+		Notes         string     `json:"Notes"`
+		EffectiveDate *time.Time `json:"EffectiveDate"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+
+	// Default to now if no date provided
+	effectiveDate := time.Now()
+	if req.EffectiveDate != nil {
+		effectiveDate = *req.EffectiveDate
+	}
+
+	previous, placement, err := h.placementRepo.MoveAsset(context.Background(), assetID, req.LocationID, req.Notes, effectiveDate)
+	switch err {
+	case nil:
+	case repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case repository.ErrLocationNotFound:
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Location not found"})
+		return
+	case repository.ErrOverlappingPlacement:
+		c.JSON(http.StatusConflict, gin.H{"Error": "The asset has a later placement than the effective date"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to move asset"})
+		return
+	}
+	h.recordEndedPlacement(c, previous, placement.EffectiveFrom.Time)
+	h.recorder.RecordCreate(c, models.AuditEntityAssetLocation, placement.ID, placement)
+
+	c.JSON(http.StatusOK, gin.H{"Message": "Asset moved successfully"})
+}
+
+// RemoveAsset ends the current placement of an asset without moving it elsewhere
+func (h *LocationHandler) RemoveAsset(c *gin.Context) {
+	assetID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+		return
+	}
+
+	effectiveDate := time.Now()
+	previous, err := h.placementRepo.RemoveAsset(context.Background(), assetID, effectiveDate)
+	switch err {
+	case nil:
+	case repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case repository.ErrAssetLocationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset has no location"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to remove asset location"})
+		return
+	}
+	h.recordEndedPlacement(c, previous, effectiveDate.Truncate(time.Second))
+
+	c.JSON(http.StatusOK, gin.H{"Message": "Asset location removed"})
+}
+
+// recordEndedPlacement audits the end of a placement, if there was one
+func (h *LocationHandler) recordEndedPlacement(c *gin.Context, previous *models.AssetLocation, endDate time.Time) {
+	if previous == nil {
+		return
+	}
+	ended := *previous
+	ended.EffectiveTo = models.NewNullTime(endDate)
+	h.recorder.RecordUpdate(c, models.AuditEntityAssetLocation, previous.ID, previous, ended)
+}
+
+// validLocation checks the name and kind of a location, defaulting the kind to other.
+// It writes a 400 response and returns false if the location is invalid.
+func validLocation(c *gin.Context, location *models.Location) bool {
+	location.Name = strings.TrimSpace(location.Name)
+	if location.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Name is required"})
+		return false
+	}
+	if location.Kind == "" {
+		location.Kind = models.LocationKindOther
+	}
+	if !location.Kind.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid location kind"})
+		return false
+	}
+	return true
+}
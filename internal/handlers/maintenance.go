@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
+)
+
+// matchLevels orders match kinds from the closest to the loosest
+var matchLevels = map[uniqueness.Match]int{
+	uniqueness.MatchExact:      0,
+	uniqueness.MatchNormalized: 1,
+	uniqueness.MatchFuzzy:      2,
+}
+
+// duplicateGroup is a set of assets or persons that likely describe the same thing
+type duplicateGroup struct {
+	Field   string           `json:"Field"`
+	Match   uniqueness.Match `json:"Match"`
+	Value   string           `json:"Value"`
+	Assets  []models.Asset   `json:"Assets,omitempty"`
+	Persons []models.Person  `json:"Persons,omitempty"`
+}
+
+// MaintenanceHandler handles data maintenance endpoints
+type MaintenanceHandler struct {
+	assetRepo  *repository.AssetRepository
+	personRepo *repository.PersonRepository
+}
+
+// NewMaintenanceHandler creates a new maintenance handler
+func NewMaintenanceHandler(assetRepo *repository.AssetRepository, personRepo *repository.PersonRepository) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		assetRepo:  assetRepo,
+		personRepo: personRepo,
+	}
+}
+
+// GetDuplicates returns groups of existing assets with matching serial numbers and of persons with
+// matching emails or names. match=exact or match=normalized leaves out looser matches.
+func (h *MaintenanceHandler) GetDuplicates(c *gin.Context) {
+	loosest := uniqueness.MatchFuzzy
+	if match := c.Query("match"); match != "" {
+		loosest = uniqueness.Match(match)
+		if _, ok := matchLevels[loosest]; !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid match, must be exact, normalized or fuzzy"})
+			return
+		}
+	}
+
+	assets, err := h.assetRepo.GetAll(context.Background(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch assets"})
+		return
+	}
+	persons, err := h.personRepo.GetAll(context.Background(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch persons"})
+		return
+	}
+
+	assetsByID := make(map[int64]models.Asset, len(assets))
+	serials := make([]uniqueness.Item, 0, len(assets))
+	for _, a := range assets {
+		assetsByID[a.ID] = a
+		serials = append(serials, uniqueness.Item{ID: a.ID, Value: a.SerialNumber})
+	}
+	// Stock pools are not people and may share contact details
+	personsByID := make(map[int64]models.Person, len(persons))
+	var emails, names []uniqueness.Item
+	for _, p := range persons {
+		if p.IsStock {
+			continue
+		}
+		personsByID[p.ID] = p
+		emails = append(emails, uniqueness.Item{ID: p.ID, Value: p.Email})
+		names = append(names, uniqueness.Item{ID: p.ID, Value: p.Name})
+	}
+
+	assetGroups := []duplicateGroup{}
+	for _, g := range uniqueness.SerialNumbers.Find(serials) {
+		if matchLevels[g.Match] > matchLevels[loosest] {
+			continue
+		}
+		group := duplicateGroup{Field: "SerialNumber", Match: g.Match, Value: g.Value}
+		for _, id := range g.IDs {
+			group.Assets = append(group.Assets, assetsByID[id])
+		}
+		assetGroups = append(assetGroups, group)
+	}
+
+	personGroups := []duplicateGroup{}
+	for _, f := range []struct {
+		name    string
+		matcher uniqueness.Matcher
+		items   []uniqueness.Item
+	}{
+		{"Email", uniqueness.Emails, emails},
+		{"Name", uniqueness.Names, names},
+	} {
+		for _, g := range f.matcher.Find(f.items) {
+			if matchLevels[g.Match] > matchLevels[loosest] {
+				continue
+			}
+			group := duplicateGroup{Field: f.name, Match: g.Match, Value: g.Value}
+			for _, id := range g.IDs {
+				group.Persons = append(group.Persons, personsByID[id])
+			}
+			personGroups = append(personGroups, group)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"Assets": assetGroups, "Persons": personGroups})
+}
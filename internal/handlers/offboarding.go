@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/export"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// offboardingChecklistColumns are the columns of the offboarding checklist document
+var offboardingChecklistColumns = []models.ReportColumn{
+	{Key: "asset_tag", Label: "Tag"},
+	{Key: "asset_name", Label: "Asset"},
+	{Key: "asset_type_name", Label: "Type"},
+	{Key: "asset_serial_number", Label: "Serial Number"},
+	{Key: "status", Label: "Status"},
+	{Key: "returned_at", Label: "Returned At"},
+	{Key: "condition", Label: "Condition"},
+	{Key: "notes", Label: "Notes"},
+}
+
+// OffboardingHandler handles the offboarding of persons who leave
+type OffboardingHandler struct {
+	personRepo     *repository.PersonRepository
+	assignmentRepo *repository.AssetAssignmentRepository
+	transitions    lifecycle.Transitions
+	recorder       *audit.Recorder
+}
+
+// NewOffboardingHandler creates a new offboarding handler
+func NewOffboardingHandler(personRepo *repository.PersonRepository, assignmentRepo *repository.AssetAssignmentRepository, transitions lifecycle.Transitions, recorder *audit.Recorder) *OffboardingHandler {
+	return &OffboardingHandler{
+		personRepo:     personRepo,
+		assignmentRepo: assignmentRepo,
+		transitions:    transitions,
+		recorder:       recorder,
+	}
+}
+
+// Offboard records the return of the assets a leaving person hands back to a stock pool, the
+// default pool unless StockPoolID is given, and marks the person inactive as of LeaveDate, today
+// by default. Leave dates in the future are rejected, as the person is deactivated at once.
+// Assets without a returned item stay with the person and are reported as missing.
+func (h *OffboardingHandler) Offboard(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var req models.OffboardingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	for _, item := range req.Items {
+		if item.Returned && !item.Condition.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Returned items need a condition of good, fair or damaged"})
+			return
+		}
+	}
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if req.LeaveDate.Valid {
+		year, month, day := req.LeaveDate.Time.Date()
+		req.LeaveDate = models.NewNullTime(time.Date(year, month, day, 0, 0, 0, 0, time.Local))
+	} else {
+		req.LeaveDate = models.NewNullTime(today)
+	}
+	if req.LeaveDate.Time.After(today) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Leave date cannot be in the future"})
+		return
+	}
+
+	pool, err := h.personRepo.GetStockPool(context.Background(), req.StockPoolID)
+	if err == repository.ErrPersonNotFound {
+		if req.StockPoolID != 0 {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Stock pool not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"Error": "No stock pool exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to find stock pool"})
+		return
+	}
+
+	changedBy := statusChangedBy(c)
+	offboarding, err := h.personRepo.Offboard(context.Background(), id, pool, req, h.transitions, changedBy)
+	switch err {
+	case nil:
+	case repository.ErrPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	case repository.ErrOffboardStockPool:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Stock pools cannot be offboarded"})
+		return
+	case repository.ErrAssetNotHeld:
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Items must be assets the person currently holds"})
+		return
+	case repository.ErrOverlappingAssignment:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Overlapping assignment exists"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to offboard person"})
+		return
+	}
+
+	person := offboarding.Person
+	person.IsActive = false
+	person.LeftAt = req.LeaveDate
+	h.recordOffboarding(c, offboarding, person)
+
+	missing := offboarding.Missing
+	if missing == nil {
+		missing = []models.AssetAssignment{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"Person":        person,
+		"Returned":      len(offboarding.Returned),
+		"StatusChanges": len(offboarding.StatusChanges),
+		"Cancelled":     len(offboarding.Cancelled),
+		"Pending":       len(offboarding.Pending),
+		"Missing":       missing,
+	})
+}
+
+// GetChecklist returns the offboarding checklist of a person: the assets still held, as missing,
+// and the assets returned with their condition. format=pdf, csv or xlsx downloads it as a document.
+func (h *OffboardingHandler) GetChecklist(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+
+	person, err := h.personRepo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	}
+	items, err := h.personRepo.GetOffboardingChecklist(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch offboarding checklist"})
+		return
+	}
+
+	if format == "" || format == "json" {
+		if items == nil {
+			items = []models.OffboardingChecklistItem{}
+		}
+		c.JSON(http.StatusOK, gin.H{"Person": person, "Items": items})
+		return
+	}
+	rows := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		status := "Missing"
+		var returnedAt interface{}
+		if item.Returned {
+			status = "Returned"
+			returnedAt = item.ReturnedAt.Time
+		}
+		rows[i] = map[string]interface{}{
+			"asset_tag":           item.AssetTag,
+			"asset_name":          item.AssetName,
+			"asset_type_name":     item.AssetTypeName,
+			"asset_serial_number": item.AssetSerialNumber,
+			"status":              status,
+			"returned_at":         returnedAt,
+			"condition":           string(item.Condition),
+			"notes":               item.Notes,
+		}
+	}
+	f, _ := export.ParseFormat(format)
+	writeExport(c, "offboarding-"+strconv.FormatInt(person.ID, 10), f, offboardingChecklistColumns, rows)
+}
+
+// recordOffboarding records the person leaving, the cancelled, extended, ended and new
+// assignments, the status changes of the returned assets and the cancelled reservations
+func (h *OffboardingHandler) recordOffboarding(c *gin.Context, offboarding *models.Offboarding, person models.Person) {
+	h.recorder.RecordUpdate(c, models.AuditEntityPerson, person.ID, offboarding.Person, person)
+	for _, pending := range offboarding.Pending {
+		h.recorder.RecordDelete(c, models.AuditEntityAssignment, pending.ID, pending)
+	}
+	for _, previous := range offboarding.Extended {
+		if extended, err := h.assignmentRepo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, extended)
+		}
+	}
+	for _, previous := range offboarding.Returned {
+		if ended, err := h.assignmentRepo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, ended)
+		}
+	}
+	for _, assignment := range offboarding.Stock {
+		h.recorder.RecordCreate(c, models.AuditEntityAssignment, assignment.ID, assignment)
+	}
+	for i := range offboarding.StatusChanges {
+		recordStatusChange(c, h.recorder, &offboarding.StatusChanges[i])
+	}
+	for _, before := range offboarding.Cancelled {
+		after := before
+		after.Status = models.ReservationStatusCancelled
+		h.recorder.RecordUpdate(c, models.AuditEntityReservation, before.ID, before, after)
+	}
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// assertStatusLogged checks that the changelog of an asset holds its status change
+func assertStatusLogged(t *testing.T, auditRepo *repository.AuditLogRepository, assetID int64, from, to models.AssetStatus) {
+	t.Helper()
+	entries, err := auditRepo.GetByAssetID(context.Background(), assetID)
+	if err != nil {
+		t.Fatalf("Failed to fetch asset changelog: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.EntityType != models.AuditEntityAsset || entry.EntityID != assetID {
+			continue
+		}
+		var changes map[string]audit.Change
+		if err := json.Unmarshal(entry.Changes, &changes); err != nil {
+			t.Fatalf("Failed to decode changes of entry %d: %v", entry.ID, err)
+		}
+		if status, ok := changes["Status"]; ok && status.Old == string(from) && status.New == string(to) {
+			return
+		}
+	}
+	t.Errorf("Expected the changelog of asset %d to hold the status change from %s to %s, got %d entries", assetID, from, to, len(entries))
+}
+
+// TestOffboard_StatusChangeInChangelog offboards a person returning a deployed asset and expects
+// the status change of the asset in its changelog.
+func TestOffboard_StatusChangeInChangelog(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	auditRepo := repository.NewAuditLogRepository(db)
+	recorder := audit.NewRecorder(auditRepo)
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Offboarding test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Returned laptop " + suffix, Status: models.AssetStatusDeployed}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	pool := &models.Person{Name: "Offboarding pool " + suffix, IsStock: true}
+	leaver := &models.Person{Name: "Leaving person " + suffix}
+	for _, person := range []*models.Person{pool, leaver} {
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+	}
+	assignment := &models.AssetAssignment{
+		AssetID:       asset.ID,
+		PersonID:      leaver.ID,
+		EffectiveFrom: models.NewNullTime(time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)),
+	}
+	if err := assignmentRepo.Create(ctx, assignment); err != nil {
+		t.Fatalf("Failed to create assignment: %v", err)
+	}
+
+	handler := NewOffboardingHandler(personRepo, assignmentRepo, lifecycle.DefaultTransitions(), recorder)
+	router := gin.New()
+	router.POST("/api/persons/:id/offboard", handler.Offboard)
+
+	body, _ := json.Marshal(models.OffboardingRequest{
+		StockPoolID: pool.ID,
+		Items:       []models.OffboardingItem{{AssetID: asset.ID, Returned: true, Condition: models.ReturnConditionGood}},
+	})
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/persons/%d/offboard", leaver.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	assertStatusLogged(t, auditRepo, asset.ID, models.AssetStatusDeployed, models.AssetStatusInStock)
+}
+
+// TestOffboard_CancelsPending offboards a person with an assignment scheduled to start next week
+// and expects it to be cancelled, with the stock pool keeping the asset.
+func TestOffboard_CancelsPending(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	recorder := audit.NewRecorder(repository.NewAuditLogRepository(db))
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Pending offboarding test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Scheduled laptop " + suffix}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	pool := &models.Person{Name: "Pending offboarding pool " + suffix, IsStock: true}
+	leaver := &models.Person{Name: "Leaving person " + suffix}
+	for _, person := range []*models.Person{pool, leaver} {
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+	}
+	startsAt := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	stock := &models.AssetAssignment{
+		AssetID:       asset.ID,
+		PersonID:      pool.ID,
+		EffectiveFrom: models.NewNullTime(time.Now().Add(-24 * time.Hour).Truncate(time.Second)),
+		EffectiveTo:   models.NewNullTime(startsAt),
+	}
+	pending := &models.AssetAssignment{AssetID: asset.ID, PersonID: leaver.ID, EffectiveFrom: models.NewNullTime(startsAt)}
+	for _, aa := range []*models.AssetAssignment{stock, pending} {
+		if err := assignmentRepo.Create(ctx, aa); err != nil {
+			t.Fatalf("Failed to create assignment: %v", err)
+		}
+	}
+
+	handler := NewOffboardingHandler(personRepo, assignmentRepo, lifecycle.DefaultTransitions(), recorder)
+	router := gin.New()
+	router.POST("/api/persons/:id/offboard", handler.Offboard)
+
+	body, _ := json.Marshal(models.OffboardingRequest{StockPoolID: pool.ID})
+	req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/persons/%d/offboard", leaver.ID), bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	if _, err := assignmentRepo.GetByID(ctx, pending.ID); err != repository.ErrAssetAssignmentNotFound {
+		t.Errorf("Expected the pending assignment to be cancelled, got %v", err)
+	}
+	extended, err := assignmentRepo.GetByID(ctx, stock.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch stock assignment: %v", err)
+	}
+	if extended.EffectiveTo.Valid {
+		t.Errorf("Expected the stock pool to keep the asset with no end date, got %v", extended.EffectiveTo.Time)
+	}
+}
+
+// TestOffboardingChecklist_ExcludesLoans offboards a person who borrowed and checked in another
+// asset before and expects only the asset returned on offboarding in the checklist.
+func TestOffboardingChecklist_ExcludesLoans(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	transitions := lifecycle.DefaultTransitions()
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Checklist test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	loaned := &models.Asset{AssetTypeID: assetType.ID, Name: "Loaned tablet " + suffix}
+	held := &models.Asset{AssetTypeID: assetType.ID, Name: "Held laptop " + suffix}
+	for _, asset := range []*models.Asset{loaned, held} {
+		if err := assetRepo.Create(ctx, asset); err != nil {
+			t.Fatalf("Failed to create asset: %v", err)
+		}
+	}
+	pool := &models.Person{Name: "Checklist pool " + suffix, IsStock: true}
+	leaver := &models.Person{Name: "Leaving person " + suffix}
+	for _, person := range []*models.Person{pool, leaver} {
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+	}
+
+	if _, err := assignmentRepo.CheckOut(ctx, models.CheckOutRequest{
+		AssetID:  loaned.ID,
+		PersonID: leaver.ID,
+		DueAt:    models.NewNullTime(time.Now().Add(24 * time.Hour).Truncate(time.Second)),
+	}, transitions, models.AssetStatusChange{}); err != nil {
+		t.Fatalf("Failed to check out asset: %v", err)
+	}
+	if _, err := assignmentRepo.CheckIn(ctx, loaned.ID, pool, models.CheckInRequest{Condition: models.ReturnConditionGood}, transitions, models.AssetStatusChange{}); err != nil {
+		t.Fatalf("Failed to check in asset: %v", err)
+	}
+	assignment := &models.AssetAssignment{
+		AssetID:       held.ID,
+		PersonID:      leaver.ID,
+		EffectiveFrom: models.NewNullTime(time.Now().Add(-30 * 24 * time.Hour).Truncate(time.Second)),
+	}
+	if err := assignmentRepo.Create(ctx, assignment); err != nil {
+		t.Fatalf("Failed to create assignment: %v", err)
+	}
+	if _, err := personRepo.Offboard(ctx, leaver.ID, pool, models.OffboardingRequest{
+		LeaveDate: models.NewNullTime(time.Now().Truncate(time.Second)),
+		Items:     []models.OffboardingItem{{AssetID: held.ID, Returned: true, Condition: models.ReturnConditionGood}},
+	}, transitions, models.AssetStatusChange{}); err != nil {
+		t.Fatalf("Failed to offboard person: %v", err)
+	}
+
+	items, err := personRepo.GetOffboardingChecklist(ctx, leaver.ID)
+	if err != nil {
+		t.Fatalf("Failed to fetch checklist: %v", err)
+	}
+	if len(items) != 1 || items[0].AssetID != held.ID || !items[0].Returned {
+		t.Errorf("Expected only the held asset as returned in the checklist, got %+v", items)
+	}
+}
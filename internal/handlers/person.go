@@ -2,26 +2,35 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
 )
 
 // PersonHandler handles person endpoints
 type PersonHandler struct {
-	repo          *repository.PersonRepository
-	attributeRepo *repository.PersonAttributeRepository
+	repo             *repository.PersonRepository
+	attributeRepo    *repository.PersonAttributeRepository
+	attributeDefRepo *repository.AttributeRepository
+	unique           uniqueness.Rules
+	recorder         *audit.Recorder
 }
 
 // NewPersonHandler creates a new person handler
-func NewPersonHandler(repo *repository.PersonRepository, attributeRepo *repository.PersonAttributeRepository) *PersonHandler {
+func NewPersonHandler(repo *repository.PersonRepository, attributeRepo *repository.PersonAttributeRepository, attributeDefRepo *repository.AttributeRepository, unique uniqueness.Rules, recorder *audit.Recorder) *PersonHandler {
 	return &PersonHandler{
-		repo:          repo,
-		attributeRepo: attributeRepo,
+		repo:             repo,
+		attributeRepo:    attributeRepo,
+		attributeDefRepo: attributeDefRepo,
+		unique:           unique,
+		recorder:         recorder,
 	}
 }
 
@@ -40,6 +49,20 @@ func (h *PersonHandler) GetAll(c *gin.Context) {
 	c.JSON(http.StatusOK, persons)
 }
 
+// GetStockPools returns the stock pools assets can be returned to; the first one is the default
+func (h *PersonHandler) GetStockPools(c *gin.Context) {
+	pools, err := h.repo.GetStockPools(context.Background())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch stock pools"})
+		return
+	}
+	if len(pools) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, pools)
+}
+
 // GetByID returns a person by ID
 func (h *PersonHandler) GetByID(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -50,6 +73,11 @@ func (h *PersonHandler) GetByID(c *gin.Context) {
 
 	person, err := h.repo.GetByID(context.Background(), id)
 	if err != nil {
+		// Links to a merged person lead clients to the survivor
+		if mergedIntoID, mergeErr := h.repo.GetMergedIntoID(context.Background(), id); mergeErr == nil && mergedIntoID != 0 {
+			c.JSON(http.StatusNotFound, gin.H{"Error": "Person was merged into another person", "MergedIntoID": mergedIntoID})
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
 		return
 	}
@@ -84,10 +112,14 @@ func (h *PersonHandler) Create(c *gin.Context) {
 		return
 	}
 
-	if err := h.repo.Create(context.Background(), &person); err != nil {
+	if err := h.repo.Create(context.Background(), &person, h.unique); err != nil {
+		if respondUnique(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create person"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityPerson, person.ID, person)
 	c.JSON(http.StatusCreated, person)
 }
 
@@ -106,10 +138,34 @@ func (h *PersonHandler) Update(c *gin.Context) {
 	}
 	person.ID = id
 
-	if err := h.repo.Update(context.Background(), &person); err != nil {
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	}
+	// Persons only become inactive through offboarding
+	person.IsActive = before.IsActive
+	person.LeftAt = before.LeftAt
+	if !person.IsStock {
+		last, err := h.isLastStockPool(context.Background(), before)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update person"})
+			return
+		}
+		if last {
+			c.JSON(http.StatusConflict, gin.H{"Error": "The last stock pool cannot be turned into a person"})
+			return
+		}
+	}
+
+	if err := h.repo.Update(context.Background(), &person, h.unique); err != nil {
+		if respondUnique(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update person"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityPerson, id, before, person)
 	c.JSON(http.StatusOK, person)
 }
 
@@ -121,10 +177,27 @@ func (h *PersonHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	}
+
+	last, err := h.isLastStockPool(context.Background(), before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete person"})
+		return
+	}
+	if last {
+		c.JSON(http.StatusConflict, gin.H{"Error": "The last stock pool cannot be deleted"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete person"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityPerson, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Person deleted"})
 }
 
@@ -163,10 +236,39 @@ func (h *PersonHandler) SetAttribute(c *gin.Context) {
 	}
 	pa.PersonID = id
 
+	attribute, err := h.attributeDefRepo.GetByID(context.Background(), pa.AttributeID)
+	if err == repository.ErrAttributeNotFound {
+		respondInvalidValues(c, "Invalid attribute value", []models.FieldError{
+			{FieldID: pa.AttributeID, Value: pa.Value, Error: "attribute does not exist"},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set attribute"})
+		return
+	}
+	value, fieldErr := validateCustomValue(attribute.Name, attribute.ID, attribute.DataType, attribute.EnumOptions, pa.Value)
+	if fieldErr != nil {
+		respondInvalidValues(c, "Invalid attribute value", []models.FieldError{*fieldErr})
+		return
+	}
+	pa.Value = value
+
+	before, err := h.attributeRepo.GetByPersonAndAttributeID(context.Background(), id, pa.AttributeID)
+	if err != nil && err != repository.ErrPersonAttributeNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set attribute"})
+		return
+	}
+
 	if err := h.attributeRepo.Upsert(context.Background(), &pa); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to set attribute"})
 		return
 	}
+	if before == nil {
+		h.recorder.RecordCreate(c, models.AuditEntityPersonAttribute, pa.ID, pa)
+	} else {
+		h.recorder.RecordUpdate(c, models.AuditEntityPersonAttribute, pa.ID, before, pa)
+	}
 	c.JSON(http.StatusOK, pa)
 }
 
@@ -178,9 +280,135 @@ func (h *PersonHandler) DeleteAttribute(c *gin.Context) {
 		return
 	}
 
+	before, err := h.attributeRepo.GetByID(context.Background(), attrID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Attribute not found"})
+		return
+	}
+
 	if err := h.attributeRepo.Delete(context.Background(), attrID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete attribute"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityPersonAttribute, attrID, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Attribute deleted"})
 }
+
+// Merge merges the source person of the request into this one. Its assignments, attribute values
+// and attachments move here and the source is deleted with a pointer to this person. Attributes
+// both have set to different values need a rule in the request; without one the merge fails with
+// 409 listing the conflicts.
+func (h *PersonHandler) Merge(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var req models.PersonMergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if req.SourceID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Source person is required"})
+		return
+	}
+	if req.Keep != "" && !req.Keep.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid keep rule, must be target or source"})
+		return
+	}
+	for _, keep := range req.Attributes {
+		if !keep.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid attribute rule, must be target or source"})
+			return
+		}
+	}
+
+	merge, err := h.repo.Merge(context.Background(), id, req)
+	var conflictErr *repository.MergeConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{"Error": "Both persons have different values for some attributes", "Conflicts": conflictErr.Conflicts})
+		return
+	case err == repository.ErrMergeSamePerson:
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "A person cannot be merged into itself"})
+		return
+	case err == repository.ErrPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	case err == repository.ErrMergeStockPool:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Stock pools cannot be merged"})
+		return
+	case err == repository.ErrPersonInactive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot be merged into"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to merge persons"})
+		return
+	}
+
+	target, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch person"})
+		return
+	}
+	h.recordMerge(c, merge, target)
+	c.JSON(http.StatusOK, gin.H{
+		"Person":            target,
+		"Assignments":       len(merge.Assignments),
+		"MovedAttributes":   len(merge.MovedAttributes),
+		"DroppedAttributes": len(merge.DroppedAttributes),
+		"Attachments":       len(merge.Attachments),
+		"Reservations":      len(merge.Reservations),
+	})
+}
+
+// recordMerge writes the audit log entries of a merge: every moved or dropped record, the
+// deletion of the source and the merge on the target
+func (h *PersonHandler) recordMerge(c *gin.Context, merge *models.PersonMerge, target *models.Person) {
+	source := merge.Source
+	for _, before := range merge.Assignments {
+		after := before
+		after.PersonID, after.PersonName = target.ID, target.Name
+		h.recorder.RecordUpdate(c, models.AuditEntityAssignment, before.ID, before, after)
+	}
+	for _, before := range merge.MovedAttributes {
+		after := before
+		after.PersonID = target.ID
+		h.recorder.RecordUpdate(c, models.AuditEntityPersonAttribute, before.ID, before, after)
+	}
+	for _, before := range merge.DroppedAttributes {
+		h.recorder.RecordDelete(c, models.AuditEntityPersonAttribute, before.ID, before)
+	}
+	for _, before := range merge.Reservations {
+		after := before
+		after.PersonID, after.PersonName = target.ID, target.Name
+		h.recorder.RecordUpdate(c, models.AuditEntityReservation, before.ID, before, after)
+	}
+	for _, attachmentID := range merge.Attachments {
+		h.recorder.RecordChanges(c, models.AuditEntityAttachment, attachmentID, models.AuditActionUpdate, map[string]audit.Change{
+			"EntityID": {Old: source.ID, New: target.ID},
+		})
+	}
+	h.recorder.RecordChanges(c, models.AuditEntityPerson, source.ID, models.AuditActionDelete, map[string]audit.Change{
+		"Name":         {Old: source.Name, New: nil},
+		"MergedIntoID": {Old: nil, New: target.ID},
+	})
+	h.recorder.RecordChanges(c, models.AuditEntityPerson, target.ID, models.AuditActionUpdate, map[string]audit.Change{
+		"MergedFrom": {Old: nil, New: source.ID},
+	})
+}
+
+// isLastStockPool reports whether the person is the only remaining stock pool. Assets must always
+// have a stock pool to be returned to.
+func (h *PersonHandler) isLastStockPool(ctx context.Context, person *models.Person) (bool, error) {
+	if !person.IsStock {
+		return false, nil
+	}
+	pools, err := h.repo.GetStockPools(ctx)
+	if err != nil {
+		return false, err
+	}
+	return len(pools) <= 1, nil
+}
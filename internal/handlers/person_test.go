@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
+)
+
+// TestCreatePerson_ConcurrentUnique creates persons with the same email from many goroutines at
+// once under a rule making emails unique and checks that exactly one of them is created.
+func TestCreatePerson_ConcurrentUnique(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	personRepo := repository.NewPersonRepository(db)
+	recorder := audit.NewRecorder(repository.NewAuditLogRepository(db))
+	unique := uniqueness.Rules{{Entity: uniqueness.EntityPerson, Field: "email", Scope: uniqueness.ScopeGlobal}}
+
+	handler := NewPersonHandler(personRepo, repository.NewPersonAttributeRepository(db), repository.NewAttributeRepository(db), unique, recorder)
+	router := gin.New()
+	router.POST("/api/persons", handler.Create)
+
+	email := fmt.Sprintf("unique.%s@example.com", time.Now().Format("20060102150405.000000000"))
+	const requests = 20
+	var wg sync.WaitGroup
+	statuses := make(chan int, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body, _ := json.Marshal(map[string]interface{}{
+				"Name":  fmt.Sprintf("Concurrent person %d", i),
+				"Email": email,
+			})
+			req := httptest.NewRequest(http.MethodPost, "/api/persons", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			statuses <- w.Code
+		}(i)
+	}
+	wg.Wait()
+	close(statuses)
+
+	created := 0
+	for status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			// The email was taken by a request that committed first
+		default:
+			t.Errorf("Unexpected status %d", status)
+		}
+	}
+	if created != 1 {
+		t.Errorf("Expected exactly one person to be created, got %d", created)
+	}
+
+	var count int
+	if err := db.GetContext(ctx, &count, `SELECT COUNT(*) FROM persons WHERE email = ? AND deleted_at IS NULL`, email); err != nil {
+		t.Fatalf("Failed to count persons: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected one person with email %s, got %d", email, count)
+	}
+}
@@ -7,18 +7,23 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
 )
 
 // PropertyHandler handles property endpoints
 type PropertyHandler struct {
-	repo *repository.PropertyRepository
+	repo     *repository.PropertyRepository
+	recorder *audit.Recorder
 }
 
 // NewPropertyHandler creates a new property handler
-func NewPropertyHandler(repo *repository.PropertyRepository) *PropertyHandler {
-	return &PropertyHandler{repo: repo}
+func NewPropertyHandler(repo *repository.PropertyRepository, recorder *audit.Recorder) *PropertyHandler {
+	return &PropertyHandler{
+		repo:     repo,
+		recorder: recorder,
+	}
 }
 
 // GetAll returns all properties
@@ -63,6 +68,7 @@ func (h *PropertyHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create property: " + err.Error()})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityProperty, property.ID, property)
 	c.JSON(http.StatusCreated, property)
 }
 
@@ -81,10 +87,17 @@ func (h *PropertyHandler) Update(c *gin.Context) {
 	}
 	property.ID = id
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Property not found"})
+		return
+	}
+
 	if err := h.repo.Update(context.Background(), &property); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update property"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityProperty, id, before, property)
 	c.JSON(http.StatusOK, property)
 }
 
@@ -96,9 +109,16 @@ func (h *PropertyHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Property not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete property"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityProperty, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "Property deleted"})
 }
@@ -2,11 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/export"
+	"assetManager/internal/models"
 	"assetManager/internal/repository"
 )
 
@@ -28,26 +34,60 @@ func (h *ReportHandler) ExecuteCustomReport(c *gin.Context) {
 		return
 	}
 
+	format := c.DefaultQuery("format", req.Format)
+	if !validReportFormat(c, format) {
+		return
+	}
+	asOf, ok := parseAsOfTime(c)
+	if !ok {
+		return
+	}
+	if asOf.IsZero() && req.AsOf.Valid {
+		asOf = req.AsOf.Time
+	}
+
 	ctx := context.Background()
+	name := req.EntityType + "-report"
+	// Sorting needs every row, so sorted reports are exported from memory
+	if f, ok := exportFormat(format); ok && (req.Sort == nil || req.Sort.Field == "") {
+		switch req.EntityType {
+		case "asset":
+			h.streamExport(c, name, f, req.EntityType, func(write func(map[string]interface{}) error) error {
+				return h.repo.StreamAssetReport(ctx, req.FilterTree(), asOf, write)
+			})
+		case "person":
+			h.streamExport(c, name, f, req.EntityType, func(write func(map[string]interface{}) error) error {
+				return h.repo.StreamPersonReport(ctx, req.FilterTree(), asOf, write)
+			})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid entity type. Must be 'asset' or 'person'"})
+		}
+		return
+	}
+
 	var results []map[string]interface{}
 	var err error
 
 	switch req.EntityType {
 	case "asset":
-		results, err = h.repo.ExecuteAssetReport(ctx, req.Filters)
+		results, err = h.repo.ExecuteAssetReport(ctx, req.FilterTree(), req.Sort, asOf)
 	case "person":
-		results, err = h.repo.ExecutePersonReport(ctx, req.Filters)
+		results, err = h.repo.ExecutePersonReport(ctx, req.FilterTree(), req.Sort, asOf)
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid entity type. Must be 'asset' or 'person'"})
 		return
 	}
 
+	if errors.Is(err, repository.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	h.writeReport(c, name, format, req.EntityType, results)
 }
 
 // ExecuteMultipleAssetsReport handles multiple assets report execution
@@ -58,11 +98,249 @@ func (h *ReportHandler) ExecuteMultipleAssetsReport(c *gin.Context) {
 		return
 	}
 
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+
+	if f, ok := exportFormat(format); ok {
+		h.streamExport(c, "multiple-assets-report", f, "multiple-assets", func(write func(map[string]interface{}) error) error {
+			return h.repo.StreamMultipleAssetsReport(context.Background(), assetTypeID, write)
+		})
+		return
+	}
+
 	results, err := h.repo.ExecuteMultipleAssetsReport(context.Background(), assetTypeID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, results)
+	h.writeReport(c, "multiple-assets-report", format, "multiple-assets", results)
+}
+
+// GetAssetListing returns all assets with their current assignee and properties.
+// If include_deleted is true, returns only soft-deleted assets. With location_id only assets
+// placed at that location or one of its sub-locations are returned. With as_of the assets are
+// listed as they were at that time.
+func (h *ReportHandler) GetAssetListing(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+	asOf, ok := parseAsOfTime(c)
+	if !ok {
+		return
+	}
+
+	var filter *repository.FilterGroup
+	if locationID := c.Query("location_id"); locationID != "" {
+		filter = &repository.FilterGroup{Conditions: []repository.FilterCondition{
+			{Field: "LocationID", Operator: "IN SUBTREE", Value: locationID},
+		}}
+	}
+
+	deleted := c.Query("include_deleted") == "true"
+	if f, ok := exportFormat(format); ok {
+		h.streamExport(c, "asset-listing", f, "asset", func(write func(map[string]interface{}) error) error {
+			return h.repo.StreamAssetReport(context.Background(), filter, asOf, writeDeleted(write, deleted))
+		})
+		return
+	}
+
+	results, err := h.repo.ExecuteAssetReport(context.Background(), filter, nil, asOf)
+	if errors.Is(err, repository.ErrInvalidFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+
+	results = filterDeleted(results, deleted)
+	h.writeReport(c, "asset-listing", format, "asset", results)
+}
+
+// GetPersonListing returns all persons with their attributes.
+// If include_deleted is true, returns only soft-deleted persons. With as_of the persons are
+// listed as they were at that time.
+func (h *ReportHandler) GetPersonListing(c *gin.Context) {
+	format := c.Query("format")
+	if !validReportFormat(c, format) {
+		return
+	}
+	asOf, ok := parseAsOfTime(c)
+	if !ok {
+		return
+	}
+
+	deleted := c.Query("include_deleted") == "true"
+	if f, ok := exportFormat(format); ok {
+		h.streamExport(c, "person-listing", f, "person", func(write func(map[string]interface{}) error) error {
+			return h.repo.StreamPersonReport(context.Background(), nil, asOf, writeDeleted(write, deleted))
+		})
+		return
+	}
+
+	results, err := h.repo.ExecutePersonReport(context.Background(), nil, nil, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+
+	results = filterDeleted(results, deleted)
+	h.writeReport(c, "person-listing", format, "person", results)
+}
+
+// validReportFormat checks the requested output format and responds with 400 if it is unknown
+func validReportFormat(c *gin.Context, format string) bool {
+	if format == "" || format == "json" {
+		return true
+	}
+	if _, err := export.ParseFormat(format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+		return false
+	}
+	return true
+}
+
+// parseAsOfTime reads the optional as_of query parameter, an RFC 3339 timestamp or a date meaning
+// the start of that day in the server's time zone. It returns the zero time, meaning now, if the parameter is absent and
+// writes an error response and returns false if it is invalid.
+func parseAsOfTime(c *gin.Context) (time.Time, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		return time.Time{}, true
+	}
+	if asOf, err := time.Parse(time.RFC3339, value); err == nil {
+		return asOf, true
+	}
+	asOf, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid as_of, expected an RFC 3339 timestamp or YYYY-MM-DD"})
+		return asOf, false
+	}
+	return asOf, true
+}
+
+// exportFormat returns the export format requested, false for JSON
+func exportFormat(format string) (export.Format, bool) {
+	if format == "" || format == "json" {
+		return "", false
+	}
+	f, err := export.ParseFormat(format)
+	return f, err == nil
+}
+
+// writeReport responds with the results as JSON, or as a file download when an export format is requested
+func (h *ReportHandler) writeReport(c *gin.Context, name, format, kind string, results []map[string]interface{}) {
+	f, ok := exportFormat(format)
+	if !ok {
+		c.JSON(http.StatusOK, results)
+		return
+	}
+
+	base, err := h.reportColumns(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	writeExport(c, name, f, export.ResolveColumns(base, results), results)
+}
+
+// streamExport responds with the rows stream passes to write as a file download, writing each
+// row as it arrives instead of loading the report first. Until the first row is written errors
+// are answered as JSON, 400 for invalid filters. Columns are those of the kind of report, with
+// one per active property or attribute. PDF writers still hold every row until the document is
+// laid out on Close.
+func (h *ReportHandler) streamExport(c *gin.Context, name string, f export.Format, kind string, stream func(write func(map[string]interface{}) error) error) {
+	columns, err := h.reportColumns(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+
+	var writer export.Writer
+	start := func() error {
+		c.Header("Content-Type", f.ContentType())
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", f.Filename(name)))
+		c.Status(http.StatusOK)
+		var err error
+		writer, err = export.NewWriter(f, c.Writer, name, columns)
+		return err
+	}
+	err = stream(func(row map[string]interface{}) error {
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.WriteRow(row)
+	})
+	if err != nil && writer == nil {
+		if errors.Is(err, repository.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": err.Error()})
+		return
+	}
+	if writer == nil {
+		// No rows, the document only has its header
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	// Headers are already sent, so a failure can only be logged
+	if err != nil {
+		log.Printf("Failed to export %s as %s: %v", name, f, err)
+	}
+}
+
+// reportColumns returns the columns of a kind of report
+func (h *ReportHandler) reportColumns(kind string) ([]models.ReportColumn, error) {
+	ctx := context.Background()
+	switch kind {
+	case "asset":
+		return h.repo.AssetReportColumns(ctx)
+	case "person":
+		return h.repo.PersonReportColumns(ctx)
+	default:
+		return h.repo.MultipleAssetsReportColumns(ctx)
+	}
+}
+
+// writeExport responds with the rows as a file download in an export format
+func writeExport(c *gin.Context, name string, f export.Format, columns []models.ReportColumn, rows []map[string]interface{}) {
+	c.Header("Content-Type", f.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", f.Filename(name)))
+	c.Status(http.StatusOK)
+
+	// Headers are already sent, so a failure can only be logged
+	if err := export.Write(f, c.Writer, name, columns, rows); err != nil {
+		log.Printf("Failed to export %s as %s: %v", name, f, err)
+	}
+}
+
+// writeDeleted passes active rows, or only soft-deleted rows if deleted is true, on to write
+func writeDeleted(write func(map[string]interface{}) error, deleted bool) func(map[string]interface{}) error {
+	return func(row map[string]interface{}) error {
+		if (row["deleted_at"] != nil) != deleted {
+			return nil
+		}
+		return write(row)
+	}
+}
+
+// filterDeleted keeps active rows, or only soft-deleted rows if deleted is true
+func filterDeleted(results []map[string]interface{}, deleted bool) []map[string]interface{} {
+	filtered := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		if (result["deleted_at"] != nil) == deleted {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
 }
@@ -0,0 +1,423 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/auth"
+	"assetManager/internal/ical"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// calendarHistory is how far back calendar feeds list reservations that have ended
+const calendarHistory = 90 * 24 * time.Hour
+
+// ReservationHandler handles reservations of assets, or of any asset of a type, and the
+// calendar feeds that publish them
+type ReservationHandler struct {
+	repo           *repository.ReservationRepository
+	assignmentRepo *repository.AssetAssignmentRepository
+	personRepo     *repository.PersonRepository
+	transitions    lifecycle.Transitions
+	recorder       *audit.Recorder
+}
+
+// NewReservationHandler creates a new reservation handler
+func NewReservationHandler(repo *repository.ReservationRepository, assignmentRepo *repository.AssetAssignmentRepository, personRepo *repository.PersonRepository, transitions lifecycle.Transitions, recorder *audit.Recorder) *ReservationHandler {
+	return &ReservationHandler{
+		repo:           repo,
+		assignmentRepo: assignmentRepo,
+		personRepo:     personRepo,
+		transitions:    transitions,
+		recorder:       recorder,
+	}
+}
+
+// List returns the reservations that have not ended, soonest first. assetId and personId narrow
+// them down and all=true includes ended and cancelled reservations.
+func (h *ReservationHandler) List(c *gin.Context) {
+	var filter repository.ReservationFilter
+	if value := c.Query("assetId"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid asset ID"})
+			return
+		}
+		filter.AssetID = id
+	}
+	if value := c.Query("personId"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid person ID"})
+			return
+		}
+		filter.PersonID = id
+	}
+	if c.Query("all") == "true" {
+		filter.IncludeCancelled = true
+	} else {
+		filter.EndsAfter = time.Now()
+	}
+
+	reservations, err := h.repo.List(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservations"})
+		return
+	}
+	if len(reservations) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusOK, reservations)
+}
+
+// GetByID returns a reservation
+func (h *ReservationHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	reservation, err := h.repo.GetByID(context.Background(), id)
+	if err == repository.ErrReservationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Reservation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservation"})
+		return
+	}
+	c.JSON(http.StatusOK, reservation)
+}
+
+// Create reserves AssetID, or any asset of AssetTypeID, for a person from StartsAt until EndsAt.
+// Conflicting reservations and assignments, or a shortage of assets of the type, are returned
+// with status 409 as Conflicts.
+func (h *ReservationHandler) Create(c *gin.Context) {
+	var reservation models.Reservation
+	if err := c.ShouldBindJSON(&reservation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	if (reservation.AssetID == nil) == (reservation.AssetTypeID == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Either an asset or an asset type is required"})
+		return
+	}
+	if reservation.StartsAt.IsZero() || !reservation.EndsAt.After(reservation.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Reservations must end after they start"})
+		return
+	}
+	if !reservation.EndsAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Reservations must end in the future"})
+		return
+	}
+	// Timestamps are stored with second precision
+	reservation.StartsAt = reservation.StartsAt.Truncate(time.Second)
+	reservation.EndsAt = reservation.EndsAt.Truncate(time.Second)
+
+	person, err := h.personRepo.GetByID(context.Background(), reservation.PersonID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	}
+	if person.IsStock {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Stock pools cannot reserve assets"})
+		return
+	}
+
+	err = h.repo.Create(context.Background(), &reservation)
+	var conflictErr *repository.ReservationConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{"Error": "The reservation conflicts with other bookings", "Conflicts": conflictErr.Conflicts})
+		return
+	case err == repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case err == repository.ErrAssetTypeNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset type not found"})
+		return
+	case err == repository.ErrAssetNotAssignable:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be reserved"})
+		return
+	case err == repository.ErrPersonNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Person not found"})
+		return
+	case err == repository.ErrPersonInactive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot reserve assets"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create reservation"})
+		return
+	}
+
+	created, err := h.repo.GetByID(context.Background(), reservation.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservation"})
+		return
+	}
+	h.recorder.RecordCreate(c, models.AuditEntityReservation, created.ID, created)
+	c.JSON(http.StatusCreated, created)
+}
+
+// Cancel cancels a reservation that was not picked up
+func (h *ReservationHandler) Cancel(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	before, err := h.repo.Cancel(context.Background(), id)
+	switch err {
+	case nil:
+	case repository.ErrReservationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Reservation not found"})
+		return
+	case repository.ErrReservationNotActive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Only reservations that were not picked up can be cancelled"})
+		return
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to cancel reservation"})
+		return
+	}
+
+	if after, err := h.repo.GetByID(context.Background(), id); err == nil {
+		h.recorder.RecordUpdate(c, models.AuditEntityReservation, id, before, after)
+	}
+	c.JSON(http.StatusOK, gin.H{"Message": "Reservation cancelled"})
+}
+
+// Pickup hands the reserved asset to the person of the reservation as a loan due back when the
+// reservation ends. Reservations of any asset of a type need the AssetID picked up.
+func (h *ReservationHandler) Pickup(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+
+	var req models.PickupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
+		return
+	}
+	reservation, err := h.repo.GetByID(context.Background(), id)
+	if err == repository.ErrReservationNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Reservation not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservation"})
+		return
+	}
+	if reservation.AssetID == nil && req.AssetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Select the asset to pick up"})
+		return
+	}
+
+	before, checkOut, err := h.repo.Pickup(context.Background(), id, req.AssetID, h.transitions, statusChangedBy(c))
+	var conflictErr *repository.ReservationConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		c.JSON(http.StatusConflict, gin.H{"Error": "The asset is reserved for someone else before the reservation ends", "Conflicts": conflictErr.Conflicts})
+		return
+	case err == repository.ErrReservationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Reservation not found"})
+		return
+	case err == repository.ErrReservationNotActive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Reservation was already picked up or cancelled"})
+		return
+	case err == repository.ErrReservationEnded:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Reservation has ended"})
+		return
+	case err == repository.ErrReservationAssetType:
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "The asset is not of the reserved type"})
+		return
+	case err == repository.ErrAssetNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Asset not found"})
+		return
+	case err == repository.ErrAssetNotAssignable:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Retired and disposed assets cannot be picked up"})
+		return
+	case err == repository.ErrPersonInactive:
+		c.JSON(http.StatusConflict, gin.H{"Error": "Persons who left cannot pick up assets"})
+		return
+	case err == repository.ErrAssetNotInStock:
+		c.JSON(http.StatusConflict, gin.H{"Error": "The asset has not been returned to a stock pool yet"})
+		return
+	case err == repository.ErrOverlappingAssignment:
+		c.JSON(http.StatusConflict, gin.H{"Error": "The asset is scheduled to move before the reservation ends"})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to pick up reservation"})
+		return
+	}
+
+	if previous := checkOut.Previous; previous != nil {
+		if ended, err := h.assignmentRepo.GetByID(context.Background(), previous.ID); err == nil {
+			h.recorder.RecordUpdate(c, models.AuditEntityAssignment, previous.ID, previous, ended)
+		}
+	}
+	after, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservation"})
+		return
+	}
+	h.recorder.RecordUpdate(c, models.AuditEntityReservation, id, before, after)
+	h.recorder.RecordCreate(c, models.AuditEntityAssignment, checkOut.Loan.ID, checkOut.Loan)
+	recordStatusChange(c, h.recorder, checkOut.StatusChange)
+
+	c.JSON(http.StatusOK, gin.H{"Reservation": after, "Assignment": checkOut.Loan})
+}
+
+// AssetFeedURL returns the address of the calendar feed of an asset
+func (h *ReservationHandler) AssetFeedURL(c *gin.Context) {
+	h.feedURL(c, "assets", false)
+}
+
+// PersonFeedURL returns the address of the calendar feed of a person
+func (h *ReservationHandler) PersonFeedURL(c *gin.Context) {
+	h.feedURL(c, "persons", false)
+}
+
+// RegenerateAssetFeedURL gives the calendar feed of an asset a new address, revoking the old one
+func (h *ReservationHandler) RegenerateAssetFeedURL(c *gin.Context) {
+	h.feedURL(c, "assets", true)
+}
+
+// RegeneratePersonFeedURL gives the calendar feed of a person a new address, revoking the old one
+func (h *ReservationHandler) RegeneratePersonFeedURL(c *gin.Context) {
+	h.feedURL(c, "persons", true)
+}
+
+// feedURL returns the address of the calendar feed of the asset or person with the id parameter,
+// with a new key if regenerate is set. The address holds the key granting access, as calendar
+// applications cannot log in.
+func (h *ReservationHandler) feedURL(c *gin.Context, kind string, regenerate bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid ID"})
+		return
+	}
+	feed := fmt.Sprintf("%s/%d", kind, id)
+	var key string
+	if regenerate {
+		key, err = h.repo.RegenerateFeedKey(context.Background(), feed)
+	} else {
+		key, err = h.repo.FeedKey(context.Background(), feed)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch calendar address"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"URL": fmt.Sprintf("/api/calendar/%s.ics?key=%s", feed, key)})
+}
+
+// AssetCalendar serves the reservations of an asset as an iCalendar feed
+func (h *ReservationHandler) AssetCalendar(c *gin.Context) {
+	h.calendar(c, "assets")
+}
+
+// PersonCalendar serves the reservations of a person as an iCalendar feed
+func (h *ReservationHandler) PersonCalendar(c *gin.Context) {
+	h.calendar(c, "persons")
+}
+
+// calendar serves the reservations of the asset or person named by the feed parameter, such as
+// 12.ics, if the key parameter grants access to the feed
+func (h *ReservationHandler) calendar(c *gin.Context, kind string) {
+	id, err := strconv.ParseInt(strings.TrimSuffix(c.Param("feed"), ".ics"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "Calendar not found"})
+		return
+	}
+	feedKey, err := h.repo.GetFeedKey(context.Background(), fmt.Sprintf("%s/%d", kind, id))
+	if err != nil && err != repository.ErrFeedNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch calendar"})
+		return
+	}
+	if !auth.ValidFeedKey(c.Query("key"), feedKey) {
+		c.JSON(http.StatusUnauthorized, gin.H{"Error": "Invalid calendar key"})
+		return
+	}
+
+	filter := repository.ReservationFilter{EndsAfter: time.Now().Add(-calendarHistory)}
+	if kind == "assets" {
+		filter.AssetID = id
+	} else {
+		filter.PersonID = id
+	}
+	reservations, err := h.repo.List(context.Background(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to fetch reservations"})
+		return
+	}
+
+	// Asset calendars show who reserved the asset, person calendars what the person reserved
+	name := "Reservations"
+	events := make([]ical.Event, len(reservations))
+	for i, r := range reservations {
+		summary := reservedAsset(r)
+		if kind == "assets" {
+			name = reservedAsset(r) + " reservations"
+			summary = "Reserved by " + r.PersonName
+		} else {
+			name = r.PersonName + " reservations"
+		}
+		description := "Status: " + strings.ReplaceAll(string(r.Status), "_", " ")
+		if r.Notes != "" {
+			description += "\n" + r.Notes
+		}
+		events[i] = ical.Event{
+			UID:         fmt.Sprintf("reservation-%d@asset-manager", r.ID),
+			Start:       r.StartsAt,
+			End:         r.EndsAt,
+			Summary:     summary,
+			Description: description,
+			Modified:    r.UpdatedAt,
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := ical.Write(&buf, name, events); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to write calendar"})
+		return
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// respondReserved responds with status 409 and the conflicting reservations if err is a
+// *repository.ReservationConflictError, and reports whether it did
+func respondReserved(c *gin.Context, err error) bool {
+	var conflictErr *repository.ReservationConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{"Error": "The asset is reserved for someone else during that time", "Conflicts": conflictErr.Conflicts})
+	return true
+}
+
+// reservedAsset names the asset of a reservation, or its type for a reservation of any asset of
+// a type that was not picked up yet
+func reservedAsset(r models.Reservation) string {
+	if r.AssetID == nil {
+		return "Any " + r.AssetTypeName
+	}
+	if r.AssetTag != "" {
+		return r.AssetTag + " " + r.AssetName
+	}
+	return r.AssetName
+}
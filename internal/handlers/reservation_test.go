@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/audit"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+)
+
+// TestCheckOut_Reserved checks out an asset reserved for someone else and expects loans running
+// into the reservation to be refused with the reservation as the conflict.
+func TestCheckOut_Reserved(t *testing.T) {
+	db := openTestDB(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	assetTypeRepo := repository.NewAssetTypeRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	personRepo := repository.NewPersonRepository(db)
+	assignmentRepo := repository.NewAssetAssignmentRepository(db)
+	reservationRepo := repository.NewReservationRepository(db)
+	recorder := audit.NewRecorder(repository.NewAuditLogRepository(db))
+
+	suffix := time.Now().Format("20060102150405.000000000")
+	assetType := &models.AssetType{Name: "Reservation test " + suffix}
+	if err := assetTypeRepo.Create(ctx, assetType); err != nil {
+		t.Fatalf("Failed to create asset type: %v", err)
+	}
+	asset := &models.Asset{AssetTypeID: assetType.ID, Name: "Demo device " + suffix}
+	if err := assetRepo.Create(ctx, asset); err != nil {
+		t.Fatalf("Failed to create asset: %v", err)
+	}
+	alice := &models.Person{Name: "Reserving person " + suffix}
+	bob := &models.Person{Name: "Borrowing person " + suffix}
+	for _, person := range []*models.Person{alice, bob} {
+		if err := personRepo.Create(ctx, person, nil); err != nil {
+			t.Fatalf("Failed to create person: %v", err)
+		}
+	}
+
+	startsAt := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	reservation := &models.Reservation{
+		AssetID:  &asset.ID,
+		PersonID: alice.ID,
+		StartsAt: startsAt,
+		EndsAt:   startsAt.Add(24 * time.Hour),
+	}
+	if err := reservationRepo.Create(ctx, reservation); err != nil {
+		t.Fatalf("Failed to create reservation: %v", err)
+	}
+
+	handler := NewLoanHandler(assignmentRepo, personRepo, lifecycle.DefaultTransitions(), recorder)
+	router := gin.New()
+	router.POST("/api/loans/check-out", handler.CheckOut)
+	checkOut := func(dueAt time.Time) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{
+			"AssetID":  asset.ID,
+			"PersonID": bob.ID,
+			"DueAt":    dueAt,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/loans/check-out", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := checkOut(startsAt.Add(time.Hour))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d for a loan into the reservation, got %d: %s", http.StatusConflict, w.Code, w.Body.String())
+	}
+	var response struct {
+		Conflicts []models.ReservationConflict
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Conflicts) != 1 || response.Conflicts[0].ID != reservation.ID {
+		t.Errorf("Expected reservation %d as the conflict, got %+v", reservation.ID, response.Conflicts)
+	}
+	if history, err := assignmentRepo.GetHistoryByAssetID(ctx, asset.ID); err != nil {
+		t.Fatalf("Failed to fetch history: %v", err)
+	} else if len(history) != 0 {
+		t.Errorf("Expected the refused loan to leave no assignments, got %d", len(history))
+	}
+
+	// A loan due back before the reservation starts does not take the asset from anyone
+	if w := checkOut(startsAt.Add(-time.Hour)); w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d for a loan ending before the reservation, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+}
@@ -7,19 +7,25 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	"assetManager/internal/audit"
 	"assetManager/internal/auth"
+	"assetManager/internal/middleware"
 	"assetManager/internal/models"
 	"assetManager/internal/repository"
 )
 
 // UserHandler handles user management endpoints
 type UserHandler struct {
-	repo *repository.UserRepository
+	repo     *repository.UserRepository
+	recorder *audit.Recorder
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(repo *repository.UserRepository) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(repo *repository.UserRepository, recorder *audit.Recorder) *UserHandler {
+	return &UserHandler{
+		repo:     repo,
+		recorder: recorder,
+	}
 }
 
 // GetAll returns all users
@@ -55,16 +61,26 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 // Create creates a new user
 func (h *UserHandler) Create(c *gin.Context) {
 	var req struct {
-		Username string `json:"Username"`
-		Email    string `json:"Email"`
-		Password string `json:"Password"`
-		IsActive bool   `json:"IsActive"`
+		Username string      `json:"Username"`
+		Email    string      `json:"Email"`
+		Password string      `json:"Password"`
+		Role     models.Role `json:"Role"`
+		IsActive bool        `json:"IsActive"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid request body"})
 		return
 	}
 
+	// Default new users to the least privileged role
+	if req.Role == "" {
+		req.Role = models.RoleAuditor
+	}
+	if !req.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid role"})
+		return
+	}
+
 	passwordHash, err := auth.HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to hash password"})
@@ -75,6 +91,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 		Username:     req.Username,
 		Email:        req.Email,
 		PasswordHash: passwordHash,
+		Role:         req.Role,
 		IsActive:     req.IsActive,
 	}
 
@@ -82,6 +99,7 @@ func (h *UserHandler) Create(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to create user"})
 		return
 	}
+	h.recorder.RecordCreate(c, models.AuditEntityUser, user.ID, user)
 	c.JSON(http.StatusCreated, user)
 }
 
@@ -100,10 +118,30 @@ func (h *UserHandler) Update(c *gin.Context) {
 	}
 	user.ID = id
 
+	existing, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "User not found"})
+		return
+	}
+
+	// Keep the current role when the client does not send one
+	if user.Role == "" {
+		user.Role = existing.Role
+	}
+	if !user.Role.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "Invalid role"})
+		return
+	}
+	if id == middleware.GetUserID(c) && user.Role != existing.Role {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "You cannot change your own role"})
+		return
+	}
+
 	if err := h.repo.Update(context.Background(), &user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to update user"})
 		return
 	}
+	h.recorder.RecordUpdate(c, models.AuditEntityUser, id, existing, user)
 	c.JSON(http.StatusOK, user)
 }
 
@@ -133,6 +171,10 @@ func (h *UserHandler) ResetPassword(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to reset password"})
 		return
 	}
+	// Never record password hashes, only the fact that the password changed
+	h.recorder.RecordChanges(c, models.AuditEntityUser, id, models.AuditActionUpdate, map[string]audit.Change{
+		"Password": {Old: nil, New: "reset"},
+	})
 	c.JSON(http.StatusOK, gin.H{"Message": "Password reset successfully"})
 }
 
@@ -144,9 +186,21 @@ func (h *UserHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	if id == middleware.GetUserID(c) {
+		c.JSON(http.StatusBadRequest, gin.H{"Error": "You cannot delete your own account"})
+		return
+	}
+
+	before, err := h.repo.GetByID(context.Background(), id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"Error": "User not found"})
+		return
+	}
+
 	if err := h.repo.Delete(context.Background(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to delete user"})
 		return
 	}
+	h.recorder.RecordDelete(c, models.AuditEntityUser, id, before)
 	c.JSON(http.StatusOK, gin.H{"Message": "User deleted"})
 }
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/datatype"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
+	"assetManager/internal/uniqueness"
+)
+
+// validateCustomValue checks a property or attribute value against its definition and
+// returns the value in canonical form
+func validateCustomValue(name string, id int64, dataType models.DataType, enumOptions, value string) (string, *models.FieldError) {
+	canonical, err := datatype.Canonical(dataType, enumOptions, value)
+	if err != nil {
+		return "", &models.FieldError{Field: name, FieldID: id, Value: value, Error: err.Error()}
+	}
+	return canonical, nil
+}
+
+// respondInvalidValues sends a 422 response listing the invalid property or attribute values
+func respondInvalidValues(c *gin.Context, message string, errs []models.FieldError) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{"Error": message, "Errors": errs})
+}
+
+// respondConflict sends a 409 response naming the field of the violated uniqueness rule and the
+// record already holding the value
+func respondConflict(c *gin.Context, rule uniqueness.Rule, value string, id int64, existing interface{}) {
+	c.JSON(http.StatusConflict, gin.H{"Error": rule.Message(value, id), "Field": rule.Name(), "Conflict": existing})
+}
+
+// respondUnique sends the 409 response of respondConflict if err is a
+// *repository.UniqueConflictError, and reports whether it did
+func respondUnique(c *gin.Context, err error) bool {
+	var conflictErr *repository.UniqueConflictError
+	if !errors.As(err, &conflictErr) {
+		return false
+	}
+	respondConflict(c, conflictErr.Rule, conflictErr.Value, conflictErr.ID, conflictErr.Existing)
+	return true
+}
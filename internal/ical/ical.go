@@ -0,0 +1,84 @@
+// Package ical writes iCalendar (RFC 5545) feeds that calendar applications can subscribe to.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Event is a calendar entry
+type Event struct {
+	UID         string // Stable identifier, so updated events replace earlier copies
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Modified    time.Time // When the event last changed
+}
+
+const timeFormat = "20060102T150405Z"
+
+// maxLineOctets is the longest content line allowed before folding, excluding the line break
+const maxLineOctets = 75
+
+// Write writes a calendar named name holding the events
+func Write(w io.Writer, name string, events []Event) error {
+	bw := bufio.NewWriter(w)
+	line := func(s string) {
+		bw.WriteString(fold(s))
+		bw.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//Asset Manager//Reservations//EN")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	line("X-WR-CALNAME:" + escape(name))
+	for _, e := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + escape(e.UID))
+		line("DTSTAMP:" + e.Modified.UTC().Format(timeFormat))
+		line("DTSTART:" + e.Start.UTC().Format(timeFormat))
+		line("DTEND:" + e.End.UTC().Format(timeFormat))
+		line("SUMMARY:" + escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION:" + escape(e.Description))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return bw.Flush()
+}
+
+// escape escapes a text value: backslashes, semicolons, commas and line breaks
+func escape(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// fold breaks a content line into lines of at most 75 octets, continuing each with a space and
+// never splitting a UTF-8 sequence
+func fold(s string) string {
+	if len(s) <= maxLineOctets {
+		return s
+	}
+	var b strings.Builder
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with the space, which counts towards their length
+		limit = maxLineOctets - 1
+	}
+	b.WriteString(s)
+	return b.String()
+}
@@ -0,0 +1,66 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	events := []Event{{
+		UID:         "reservation-7@asset-manager",
+		Start:       time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC),
+		End:         time.Date(2026, 3, 2, 17, 0, 0, 0, time.FixedZone("CET", 3600)),
+		Summary:     "Projector; room 4, floor 2",
+		Description: "Bring the HDMI adapter\nand the remote",
+		Modified:    time.Date(2026, 2, 20, 12, 30, 0, 0, time.UTC),
+	}}
+	if err := Write(&buf, "Demo devices", events); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Asset Manager//Reservations//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Demo devices",
+		"BEGIN:VEVENT",
+		"UID:reservation-7@asset-manager",
+		"DTSTAMP:20260220T123000Z",
+		"DTSTART:20260302T090000Z",
+		"DTEND:20260302T160000Z",
+		`SUMMARY:Projector\; room 4\, floor 2`,
+		`DESCRIPTION:Bring the HDMI adapter\nand the remote`,
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if buf.String() != want {
+		t.Errorf("Expected\n%q\ngot\n%q", want, buf.String())
+	}
+}
+
+func TestFold(t *testing.T) {
+	short := "SUMMARY:Laptop"
+	if got := fold(short); got != short {
+		t.Errorf("Expected short lines unchanged, got %q", got)
+	}
+
+	long := "SUMMARY:" + strings.Repeat("ä", 60)
+	folded := fold(long)
+	for i, line := range strings.Split(folded, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line %d has %d octets: %q", i, len(line), line)
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("Expected continuation line %d to start with a space: %q", i, line)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != long {
+		t.Errorf("Expected unfolding to restore the line, got %q", unfolded)
+	}
+}
@@ -0,0 +1,308 @@
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"assetManager/internal/models"
+	"assetManager/internal/validation"
+)
+
+// PropertyColumnPrefix marks columns holding custom property values, e.g. prop_RAM
+const PropertyColumnPrefix = "prop_"
+
+// assetColumns maps normalized column names to the asset field they fill
+var assetColumns = map[string]string{
+	"tag":           "Tag",
+	"assettag":      "Tag",
+	"name":          "Name",
+	"assettype":     "AssetType",
+	"assettypename": "AssetType",
+	"type":          "AssetType",
+	"assettypeid":   "AssetTypeID",
+	"model":         "Model",
+	"serialnumber":  "SerialNumber",
+	"serial":        "SerialNumber",
+	"orderno":       "OrderNo",
+	"ordernumber":   "OrderNo",
+	"licensenumber": "LicenseNumber",
+	"notes":         "Notes",
+	"purchasedat":   "PurchasedAt",
+	"purchasecost":  "PurchaseCost",
+	"cost":          "PurchaseCost",
+	"currency":      "Currency",
+	"assignee":      "Assignee",
+}
+
+// AssetLookup resolves names used in an import file to database records
+type AssetLookup struct {
+	assetTypesByName map[string]int64
+	assetTypesByID   map[int64]bool
+	schemas          map[int64][]models.AssetTypeProperty
+	properties       map[string]models.Property
+	persons          map[string][]int64
+}
+
+// NewAssetLookup indexes asset types, properties and persons for name lookups. schemas holds the
+// properties that apply to each asset type, keyed by asset type ID.
+func NewAssetLookup(assetTypes []models.AssetType, schemas map[int64][]models.AssetTypeProperty, properties []models.Property, persons []models.Person) *AssetLookup {
+	l := &AssetLookup{
+		assetTypesByName: make(map[string]int64),
+		assetTypesByID:   make(map[int64]bool),
+		schemas:          schemas,
+		properties:       make(map[string]models.Property),
+		persons:          make(map[string][]int64),
+	}
+	for _, at := range assetTypes {
+		l.assetTypesByName[normalizeValue(at.Name)] = at.ID
+		l.assetTypesByID[at.ID] = true
+	}
+	for _, p := range properties {
+		l.properties[normalizeValue(p.Name)] = p
+	}
+	for _, p := range persons {
+		l.addPerson(p.Name, p.ID)
+		if p.Email != "" {
+			l.addPerson(p.Email, p.ID)
+		}
+	}
+	return l
+}
+
+func (l *AssetLookup) addPerson(key string, id int64) {
+	key = normalizeValue(key)
+	for _, existing := range l.persons[key] {
+		if existing == id {
+			return
+		}
+	}
+	l.persons[key] = append(l.persons[key], id)
+}
+
+// column describes what a header column maps to
+type column struct {
+	name     string
+	field    string
+	property *models.Property
+}
+
+// MapAssets converts the rows of a table into validated asset import rows. All problems found
+// are returned as import errors; the rows are only usable when no errors are returned.
+func MapAssets(table *Table, lookup *AssetLookup) ([]models.AssetImportRow, []models.ImportError) {
+	var errs []models.ImportError
+
+	columns := make([]column, len(table.Header))
+	seen := make(map[string]bool)
+	for i, name := range table.Header {
+		col := column{name: name}
+		switch {
+		case name == "":
+			// Unnamed columns are ignored
+		case strings.HasPrefix(strings.ToLower(name), PropertyColumnPrefix):
+			propName := name[len(PropertyColumnPrefix):]
+			prop, ok := lookup.properties[normalizeValue(propName)]
+			if !ok {
+				errs = append(errs, models.ImportError{Row: 1, Column: name, Error: fmt.Sprintf("Unknown property %q", propName)})
+				break
+			}
+			col.property = &prop
+		default:
+			field, ok := assetColumns[normalizeColumn(name)]
+			if !ok {
+				errs = append(errs, models.ImportError{Row: 1, Column: name, Error: "Unknown column"})
+				break
+			}
+			col.field = field
+		}
+
+		key := col.field
+		if col.property != nil {
+			key = PropertyColumnPrefix + strconv.FormatInt(col.property.ID, 10)
+		}
+		if key != "" {
+			if seen[key] {
+				errs = append(errs, models.ImportError{Row: 1, Column: name, Error: "Duplicate column"})
+			}
+			seen[key] = true
+		}
+		columns[i] = col
+	}
+
+	if !seen["Name"] {
+		errs = append(errs, models.ImportError{Row: 1, Column: "Name", Error: "Missing required column"})
+	}
+	if !seen["AssetType"] && !seen["AssetTypeID"] {
+		errs = append(errs, models.ImportError{Row: 1, Column: "AssetType", Error: "Missing required column"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if len(table.Rows) == 0 {
+		return nil, []models.ImportError{{Row: 1, Error: "File contains no data rows"}}
+	}
+
+	rows := make([]models.AssetImportRow, 0, len(table.Rows))
+	tagRows := make(map[string]int)
+	for _, r := range table.Rows {
+		row, rowErrs := mapAssetRow(r, columns, lookup)
+		errs = append(errs, rowErrs...)
+		if tag := strings.ToLower(row.Asset.Tag); tag != "" {
+			if first, ok := tagRows[tag]; ok {
+				errs = append(errs, models.ImportError{Row: r.Number, Column: "Tag", Error: fmt.Sprintf("Tag %q is also used in row %d", row.Asset.Tag, first)})
+			} else {
+				tagRows[tag] = r.Number
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return rows, nil
+}
+
+func mapAssetRow(r Row, columns []column, lookup *AssetLookup) (models.AssetImportRow, []models.ImportError) {
+	row := models.AssetImportRow{Row: r.Number}
+	var errs []models.ImportError
+	assetTypeInvalid := false
+	var values []models.AssetProperty
+	propertyColumns := make(map[int64]string)
+	fail := func(col, msg string) {
+		errs = append(errs, models.ImportError{Row: r.Number, Column: col, Error: msg})
+	}
+
+	for i, col := range columns {
+		if i >= len(r.Values) {
+			break
+		}
+		value := strings.TrimSpace(r.Values[i])
+
+		if col.property != nil {
+			propertyColumns[col.property.ID] = col.name
+			if value != "" {
+				values = append(values, models.AssetProperty{
+					PropertyID:   col.property.ID,
+					Value:        value,
+					PropertyName: col.property.Name,
+				})
+			}
+			continue
+		}
+
+		switch col.field {
+		case "Name":
+			row.Asset.Name = value
+		case "AssetType":
+			if value == "" {
+				break
+			}
+			id, ok := lookup.assetTypesByName[normalizeValue(value)]
+			if !ok {
+				fail(col.name, fmt.Sprintf("Unknown asset type %q", value))
+				assetTypeInvalid = true
+				break
+			}
+			row.Asset.AssetTypeID = id
+		case "AssetTypeID":
+			if value == "" {
+				break
+			}
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || !lookup.assetTypesByID[id] {
+				fail(col.name, fmt.Sprintf("Unknown asset type ID %q", value))
+				assetTypeInvalid = true
+				break
+			}
+			row.Asset.AssetTypeID = id
+		case "Tag":
+			row.Asset.Tag = value
+		case "Model":
+			row.Asset.Model = value
+		case "SerialNumber":
+			row.Asset.SerialNumber = value
+		case "OrderNo":
+			row.Asset.OrderNo = value
+		case "LicenseNumber":
+			row.Asset.LicenseNumber = value
+		case "Notes":
+			row.Asset.Notes = value
+		case "PurchasedAt":
+			if value == "" {
+				break
+			}
+			t, err := time.Parse("2006-01-02", value)
+			if err != nil {
+				fail(col.name, "Invalid date, expected YYYY-MM-DD")
+				break
+			}
+			row.Asset.PurchasedAt = models.NewNullTime(t)
+		case "PurchaseCost":
+			if value == "" {
+				break
+			}
+			cost, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				fail(col.name, "Invalid purchase cost")
+				break
+			}
+			row.Asset.PurchaseCost = &cost
+		case "Currency":
+			row.Asset.Currency = value
+		case "Assignee":
+			if value == "" {
+				break
+			}
+			ids := lookup.persons[normalizeValue(value)]
+			switch len(ids) {
+			case 0:
+				fail(col.name, fmt.Sprintf("Unknown person %q", value))
+			case 1:
+				row.AssigneeID = ids[0]
+			default:
+				fail(col.name, fmt.Sprintf("Ambiguous person %q, use the email address", value))
+			}
+		}
+	}
+
+	if row.Asset.Name == "" {
+		fail("Name", "Name is required")
+	}
+	if err := validation.Tag(&row.Asset); err != nil {
+		fail("Tag", err.Error())
+	}
+	if err := validation.PurchaseCost(&row.Asset); err != nil {
+		fail("PurchaseCost", err.Error())
+	}
+	if row.Asset.AssetTypeID == 0 && !assetTypeInvalid {
+		fail("AssetType", "Asset type is required")
+	}
+
+	// Property values can only be checked once the asset type and with it the schema is known
+	if row.Asset.AssetTypeID != 0 {
+		properties, fieldErrs := validation.AssetProperties(lookup.schemas[row.Asset.AssetTypeID], nil, values)
+		for _, fe := range fieldErrs {
+			colName, ok := propertyColumns[fe.FieldID]
+			if !ok {
+				colName = PropertyColumnPrefix + fe.Field
+			}
+			fail(colName, fe.Error)
+		}
+		row.Properties = properties
+	}
+
+	return row, errs
+}
+
+// normalizeColumn makes header matching ignore case, spaces and underscores
+func normalizeColumn(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(name)
+}
+
+// normalizeValue makes name lookups case-insensitive
+func normalizeValue(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
@@ -0,0 +1,210 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"assetManager/internal/models"
+)
+
+func testLookup() *AssetLookup {
+	return NewAssetLookup(
+		[]models.AssetType{
+			{BaseModel: models.BaseModel{ID: 1}, Name: "Laptop"},
+			{BaseModel: models.BaseModel{ID: 2}, Name: "Phone"},
+			{BaseModel: models.BaseModel{ID: 3}, Name: "Monitor"},
+		},
+		map[int64][]models.AssetTypeProperty{
+			1: {{PropertyID: 10, PropertyName: "RAM", DataType: models.DataTypeInt}},
+			3: {
+				{PropertyID: 11, PropertyName: "Size", DataType: models.DataTypeInt, IsRequired: true},
+				{PropertyID: 12, PropertyName: "Colour", DataType: models.DataTypeString, DefaultValue: "Black"},
+			},
+		},
+		[]models.Property{
+			{BaseModel: models.BaseModel{ID: 10}, Name: "RAM", DataType: models.DataTypeInt},
+			{BaseModel: models.BaseModel{ID: 11}, Name: "Size", DataType: models.DataTypeInt},
+			{BaseModel: models.BaseModel{ID: 12}, Name: "Colour", DataType: models.DataTypeString},
+		},
+		[]models.Person{
+			{BaseModel: models.BaseModel{ID: 100}, Name: "Jane Doe", Email: "jane@example.com"},
+			{BaseModel: models.BaseModel{ID: 101}, Name: "John Smith", Email: "john1@example.com"},
+			{BaseModel: models.BaseModel{ID: 102}, Name: "John Smith", Email: "john2@example.com"},
+		},
+	)
+}
+
+func TestMapAssets_Valid(t *testing.T) {
+	csv := "Name,Asset Type,Serial Number,PurchasedAt,prop_RAM,Assignee\n" +
+		"ThinkPad,laptop,SN-1,2024-01-15,16,jane@example.com\n" +
+		"\n" +
+		"Pixel,Phone,SN-2,,,\n"
+
+	table, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	rows, errs := MapAssets(table, testLookup())
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Expected 2 rows, got %d", len(rows))
+	}
+
+	first := rows[0]
+	if first.Asset.Name != "ThinkPad" || first.Asset.AssetTypeID != 1 || first.Asset.SerialNumber != "SN-1" {
+		t.Errorf("Unexpected asset %+v", first.Asset)
+	}
+	if !first.Asset.PurchasedAt.Valid {
+		t.Error("Expected PurchasedAt to be set")
+	}
+	if len(first.Properties) != 1 || first.Properties[0].PropertyID != 10 || first.Properties[0].Value != "16" {
+		t.Errorf("Unexpected properties %+v", first.Properties)
+	}
+	if first.AssigneeID != 100 {
+		t.Errorf("Expected assignee 100, got %d", first.AssigneeID)
+	}
+
+	// Blank lines are skipped but row numbers still match the file
+	if rows[1].Row != 4 {
+		t.Errorf("Expected second data row to be row 4, got %d", rows[1].Row)
+	}
+	if len(rows[1].Properties) != 0 || rows[1].AssigneeID != 0 {
+		t.Errorf("Expected empty cells to be ignored, got %+v", rows[1])
+	}
+}
+
+func TestMapAssets_RowErrors(t *testing.T) {
+	csv := "Name,AssetType,PurchasedAt,Assignee,prop_RAM\n" +
+		",Laptop,,,\n" +
+		"Tablet,Tablet,,,\n" +
+		"Phone,Phone,15/01/2024,John Smith,\n" +
+		"Laptop,Laptop,,,lots\n"
+
+	table, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	rows, errs := MapAssets(table, testLookup())
+	if rows != nil {
+		t.Error("Expected no rows when validation fails")
+	}
+
+	want := map[int]int{2: 1, 3: 1, 4: 2, 5: 1}
+	got := make(map[int]int)
+	for _, e := range errs {
+		got[e.Row]++
+	}
+	for row, count := range want {
+		if got[row] != count {
+			t.Errorf("Expected %d errors on row %d, got %d (%v)", count, row, got[row], errs)
+		}
+	}
+}
+
+func TestMapAssets_DuplicateTags(t *testing.T) {
+	csv := "Asset Tag,Name,AssetType\n" +
+		"LPT-001,ThinkPad,Laptop\n" +
+		",Pixel,Phone\n" +
+		"lpt-001,Latitude,Laptop\n"
+
+	table, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	_, errs := MapAssets(table, testLookup())
+	if len(errs) != 1 || errs[0].Row != 4 || errs[0].Column != "Tag" {
+		t.Fatalf("Expected the repeated tag to be rejected on row 4, got %v", errs)
+	}
+
+	table.Rows = table.Rows[:2]
+	rows, errs := MapAssets(table, testLookup())
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if rows[0].Asset.Tag != "LPT-001" || rows[1].Asset.Tag != "" {
+		t.Errorf("Expected the given tag and none for generation, got %q and %q", rows[0].Asset.Tag, rows[1].Asset.Tag)
+	}
+}
+
+func TestMapAssets_Schema(t *testing.T) {
+	csv := "Name,AssetType,prop_RAM,prop_Size\n" +
+		"Dell,Monitor,,27\n" +
+		"LG,Monitor,,\n" +
+		"Pixel,Phone,4,\n"
+
+	table, err := ReadCSV(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ReadCSV failed: %v", err)
+	}
+
+	_, errs := MapAssets(table, testLookup())
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, got %v", errs)
+	}
+	if errs[0].Row != 3 || errs[0].Column != "prop_Size" {
+		t.Errorf("Expected missing required size on row 3, got %+v", errs[0])
+	}
+	if errs[1].Row != 4 || errs[1].Column != "prop_RAM" {
+		t.Errorf("Expected RAM to be rejected for phones on row 4, got %+v", errs[1])
+	}
+
+	table.Rows = table.Rows[:1]
+	rows, errs := MapAssets(table, testLookup())
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	props := rows[0].Properties
+	if len(props) != 2 || props[0].Value != "27" || props[1].PropertyID != 12 || props[1].Value != "Black" {
+		t.Errorf("Expected size and default colour, got %+v", props)
+	}
+}
+
+func TestMapAssets_HeaderErrors(t *testing.T) {
+	table := &Table{Header: []string{"Name", "Colour", "prop_Unknown"}}
+
+	_, errs := MapAssets(table, testLookup())
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 header errors, got %v", errs)
+	}
+	for _, e := range errs {
+		if e.Row != 1 {
+			t.Errorf("Expected header errors on row 1, got %+v", e)
+		}
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	f.SetSheetRow(sheet, "A1", &[]interface{}{"Name", "AssetType"})
+	f.SetSheetRow(sheet, "A2", &[]interface{}{"ThinkPad", "Laptop"})
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		t.Fatalf("Failed to write workbook: %v", err)
+	}
+
+	table, err := Read(&buf, FormatXLSX)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(table.Rows) != 1 || table.Rows[0].Values[0] != "ThinkPad" {
+		t.Errorf("Unexpected table %+v", table)
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	if f, err := FormatFromFilename("assets.XLSX"); err != nil || f != FormatXLSX {
+		t.Errorf("Expected xlsx, got %q (%v)", f, err)
+	}
+	if _, err := FormatFromFilename("assets.xls"); err != ErrUnsupportedFormat {
+		t.Errorf("Expected unsupported format error, got %v", err)
+	}
+}
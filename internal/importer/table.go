@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format identifies the file format of an import
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported import format, must be csv or xlsx")
+
+// Row is a data row of an import file
+type Row struct {
+	Number int // 1-based row number in the file, the header being row 1
+	Values []string
+}
+
+// Table is the raw content of an import file: a header row followed by data rows
+type Table struct {
+	Header []string
+	Rows   []Row
+}
+
+// FormatFromFilename determines the import format from a file extension
+func FormatFromFilename(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".xlsx":
+		return FormatXLSX, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Read reads a table in the given format
+func Read(r io.Reader, format Format) (*Table, error) {
+	switch format {
+	case FormatCSV:
+		return ReadCSV(r)
+	case FormatXLSX:
+		return ReadXLSX(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// ReadCSV reads a comma separated file with a header row
+func ReadCSV(r io.Reader) (*Table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// The CSV reader skips empty lines, keep track of where each record starts
+	var records [][]string
+	var numbers []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+		records = append(records, record)
+		numbers = append(numbers, line)
+	}
+	return newTable(records, numbers)
+}
+
+// ReadXLSX reads the first sheet of a workbook with a header row
+func ReadXLSX(r io.Reader) (*Table, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open workbook: %w", err)
+	}
+	defer f.Close()
+
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("workbook has no sheets")
+	}
+	records, err := f.GetRows(sheets[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sheet: %w", err)
+	}
+	numbers := make([]int, len(records))
+	for i := range records {
+		numbers[i] = i + 1
+	}
+	return newTable(records, numbers)
+}
+
+// newTable splits records into header and data rows; numbers holds the row number of each record
+func newTable(records [][]string, numbers []int) (*Table, error) {
+	if len(records) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	// Strip a UTF-8 byte order mark written by spreadsheet programs
+	header := records[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	table := &Table{Header: header}
+	for i, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		table.Rows = append(table.Rows, Row{Number: numbers[i+1], Values: record})
+	}
+	return table, nil
+}
+
+func isBlank(record []string) bool {
+	for _, v := range record {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,31 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"assetManager/internal/repository"
+)
+
+// ExpiryAlerts returns a job raising an alert for every warranty, support contract and lease
+// ending within the period, such as "30d", counted from today
+func ExpiryAlerts(repo *repository.AssetContractRepository, within string) Job {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		until, err := repository.AddPeriod(today, within, 1)
+		if err != nil {
+			return err
+		}
+
+		raised, err := repo.RaiseExpiryAlerts(ctx, today, until)
+		if err != nil {
+			return err
+		}
+		if raised > 0 {
+			log.Printf("Raised %d expiry alerts for contracts ending by %s", raised, until.Format("2006-01-02"))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,57 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Job is a unit of background work run by the scheduler
+type Job func(ctx context.Context) error
+
+// TimeOfDay is a wall clock time at which a daily job runs
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+}
+
+// ParseTimeOfDay parses a time of day in HH:MM form
+func ParseTimeOfDay(s string) (TimeOfDay, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TimeOfDay{}, fmt.Errorf("invalid time of day %q, expected HH:MM", s)
+	}
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+// NextRun returns the first time at the given time of day strictly after now
+func NextRun(now time.Time, at TimeOfDay) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour, at.Minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// RunDaily runs a job once right away and then every day at the given time, until the context
+// is cancelled. Errors are logged and do not stop the schedule.
+func RunDaily(ctx context.Context, name string, at TimeOfDay, job Job) {
+	run := func() {
+		if err := job(ctx); err != nil {
+			log.Printf("Job %s failed: %v", name, err)
+		}
+	}
+
+	run()
+	for {
+		timer := time.NewTimer(time.Until(NextRun(time.Now(), at)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			run()
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package jobs
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+func TestParseTimeOfDay(t *testing.T) {
+	at, err := ParseTimeOfDay("06:30")
+	if err != nil || at != (TimeOfDay{Hour: 6, Minute: 30}) {
+		t.Errorf("Expected 06:30, got %+v (%v)", at, err)
+	}
+	for _, bad := range []string{"", "6", "25:00", "06:30:00"} {
+		if _, err := ParseTimeOfDay(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	at := TimeOfDay{Hour: 6}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 3, 10, 5, 59, 0, 0, time.UTC), time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 3, 10, 6, 0, 0, 0, time.UTC), time.Date(2024, 3, 11, 6, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := NextRun(tt.now, at); !got.Equal(tt.want) {
+			t.Errorf("NextRun(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestLoanReminder(t *testing.T) {
+	loan := models.Loan{
+		AssetTag:     "LPT-0042",
+		AssetName:    "Loaner laptop",
+		PersonName:   "Ada Lovelace",
+		PersonEmail:  "ada@example.com",
+		CheckedOutAt: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		DueAt:        time.Date(2024, 3, 8, 17, 0, 0, 0, time.UTC),
+	}
+
+	msg := loanReminder(loan, time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC))
+	if msg.ToAddress != "ada@example.com" || msg.ToName != "Ada Lovelace" {
+		t.Errorf("Expected the borrower as recipient, got %q <%q>", msg.ToName, msg.ToAddress)
+	}
+	if want := "Reminder: Loaner laptop (LPT-0042) is due back March 8"; msg.Subject != want {
+		t.Errorf("Expected subject %q, got %q", want, msg.Subject)
+	}
+
+	msg = loanReminder(loan, time.Date(2024, 3, 10, 18, 0, 0, 0, time.UTC))
+	if want := "Overdue: please return Loaner laptop (LPT-0042)"; msg.Subject != want {
+		t.Errorf("Expected subject %q, got %q", want, msg.Subject)
+	}
+	if !strings.Contains(msg.Body, "was due back on Friday, March 8, 2024 17:00, 2 days ago") {
+		t.Errorf("Expected the body to say how long the loan is overdue, got %q", msg.Body)
+	}
+
+	msg = loanReminder(loan, time.Date(2024, 3, 8, 20, 0, 0, 0, time.UTC))
+	if !strings.Contains(msg.Body, "was due back on Friday, March 8, 2024 17:00. Please") {
+		t.Errorf("Expected no day count within the first day, got %q", msg.Body)
+	}
+}
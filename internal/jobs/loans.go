@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"assetManager/internal/models"
+	"assetManager/internal/notify"
+	"assetManager/internal/repository"
+)
+
+// LoanReminders returns a job reminding the borrowers of loans due within the period, such as
+// "1d", or overdue. Each borrower is reminded at most once a day per loan; a failed reminder is
+// retried on the next run.
+func LoanReminders(repo *repository.AssetAssignmentRepository, notifier notify.Notifier, remindBefore string) Job {
+	return func(ctx context.Context) error {
+		now := time.Now()
+		dueBy, err := repository.AddPeriod(now, remindBefore, 1)
+		if err != nil {
+			return err
+		}
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+		loans, err := repo.GetLoansToRemind(ctx, dueBy, today)
+		if err != nil {
+			return err
+		}
+		failed := 0
+		for _, loan := range loans {
+			if err := notifier.Notify(ctx, loanReminder(loan, now)); err != nil {
+				log.Printf("Failed to remind %s of loan %d: %v", loan.PersonName, loan.AssignmentID, err)
+				failed++
+				continue
+			}
+			if err := repo.MarkReminded(ctx, loan.AssignmentID, now); err != nil {
+				return err
+			}
+		}
+		if sent := len(loans) - failed; sent > 0 {
+			log.Printf("Sent %d loan reminders", sent)
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d loan reminders failed", failed, len(loans))
+		}
+		return nil
+	}
+}
+
+// loanReminder is the message reminding a borrower to bring a loaned asset back
+func loanReminder(loan models.Loan, now time.Time) notify.Message {
+	asset := loan.AssetName
+	if loan.AssetTag != "" {
+		asset += " (" + loan.AssetTag + ")"
+	}
+	due := loan.DueAt.Format("Monday, January 2, 2006 15:04")
+
+	msg := notify.Message{ToName: loan.PersonName, ToAddress: loan.PersonEmail}
+	if loan.Overdue(now) {
+		msg.Subject = "Overdue: please return " + asset
+		msg.Body = fmt.Sprintf("Hello %s,\n\nthe %s you borrowed on %s was due back on %s", loan.PersonName, asset, loan.CheckedOutAt.Format("January 2, 2006"), due)
+		if days := loan.DaysOverdue(now); days > 0 {
+			msg.Body += fmt.Sprintf(", %d %s ago", days, plural(days, "day", "days"))
+		}
+		msg.Body += ". Please bring it back to the loan desk as soon as possible.\n"
+	} else {
+		msg.Subject = "Reminder: " + asset + " is due back " + loan.DueAt.Format("January 2")
+		msg.Body = fmt.Sprintf("Hello %s,\n\nthe %s you borrowed on %s is due back on %s. Please bring it back to the loan desk by then.\n", loan.PersonName, asset, loan.CheckedOutAt.Format("January 2, 2006"), due)
+	}
+	return msg
+}
+
+// plural returns one for a count of 1 and other otherwise
+func plural(n int, one, other string) string {
+	if n == 1 {
+		return one
+	}
+	return other
+}
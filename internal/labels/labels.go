@@ -0,0 +1,215 @@
+// Package labels renders asset labels with a QR code or Code128 barcode, as PDF sheets for
+// common label paper and as ZPL for thermal printers
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/qr"
+
+	"assetManager/internal/models"
+)
+
+var (
+	ErrUnknownLayout      = errors.New("unknown label layout")
+	ErrUnknownPlaceholder = errors.New("unknown label placeholder")
+	ErrUnencodable        = errors.New("label content cannot be encoded")
+)
+
+// maxLines is the number of template lines printed below the asset tag
+const maxLines = 3
+
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// placeholders maps the placeholders of a label text to the asset field they print
+var placeholders = map[string]func(asset *models.Asset) string{
+	"id":      func(a *models.Asset) string { return strconv.FormatInt(a.ID, 10) },
+	"tag":     Tag,
+	"name":    func(a *models.Asset) string { return a.Name },
+	"model":   func(a *models.Asset) string { return a.Model },
+	"serial":  func(a *models.Asset) string { return a.SerialNumber },
+	"order":   func(a *models.Asset) string { return a.OrderNo },
+	"license": func(a *models.Asset) string { return a.LicenseNumber },
+	"type":    func(a *models.Asset) string { return a.AssetTypeName },
+}
+
+// Label is the content of a single rendered label
+type Label struct {
+	Tag   string           // Printed in bold above the lines
+	Lines []string         // Expanded template lines, without empty ones
+	Code  models.LabelCode // Symbology of the barcode
+	Data  string           // Content encoded in the barcode
+}
+
+// Build expands the label template of an asset type for an asset. lookupURL is the URL the
+// barcode encodes when the type asks for one, with {id} replaced by the asset ID.
+func Build(asset *models.Asset, assetType *models.AssetType, lookupURL string) Label {
+	label := Label{
+		Tag:  Tag(asset),
+		Code: assetType.LabelCode,
+		Data: strconv.FormatInt(asset.ID, 10),
+	}
+	if !label.Code.IsValid() {
+		label.Code = models.LabelCodeQR
+	}
+	switch {
+	case assetType.LabelContent == models.LabelContentTag:
+		label.Data = label.Tag
+	case assetType.LabelContent != models.LabelContentID && lookupURL != "":
+		label.Data = strings.ReplaceAll(lookupURL, "{id}", label.Data)
+	}
+
+	for _, line := range strings.Split(assetType.LabelText, "\n") {
+		line = placeholderPattern.ReplaceAllStringFunc(line, func(match string) string {
+			if field, ok := placeholders[match[1:len(match)-1]]; ok {
+				return field(asset)
+			}
+			return match
+		})
+		if line = strings.TrimSpace(line); line != "" && len(label.Lines) < maxLines {
+			label.Lines = append(label.Lines, line)
+		}
+	}
+	return label
+}
+
+// Tag returns the asset tag printed on a label, or the padded asset ID for assets created
+// before tags were generated
+func Tag(asset *models.Asset) string {
+	if asset.Tag != "" {
+		return asset.Tag
+	}
+	return fmt.Sprintf("#%06d", asset.ID)
+}
+
+// CheckText checks that a label template only uses known placeholders
+func CheckText(text string) error {
+	for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		if _, ok := placeholders[match[1]]; !ok {
+			return fmt.Errorf("%w: {%s}", ErrUnknownPlaceholder, match[1])
+		}
+	}
+	return nil
+}
+
+// encode renders the barcode of a label as a grid of modules
+func encode(label Label) (barcode.Barcode, error) {
+	var code barcode.Barcode
+	var err error
+	if label.Code == models.LabelCodeCode128 {
+		code, err = code128.Encode(label.Data)
+	} else {
+		code, err = qr.Encode(label.Data, qr.M, qr.Auto)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q: %v", ErrUnencodable, label.Data, err)
+	}
+	return code, nil
+}
+
+// isDark reports whether the module of a barcode at x, y is printed
+func isDark(code barcode.Barcode, x, y int) bool {
+	bounds := code.Bounds()
+	r, _, _, _ := code.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+	return r < 0x8000
+}
+
+// Layout describes a sheet of labels, or a single label per page for label printers.
+// Sizes are in millimetres.
+type Layout struct {
+	Name        string  `json:"Name"`
+	Description string  `json:"Description"`
+	PageWidth   float64 `json:"PageWidth"`
+	PageHeight  float64 `json:"PageHeight"`
+	Columns     int     `json:"Columns"`
+	Rows        int     `json:"Rows"`
+	LabelWidth  float64 `json:"LabelWidth"`
+	LabelHeight float64 `json:"LabelHeight"`
+	MarginTop   float64 `json:"MarginTop"`
+	MarginLeft  float64 `json:"MarginLeft"`
+	GapX        float64 `json:"GapX"` // Horizontal space between labels
+	GapY        float64 `json:"GapY"` // Vertical space between labels
+}
+
+// PerPage returns the number of labels on a page
+func (l Layout) PerPage() int {
+	return l.Columns * l.Rows
+}
+
+// position returns the top left corner of the label at index i of a page, filled row by row
+func (l Layout) position(i int) (float64, float64) {
+	col, row := i%l.Columns, i/l.Columns
+	return l.MarginLeft + float64(col)*(l.LabelWidth+l.GapX), l.MarginTop + float64(row)*(l.LabelHeight+l.GapY)
+}
+
+// DefaultLayout is used when no layout is requested
+const DefaultLayout = "avery-l7160"
+
+// Layouts lists the supported label layouts
+var Layouts = []Layout{
+	{Name: "avery-l7160", Description: "Avery L7160, A4, 21 labels of 63.5 x 38.1 mm", PageWidth: 210, PageHeight: 297,
+		Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 7.25, GapX: 2.5},
+	{Name: "avery-l7163", Description: "Avery L7163, A4, 14 labels of 99.1 x 38.1 mm", PageWidth: 210, PageHeight: 297,
+		Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 4.65, GapX: 2.5},
+	{Name: "avery-l7651", Description: "Avery L7651, A4, 65 labels of 38.1 x 21.2 mm", PageWidth: 210, PageHeight: 297,
+		Columns: 5, Rows: 13, LabelWidth: 38.1, LabelHeight: 21.2, MarginTop: 10.7, MarginLeft: 4.75, GapX: 2.5},
+	{Name: "avery-5160", Description: "Avery 5160, Letter, 30 labels of 2.63 x 1 in", PageWidth: 215.9, PageHeight: 279.4,
+		Columns: 3, Rows: 10, LabelWidth: 66.675, LabelHeight: 25.4, MarginTop: 12.7, MarginLeft: 4.7625, GapX: 3.175},
+	{Name: "avery-5163", Description: "Avery 5163, Letter, 10 labels of 4 x 2 in", PageWidth: 215.9, PageHeight: 279.4,
+		Columns: 2, Rows: 5, LabelWidth: 101.6, LabelHeight: 50.8, MarginTop: 12.7, MarginLeft: 3.96875, GapX: 4.7625},
+	{Name: "roll-57x32", Description: "Label roll, one 57 x 32 mm label per page", PageWidth: 57, PageHeight: 32,
+		Columns: 1, Rows: 1, LabelWidth: 57, LabelHeight: 32},
+	{Name: "roll-102x51", Description: "Label roll, one 102 x 51 mm label per page", PageWidth: 102, PageHeight: 51,
+		Columns: 1, Rows: 1, LabelWidth: 102, LabelHeight: 51},
+}
+
+// FindLayout returns the layout with the given name, or the default layout if name is empty
+func FindLayout(name string) (Layout, error) {
+	if name == "" {
+		name = DefaultLayout
+	}
+	for _, layout := range Layouts {
+		if layout.Name == name {
+			return layout, nil
+		}
+	}
+	return Layout{}, ErrUnknownLayout
+}
+
+// box is the area of a label element, in millimetres relative to the label's top left corner
+type box struct {
+	x, y, w, h float64
+}
+
+// arrangement places the elements of a label: a QR code sits on the left with the text to its
+// right, a Code128 barcode spans the bottom below the text
+type arrangement struct {
+	code       box
+	text       box
+	lineHeight float64
+}
+
+// arrange computes the arrangement of a label of the given size
+func arrange(code models.LabelCode, width, height float64) arrangement {
+	padding := min(2.5, height*0.08)
+	innerW, innerH := width-2*padding, height-2*padding
+
+	var a arrangement
+	if code == models.LabelCodeCode128 {
+		barHeight := innerH * 0.45
+		a.code = box{padding, padding + innerH - barHeight, innerW, barHeight}
+		a.text = box{padding, padding, innerW, innerH - barHeight - 1}
+	} else {
+		size := min(innerH, innerW/2)
+		a.code = box{padding, padding, size, size}
+		a.text = box{padding + size + 2, padding, innerW - size - 2, innerH}
+	}
+	a.lineHeight = min(5, a.text.h/(maxLines+1))
+	return a
+}
@@ -0,0 +1,138 @@
+package labels
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func testAsset() *models.Asset {
+	asset := &models.Asset{Tag: "LPT-2025-00042", Name: "ThinkPad T14", Model: "T14 Gen 4", SerialNumber: "PF4X2Z", AssetTypeName: "Laptop"}
+	asset.ID = 42
+	return asset
+}
+
+func TestBuild(t *testing.T) {
+	assetType := &models.AssetType{
+		LabelCode:    models.LabelCodeQR,
+		LabelContent: models.LabelContentURL,
+		LabelText:    "{name}\n\n{type} {model}\nS/N {serial}\n{unknown}\n{order}",
+	}
+	label := Build(testAsset(), assetType, "https://assets.example.com/#/assets/{id}")
+
+	if label.Tag != "LPT-2025-00042" {
+		t.Errorf("Expected tag LPT-2025-00042, got %q", label.Tag)
+	}
+	if label.Data != "https://assets.example.com/#/assets/42" {
+		t.Errorf("Expected the lookup URL, got %q", label.Data)
+	}
+	if untagged := Build(&models.Asset{BaseModel: models.BaseModel{ID: 7}}, assetType, ""); untagged.Tag != "#000007" {
+		t.Errorf("Expected the padded ID for untagged assets, got %q", untagged.Tag)
+	}
+	want := []string{"ThinkPad T14", "Laptop T14 Gen 4", "S/N PF4X2Z"}
+	if strings.Join(label.Lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected lines %q, got %q", want, label.Lines)
+	}
+
+	assetType.LabelContent = models.LabelContentID
+	if label := Build(testAsset(), assetType, "https://assets.example.com/#/assets/{id}"); label.Data != "42" {
+		t.Errorf("Expected the asset ID, got %q", label.Data)
+	}
+	assetType.LabelContent = models.LabelContentTag
+	if label := Build(testAsset(), assetType, "https://assets.example.com/#/assets/{id}"); label.Data != "LPT-2025-00042" {
+		t.Errorf("Expected the asset tag, got %q", label.Data)
+	}
+	assetType.LabelContent = models.LabelContentURL
+	if label := Build(testAsset(), assetType, ""); label.Data != "42" {
+		t.Errorf("Expected the asset ID without a lookup URL, got %q", label.Data)
+	}
+}
+
+func TestCheckText(t *testing.T) {
+	if err := CheckText("{name} ({serial})\n{type}"); err != nil {
+		t.Errorf("Expected known placeholders to pass, got %v", err)
+	}
+	if err := CheckText("{name} {colour}"); !errors.Is(err, ErrUnknownPlaceholder) {
+		t.Errorf("Expected ErrUnknownPlaceholder, got %v", err)
+	}
+}
+
+func TestFindLayout(t *testing.T) {
+	layout, err := FindLayout("")
+	if err != nil || layout.Name != DefaultLayout {
+		t.Errorf("Expected the default layout, got %q, %v", layout.Name, err)
+	}
+	if _, err := FindLayout("avery-9999"); err != ErrUnknownLayout {
+		t.Errorf("Expected ErrUnknownLayout, got %v", err)
+	}
+
+	// Every label of a sheet has to fit on the page
+	for _, layout := range Layouts {
+		x, y := layout.position(layout.PerPage() - 1)
+		if x+layout.LabelWidth > layout.PageWidth+0.01 || y+layout.LabelHeight > layout.PageHeight+0.01 {
+			t.Errorf("Layout %s: last label ends at %.2f, %.2f outside the page", layout.Name, x+layout.LabelWidth, y+layout.LabelHeight)
+		}
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	layout, _ := FindLayout("avery-l7163")
+	assetType := &models.AssetType{LabelCode: models.LabelCodeCode128, LabelContent: models.LabelContentID, LabelText: "{name}"}
+	var labels []Label
+	for i := 0; i < 20; i++ {
+		labels = append(labels, Build(testAsset(), assetType, ""))
+	}
+
+	var buf bytes.Buffer
+	if err := WritePDF(&buf, labels, layout, 10); err != nil {
+		t.Fatalf("WritePDF failed: %v", err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF")) {
+		t.Fatal("Expected a PDF document")
+	}
+	// 4 labels fill the first sheet after skipping 10 positions, the other 16 need two more
+	if pages := bytes.Count(buf.Bytes(), []byte("/Type /Page\n")); pages != 3 {
+		t.Errorf("Expected 3 pages, got %d", pages)
+	}
+}
+
+func TestWriteZPL(t *testing.T) {
+	layout, _ := FindLayout("roll-57x32")
+	asset := testAsset()
+	asset.Name = "Dock ^XZ_2"
+	qrLabel := Build(asset, &models.AssetType{LabelCode: models.LabelCodeQR, LabelText: "{name}"}, "https://assets.example.com/#/assets/{id}")
+	barLabel := Build(asset, &models.AssetType{LabelCode: models.LabelCodeCode128, LabelContent: models.LabelContentID, LabelText: "{serial}"}, "")
+
+	var buf bytes.Buffer
+	if err := WriteZPL(&buf, []Label{qrLabel, barLabel}, layout); err != nil {
+		t.Fatalf("WriteZPL failed: %v", err)
+	}
+	out := buf.String()
+
+	if strings.Count(out, "^XA") != 2 || strings.Count(out, "^XZ\n") != 2 {
+		t.Errorf("Expected one format per label, got:\n%s", out)
+	}
+	if !strings.Contains(out, "^PW456\n^LL256\n") {
+		t.Errorf("Expected the label size in dots, got:\n%s", out)
+	}
+	if !strings.Contains(out, "^FDMA,https://assets.example.com/#/assets/42^FS") {
+		t.Errorf("Expected a QR code with the lookup URL, got:\n%s", out)
+	}
+	if !strings.Contains(out, "^BCN,") || !strings.Contains(out, "^FD42^FS") {
+		t.Errorf("Expected a Code128 barcode with the asset ID, got:\n%s", out)
+	}
+	if !strings.Contains(out, "^FDDock _5EXZ_5F2^FS") {
+		t.Errorf("Expected ZPL command characters in text to be escaped, got:\n%s", out)
+	}
+}
+
+func TestUnencodable(t *testing.T) {
+	layout, _ := FindLayout("roll-57x32")
+	label := Label{Tag: "#1", Code: models.LabelCodeCode128, Data: strings.Repeat("x", 81)}
+	if err := WriteZPL(&bytes.Buffer{}, []Label{label}, layout); !errors.Is(err, ErrUnencodable) {
+		t.Errorf("Expected ErrUnencodable, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package labels
+
+import (
+	"io"
+
+	"github.com/boombuler/barcode"
+	"github.com/go-pdf/fpdf"
+)
+
+// mmToPt converts millimetres to font points
+const mmToPt = 72 / 25.4
+
+// WritePDF renders labels onto sheets of the given layout. skip leaves that many positions of
+// the first sheet empty so partly used sheets can be reused.
+func WritePDF(w io.Writer, labels []Label, layout Layout, skip int) error {
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: layout.PageWidth, Ht: layout.PageHeight},
+	})
+	pdf.SetTitle("Asset labels", true)
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetFillColor(0, 0, 0)
+	// Core fonts use cp1252, so UTF-8 text has to be translated
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	perPage := layout.PerPage()
+	skip = max(0, skip) % perPage
+	for i, label := range labels {
+		position := (skip + i) % perPage
+		if i == 0 || position == 0 {
+			pdf.AddPage()
+		}
+		code, err := encode(label)
+		if err != nil {
+			return err
+		}
+		x, y := layout.position(position)
+		drawLabel(pdf, tr, label, code, x, y, layout.LabelWidth, layout.LabelHeight)
+	}
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+// drawLabel draws a single label with its top left corner at x, y
+func drawLabel(pdf *fpdf.Fpdf, tr func(string) string, label Label, code barcode.Barcode, x, y, width, height float64) {
+	a := arrange(label.Code, width, height)
+	drawCode(pdf, code, x+a.code.x, y+a.code.y, a.code.w, a.code.h)
+
+	textX, textY := x+a.text.x, y+a.text.y
+	pdf.SetFont("Helvetica", "B", a.lineHeight*1.2*mmToPt*0.8)
+	pdf.SetXY(textX, textY)
+	pdf.CellFormat(a.text.w, a.lineHeight*1.2, fit(pdf, tr(label.Tag), a.text.w), "", 0, "L", false, 0, "")
+	textY += a.lineHeight * 1.2
+
+	pdf.SetFont("Helvetica", "", a.lineHeight*mmToPt*0.8)
+	for _, line := range label.Lines {
+		pdf.SetXY(textX, textY)
+		pdf.CellFormat(a.text.w, a.lineHeight, fit(pdf, tr(line), a.text.w), "", 0, "L", false, 0, "")
+		textY += a.lineHeight
+	}
+}
+
+// drawCode draws the modules of a barcode as filled rectangles so the code stays sharp at any
+// printer resolution. 2D codes keep square modules and are centered in the area.
+func drawCode(pdf *fpdf.Fpdf, code barcode.Barcode, x, y, width, height float64) {
+	bounds := code.Bounds()
+	cols, rows := bounds.Dx(), bounds.Dy()
+	moduleW, moduleH := width/float64(cols), height/float64(rows)
+	if code.Metadata().Dimensions == 2 {
+		size := min(moduleW, moduleH)
+		x += (width - size*float64(cols)) / 2
+		moduleW, moduleH = size, size
+	}
+
+	for row := 0; row < rows; row++ {
+		// Draw runs of dark modules as one rectangle
+		for col := 0; col < cols; {
+			if !isDark(code, col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < cols && isDark(code, col, row) {
+				col++
+			}
+			pdf.Rect(x+float64(start)*moduleW, y+float64(row)*moduleH, float64(col-start)*moduleW, moduleH, "F")
+		}
+	}
+}
+
+// fit truncates translated (single byte) text so that it fits into the given width
+func fit(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	for len(text) > 0 && pdf.GetStringWidth(text+"...") > width {
+		text = text[:len(text)-1]
+	}
+	return text + "..."
+}
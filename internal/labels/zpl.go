@@ -0,0 +1,62 @@
+package labels
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"assetManager/internal/models"
+)
+
+// zplDotsPerMM is the resolution of 203 dpi thermal printers
+const zplDotsPerMM = 8
+
+// zplEscaper hex-escapes the characters ZPL treats as commands inside ^FH field data
+var zplEscaper = strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E")
+
+// WriteZPL renders labels as ZPL for thermal printers, one format per label sized to the
+// labels of the given layout
+func WriteZPL(w io.Writer, labels []Label, layout Layout) error {
+	bw := bufio.NewWriter(w)
+	for _, label := range labels {
+		code, err := encode(label)
+		if err != nil {
+			return err
+		}
+		a := arrange(label.Code, layout.LabelWidth, layout.LabelHeight)
+
+		fmt.Fprintf(bw, "^XA\n^CI28\n^PW%d\n^LL%d\n", dots(layout.LabelWidth), dots(layout.LabelHeight))
+
+		modules := code.Bounds().Dx()
+		if label.Code == models.LabelCodeCode128 {
+			moduleWidth := min(10, max(1, dots(a.code.w)/modules))
+			// A literal > starts an invocation code in ^BC data, >< prints it
+			data := strings.ReplaceAll(label.Data, ">", "><")
+			fmt.Fprintf(bw, "^FO%d,%d^BY%d^BCN,%d,N,N,N^FH^FD%s^FS\n",
+				dots(a.code.x), dots(a.code.y), moduleWidth, dots(a.code.h), zplEscaper.Replace(data))
+		} else {
+			magnification := min(10, max(1, dots(a.code.w)/modules))
+			fmt.Fprintf(bw, "^FO%d,%d^BQN,2,%d^FH^FDMA,%s^FS\n",
+				dots(a.code.x), dots(a.code.y), magnification, zplEscaper.Replace(label.Data))
+		}
+
+		y := a.text.y
+		fmt.Fprintf(bw, "^FO%d,%d^A0N,%d,%d^FB%d,1,0,L,0^FH^FD%s^FS\n", dots(a.text.x), dots(y),
+			dots(a.lineHeight*1.2), dots(a.lineHeight*1.2), dots(a.text.w), zplEscaper.Replace(label.Tag))
+		y += a.lineHeight * 1.2
+		for _, line := range label.Lines {
+			fmt.Fprintf(bw, "^FO%d,%d^A0N,%d,%d^FB%d,1,0,L,0^FH^FD%s^FS\n", dots(a.text.x), dots(y),
+				dots(a.lineHeight), dots(a.lineHeight*0.8), dots(a.text.w), zplEscaper.Replace(line))
+			y += a.lineHeight
+		}
+
+		fmt.Fprint(bw, "^XZ\n")
+	}
+	return bw.Flush()
+}
+
+// dots converts millimetres to printer dots
+func dots(mm float64) int {
+	return int(mm*zplDotsPerMM + 0.5)
+}
@@ -0,0 +1,60 @@
+// Package lifecycle defines which asset status transitions are allowed.
+package lifecycle
+
+import (
+	"errors"
+	"fmt"
+
+	"assetManager/internal/models"
+)
+
+// ErrTransitionNotAllowed is returned when an asset cannot move from its status to the requested one
+var ErrTransitionNotAllowed = errors.New("status transition not allowed")
+
+// Transitions maps each status to the statuses an asset may move to from it
+type Transitions map[models.AssetStatus][]models.AssetStatus
+
+// DefaultTransitions returns the transitions used when the configuration does not override them
+func DefaultTransitions() Transitions {
+	return Transitions{
+		models.AssetStatusOrdered:    {models.AssetStatusInStock, models.AssetStatusDisposed},
+		models.AssetStatusInStock:    {models.AssetStatusDeployed, models.AssetStatusInRepair, models.AssetStatusLostStolen, models.AssetStatusRetired},
+		models.AssetStatusDeployed:   {models.AssetStatusInStock, models.AssetStatusInRepair, models.AssetStatusLostStolen, models.AssetStatusRetired},
+		models.AssetStatusInRepair:   {models.AssetStatusInStock, models.AssetStatusDeployed, models.AssetStatusRetired},
+		models.AssetStatusLostStolen: {models.AssetStatusInStock, models.AssetStatusRetired, models.AssetStatusDisposed},
+		models.AssetStatusRetired:    {models.AssetStatusInStock, models.AssetStatusDisposed},
+		models.AssetStatusDisposed:   {},
+	}
+}
+
+// New builds the transitions from configuration. Every status listed in the configuration replaces
+// the default transitions of that status; statuses not listed keep their defaults.
+func New(config map[string][]string) (Transitions, error) {
+	t := DefaultTransitions()
+	for from, targets := range config {
+		fromStatus := models.AssetStatus(from)
+		if !fromStatus.IsValid() {
+			return nil, fmt.Errorf("unknown asset status %q", from)
+		}
+		allowed := make([]models.AssetStatus, 0, len(targets))
+		for _, to := range targets {
+			toStatus := models.AssetStatus(to)
+			if !toStatus.IsValid() {
+				return nil, fmt.Errorf("unknown asset status %q in transitions of %q", to, from)
+			}
+			allowed = append(allowed, toStatus)
+		}
+		t[fromStatus] = allowed
+	}
+	return t, nil
+}
+
+// Check returns ErrTransitionNotAllowed unless an asset may move from one status to the other
+func (t Transitions) Check(from, to models.AssetStatus) error {
+	for _, allowed := range t[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s to %s", ErrTransitionNotAllowed, from, to)
+}
@@ -0,0 +1,44 @@
+package lifecycle
+
+import (
+	"errors"
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func TestDefaultTransitions(t *testing.T) {
+	transitions := DefaultTransitions()
+
+	if err := transitions.Check(models.AssetStatusInStock, models.AssetStatusDeployed); err != nil {
+		t.Errorf("Expected in_stock -> deployed to be allowed, got %v", err)
+	}
+	if err := transitions.Check(models.AssetStatusDisposed, models.AssetStatusInStock); !errors.Is(err, ErrTransitionNotAllowed) {
+		t.Errorf("Expected disposed assets to stay disposed, got %v", err)
+	}
+	for _, status := range models.AssetStatuses {
+		if _, ok := transitions[status]; !ok {
+			t.Errorf("Expected transitions for status %q", status)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	transitions, err := New(map[string][]string{"retired": {"disposed"}})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if err := transitions.Check(models.AssetStatusRetired, models.AssetStatusInStock); err == nil {
+		t.Error("Expected configured transitions to replace the defaults of a status")
+	}
+	if err := transitions.Check(models.AssetStatusInStock, models.AssetStatusDeployed); err != nil {
+		t.Errorf("Expected unconfigured statuses to keep their defaults, got %v", err)
+	}
+
+	if _, err := New(map[string][]string{"broken": {"in_stock"}}); err == nil {
+		t.Error("Expected an unknown source status to fail")
+	}
+	if _, err := New(map[string][]string{"in_stock": {"gone"}}); err == nil {
+		t.Error("Expected an unknown target status to fail")
+	}
+}
@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"assetManager/internal/auth"
+	"assetManager/internal/models"
+	"assetManager/internal/repository"
 )
 
 const (
@@ -14,10 +17,13 @@ const (
 	BearerPrefix        = "Bearer "
 	UserIDKey           = "userID"
 	UsernameKey         = "username"
+	RoleKey             = "role"
 )
 
-// AuthMiddleware creates a JWT authentication middleware
-func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
+// AuthMiddleware creates a JWT authentication middleware. The user of the token is loaded on every
+// request, so role changes take effect at once and disabled or deleted users are turned away
+// even while their tokens have not expired.
+func AuthMiddleware(jwtService *auth.JWTService, userRepo *repository.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader(AuthorizationHeader)
 		if authHeader == "" {
@@ -40,9 +46,22 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		user, err := userRepo.GetByID(context.Background(), claims.UserID)
+		if err == repository.ErrUserNotFound || (err == nil && !user.IsActive) {
+			c.JSON(http.StatusUnauthorized, gin.H{"Error": "User account is disabled"})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"Error": "Failed to load user"})
+			c.Abort()
+			return
+		}
+
 		// Set user info in context
-		c.Set(UserIDKey, claims.UserID)
-		c.Set(UsernameKey, claims.Username)
+		c.Set(UserIDKey, user.ID)
+		c.Set(UsernameKey, user.Username)
+		c.Set(RoleKey, user.Role)
 
 		c.Next()
 	}
@@ -65,3 +84,12 @@ func GetUsername(c *gin.Context) string {
 	}
 	return username.(string)
 }
+
+// GetRole retrieves the user role from the context
+func GetRole(c *gin.Context) models.Role {
+	role, exists := c.Get(RoleKey)
+	if !exists {
+		return ""
+	}
+	return role.(models.Role)
+}
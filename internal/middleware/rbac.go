@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"assetManager/internal/auth"
+)
+
+// RequirePermission creates a middleware that only lets through users whose current role grants the
+// permission. It must run after AuthMiddleware, which loads the role.
+func RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.HasPermission(GetRole(c), permission) {
+			c.JSON(http.StatusForbidden, gin.H{"Error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
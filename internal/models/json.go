@@ -3,6 +3,7 @@ package models
 import (
 	"database/sql"
 	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -76,3 +77,32 @@ func NewNullTime(t time.Time) NullTime {
 func NewNullString(s string) NullString {
 	return NullString{sql.NullString{String: s, Valid: s != ""}}
 }
+
+// Scan implements sql.Scanner for JSONData
+func (j *JSONData) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSONData(v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSONData", value)
+	}
+	return nil
+}
+
+// MarshalJSON for JSONData
+func (j JSONData) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// UnmarshalJSON for JSONData
+func (j *JSONData) UnmarshalJSON(data []byte) error {
+	*j = append((*j)[:0], data...)
+	return nil
+}
@@ -15,6 +15,9 @@ type NullString struct {
 	sql.NullString
 }
 
+// JSONData holds raw JSON stored in a JSON column
+type JSONData []byte
+
 // BaseModel contains common fields for all models
 type BaseModel struct {
 	ID        int64     `db:"id" json:"ID"`
@@ -23,33 +26,162 @@ type BaseModel struct {
 	DeletedAt NullTime  `db:"deleted_at" json:"DeletedAt,omitempty"`
 }
 
+// Role represents the access level of an application user
+type Role string
+
+const (
+	RoleAdmin   Role = "admin"
+	RoleEditor  Role = "editor"
+	RoleAuditor Role = "auditor"
+)
+
+// IsValid reports whether the role is one of the known roles
+func (r Role) IsValid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleAuditor:
+		return true
+	}
+	return false
+}
+
 // User represents an application user for authentication
 type User struct {
 	BaseModel
 	Username     string `db:"username" json:"Username"`
 	Email        string `db:"email" json:"Email"`
 	PasswordHash string `db:"password_hash" json:"-"`
+	Role         Role   `db:"role" json:"Role"`
 	IsActive     bool   `db:"is_active" json:"IsActive"`
 }
 
 // AssetType represents a category of assets
 type AssetType struct {
 	BaseModel
-	Name        string `db:"name" json:"Name"`
-	Description string `db:"description" json:"Description"`
+	Name               string             `db:"name" json:"Name"`
+	Description        string             `db:"description" json:"Description"`
+	TagPattern         string             `db:"tag_pattern" json:"TagPattern"` // Such as LPT-{YYYY}-{seq:5}, empty for the default pattern
+	DepreciationMethod DepreciationMethod `db:"depreciation_method" json:"DepreciationMethod"`
+	UsefulLifeMonths   int                `db:"useful_life_months" json:"UsefulLifeMonths"` // 0 if assets of the type are not depreciated
+	LabelCode          LabelCode          `db:"label_code" json:"LabelCode"`
+	LabelContent       LabelContent       `db:"label_content" json:"LabelContent"`
+	LabelText          string             `db:"label_text" json:"LabelText"` // Lines printed below the asset tag, with placeholders such as {name}
+}
+
+// DepreciationMethod defines how the value of an asset decreases over its useful life
+type DepreciationMethod string
+
+const (
+	DepreciationStraightLine     DepreciationMethod = "straight_line"
+	DepreciationDecliningBalance DepreciationMethod = "declining_balance"
+)
+
+// IsValid reports whether the method is a known depreciation method
+func (m DepreciationMethod) IsValid() bool {
+	return m == DepreciationStraightLine || m == DepreciationDecliningBalance
+}
+
+// LabelCode is the barcode symbology printed on asset labels
+type LabelCode string
+
+const (
+	LabelCodeQR      LabelCode = "qr"
+	LabelCodeCode128 LabelCode = "code128"
+)
+
+// IsValid reports whether the code is a known symbology
+func (c LabelCode) IsValid() bool {
+	return c == LabelCodeQR || c == LabelCodeCode128
+}
+
+// LabelContent is what the barcode on an asset label encodes
+type LabelContent string
+
+const (
+	LabelContentURL LabelContent = "url" // The lookup URL of the asset in the web interface
+	LabelContentID  LabelContent = "id"  // The asset ID
+	LabelContentTag LabelContent = "tag" // The asset tag
+)
+
+// IsValid reports whether the content is a known label content
+func (c LabelContent) IsValid() bool {
+	return c == LabelContentURL || c == LabelContentID || c == LabelContentTag
+}
+
+// AssetTypeProperty defines that a property applies to an asset type
+type AssetTypeProperty struct {
+	ID           int64  `db:"id" json:"ID"`
+	AssetTypeID  int64  `db:"asset_type_id" json:"AssetTypeID"`
+	PropertyID   int64  `db:"property_id" json:"PropertyID"`
+	IsRequired   bool   `db:"is_required" json:"IsRequired"`
+	DisplayOrder int    `db:"display_order" json:"DisplayOrder"`
+	DefaultValue string `db:"default_value" json:"DefaultValue"`
+
+	// Joined fields from properties
+	PropertyName string   `db:"property_name" json:"PropertyName,omitempty"`
+	DataType     DataType `db:"data_type" json:"DataType,omitempty"`
+	EnumOptions  string   `db:"enum_options" json:"EnumOptions,omitempty"`
+}
+
+// AssetTypeSchema is an asset type with the properties that apply to it, in display order
+type AssetTypeSchema struct {
+	AssetType
+	Properties []AssetTypeProperty `json:"Properties"`
+}
+
+// AssetStatus is the lifecycle state of an asset
+type AssetStatus string
+
+const (
+	AssetStatusOrdered    AssetStatus = "ordered"
+	AssetStatusInStock    AssetStatus = "in_stock"
+	AssetStatusDeployed   AssetStatus = "deployed"
+	AssetStatusInRepair   AssetStatus = "in_repair"
+	AssetStatusLostStolen AssetStatus = "lost_stolen"
+	AssetStatusRetired    AssetStatus = "retired"
+	AssetStatusDisposed   AssetStatus = "disposed"
+)
+
+// AssetStatuses lists all asset statuses in lifecycle order
+var AssetStatuses = []AssetStatus{
+	AssetStatusOrdered,
+	AssetStatusInStock,
+	AssetStatusDeployed,
+	AssetStatusInRepair,
+	AssetStatusLostStolen,
+	AssetStatusRetired,
+	AssetStatusDisposed,
+}
+
+// IsValid reports whether the status is one of the known statuses
+func (s AssetStatus) IsValid() bool {
+	for _, status := range AssetStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAssignable reports whether assets in this status may be assigned to a person
+func (s AssetStatus) IsAssignable() bool {
+	return s != AssetStatusRetired && s != AssetStatusDisposed
 }
 
 // Asset represents a tracked asset
 type Asset struct {
 	BaseModel
-	AssetTypeID   int64    `db:"asset_type_id" json:"AssetTypeID"`
-	Name          string   `db:"name" json:"Name"`
-	Model         string   `db:"model" json:"Model"`
-	SerialNumber  string   `db:"serial_number" json:"SerialNumber"`
-	OrderNo       string   `db:"order_no" json:"OrderNo"`
-	LicenseNumber string   `db:"license_number" json:"LicenseNumber"`
-	Notes         string   `db:"notes" json:"Notes"`
-	PurchasedAt   NullTime `db:"purchased_at" json:"PurchasedAt,omitempty"`
+	AssetTypeID   int64       `db:"asset_type_id" json:"AssetTypeID"`
+	Tag           string      `db:"tag" json:"Tag"` // Generated from the asset type's tag pattern unless given
+	Status        AssetStatus `db:"status" json:"Status"`
+	Name          string      `db:"name" json:"Name"`
+	Model         string      `db:"model" json:"Model"`
+	SerialNumber  string      `db:"serial_number" json:"SerialNumber"`
+	OrderNo       string      `db:"order_no" json:"OrderNo"`
+	LicenseNumber string      `db:"license_number" json:"LicenseNumber"`
+	Notes         string      `db:"notes" json:"Notes"`
+	PurchasedAt   NullTime    `db:"purchased_at" json:"PurchasedAt,omitempty"`
+	PurchaseCost  *float64    `db:"purchase_cost" json:"PurchaseCost,omitempty"`
+	Currency      string      `db:"currency" json:"Currency"`
 
 	// Joined fields (not stored in assets table)
 	AssetTypeName string `db:"asset_type_name" json:"AssetTypeName,omitempty"`
@@ -76,6 +208,18 @@ type Property struct {
 	EnumOptions string   `db:"enum_options" json:"EnumOptions,omitempty"` // JSON array for enum options
 }
 
+// AssetStatusChange records a lifecycle transition of an asset
+type AssetStatusChange struct {
+	ID         int64       `db:"id" json:"ID"`
+	AssetID    int64       `db:"asset_id" json:"AssetID"`
+	FromStatus AssetStatus `db:"from_status" json:"FromStatus"`
+	ToStatus   AssetStatus `db:"to_status" json:"ToStatus"`
+	Reason     string      `db:"reason" json:"Reason"`
+	UserID     *int64      `db:"user_id" json:"UserID,omitempty"`
+	Username   string      `db:"username" json:"Username"`
+	ChangedAt  time.Time   `db:"changed_at" json:"ChangedAt"`
+}
+
 // AssetProperty links an asset to a property value
 type AssetProperty struct {
 	BaseModel
@@ -91,9 +235,55 @@ type AssetProperty struct {
 // Person represents a person who can be assigned assets
 type Person struct {
 	BaseModel
-	Name  string `db:"name" json:"Name"`
-	Email string `db:"email" json:"Email"`
-	Phone string `db:"phone" json:"Phone"`
+	Name    string `db:"name" json:"Name"`
+	Email   string `db:"email" json:"Email"`
+	Phone   string `db:"phone" json:"Phone"`
+	IsStock bool   `db:"is_stock" json:"IsStock"` // Stock pool holding assets that are not assigned to anyone
+
+	IsActive bool     `db:"is_active" json:"IsActive"` // False once the person left and was offboarded
+	LeftAt   NullTime `db:"left_at" json:"LeftAt,omitempty"`
+
+	MergedIntoID *int64 `db:"merged_into_id" json:"MergedIntoID,omitempty"` // Surviving person of a merge, set on deleted persons only
+}
+
+// MergeKeep selects the surviving value of an attribute both persons of a merge have set
+type MergeKeep string
+
+const (
+	MergeKeepTarget MergeKeep = "target"
+	MergeKeepSource MergeKeep = "source"
+)
+
+// IsValid reports whether k is a known merge rule
+func (k MergeKeep) IsValid() bool {
+	return k == MergeKeepTarget || k == MergeKeepSource
+}
+
+// PersonMergeRequest is the body of a person merge. Attributes holds the rule for an attribute ID
+// and Keep the rule for all other attributes both persons have set to different values; such a
+// conflict without a rule fails the merge.
+type PersonMergeRequest struct {
+	SourceID   int64               `json:"SourceID"`
+	Keep       MergeKeep           `json:"Keep"`
+	Attributes map[int64]MergeKeep `json:"Attributes"`
+}
+
+// AttributeConflict is an attribute both persons of a merge have set to different values
+type AttributeConflict struct {
+	AttributeID   int64  `json:"AttributeID"`
+	AttributeName string `json:"AttributeName"`
+	SourceValue   string `json:"SourceValue"`
+	TargetValue   string `json:"TargetValue"`
+}
+
+// PersonMerge lists the records a person merge changed, each as it was before the merge
+type PersonMerge struct {
+	Source            Person            `json:"Source"`
+	Assignments       []AssetAssignment `json:"Assignments"`       // Moved to the target
+	MovedAttributes   []PersonAttribute `json:"MovedAttributes"`   // Moved to the target
+	DroppedAttributes []PersonAttribute `json:"DroppedAttributes"` // Equal or losing values of either person
+	Attachments       []int64           `json:"Attachments"`       // IDs of attachments moved to the target
+	Reservations      []Reservation     `json:"Reservations"`      // Moved to the target
 }
 
 // Attribute defines a custom attribute that can be attached to persons
@@ -123,23 +313,413 @@ type AssetAssignment struct {
 	PersonID      int64    `db:"person_id" json:"PersonID"`
 	EffectiveFrom NullTime `db:"effective_from" json:"EffectiveFrom"`
 	EffectiveTo   NullTime `db:"effective_to" json:"EffectiveTo,omitempty"`
+	DueAt         NullTime `db:"due_at" json:"DueAt,omitempty"` // Set for loans checked out at the loan desk
 	Notes         string   `db:"notes" json:"Notes"`
 
+	ReturnCondition ReturnCondition `db:"return_condition" json:"ReturnCondition,omitempty"` // Set when the asset was handed back on offboarding or check-in
+	ReturnNotes     string          `db:"return_notes" json:"ReturnNotes,omitempty"`
+
 	// Joined fields
 	AssetName         string `db:"asset_name" json:"AssetName,omitempty"`
 	PersonName        string `db:"person_name" json:"PersonName,omitempty"`
+	PersonIsStock     bool   `db:"person_is_stock" json:"PersonIsStock,omitempty"`
 	AssetTypeName     string `db:"asset_type_name" json:"AssetTypeName,omitempty"`
 	AssetModel        string `db:"asset_model" json:"AssetModel,omitempty"`
 	AssetSerialNumber string `db:"asset_serial_number" json:"AssetSerialNumber,omitempty"`
 }
 
+// CustodyPeriod is a stretch of the custody timeline of an asset: an assignment, or a gap in which
+// no person or stock pool was recorded to hold the asset
+type CustodyPeriod struct {
+	From          time.Time `json:"From"`
+	To            NullTime  `json:"To"` // Null while the period lasts
+	Gap           bool      `json:"Gap"`
+	AssignmentID  int64     `json:"AssignmentID,omitempty"`
+	PersonID      int64     `json:"PersonID,omitempty"`
+	PersonName    string    `json:"PersonName,omitempty"`
+	PersonIsStock bool      `json:"PersonIsStock,omitempty"`
+	Notes         string    `json:"Notes,omitempty"`
+}
+
+// CustodyTimeline is the custody history of an asset from its creation, oldest period first
+type CustodyTimeline struct {
+	AssetID int64           `json:"AssetID"`
+	Periods []CustodyPeriod `json:"Periods"`
+	Gaps    int             `json:"Gaps"`
+}
+
+// Loan is an asset checked out at the loan desk and not yet checked in
+type Loan struct {
+	AssignmentID  int64     `db:"assignment_id" json:"AssignmentID"`
+	AssetID       int64     `db:"asset_id" json:"AssetID"`
+	AssetTag      string    `db:"asset_tag" json:"AssetTag"`
+	AssetName     string    `db:"asset_name" json:"AssetName"`
+	AssetTypeName string    `db:"asset_type_name" json:"AssetTypeName"`
+	PersonID      int64     `db:"person_id" json:"PersonID"`
+	PersonName    string    `db:"person_name" json:"PersonName"`
+	PersonEmail   string    `db:"person_email" json:"PersonEmail"`
+	CheckedOutAt  time.Time `db:"checked_out_at" json:"CheckedOutAt"`
+	DueAt         time.Time `db:"due_at" json:"DueAt"`
+	RemindedAt    NullTime  `db:"reminded_at" json:"RemindedAt"`
+	Notes         string    `db:"notes" json:"Notes"`
+}
+
+// Overdue reports whether the loan was due back before now
+func (l Loan) Overdue(now time.Time) bool {
+	return l.DueAt.Before(now)
+}
+
+// DaysOverdue is the number of whole days since the loan was due back, zero if it is not overdue
+func (l Loan) DaysOverdue(now time.Time) int {
+	if !l.Overdue(now) {
+		return 0
+	}
+	return int(now.Sub(l.DueAt) / (24 * time.Hour))
+}
+
+// CheckOutRequest lends an asset held by a stock pool to a person until DueAt
+type CheckOutRequest struct {
+	AssetID  int64    `json:"AssetID"`
+	PersonID int64    `json:"PersonID"`
+	DueAt    NullTime `json:"DueAt"`
+	Notes    string   `json:"Notes"`
+}
+
+// CheckInRequest takes a loaned asset back into a stock pool, the default pool unless StockPoolID
+// is given
+type CheckInRequest struct {
+	StockPoolID int64           `json:"StockPoolID"`
+	Condition   ReturnCondition `json:"Condition"`
+	Notes       string          `json:"Notes"`
+}
+
+// CheckOut is the outcome of lending an asset
+type CheckOut struct {
+	Previous     *AssetAssignment   `json:"Previous"`     // The stock pool assignment as it was before it ended, nil if nobody held the asset
+	Loan         AssetAssignment    `json:"Loan"`         // The new loan
+	StatusChange *AssetStatusChange `json:"StatusChange"` // Nil if the asset kept its status
+}
+
+// CheckIn is the outcome of checking a loaned asset back in
+type CheckIn struct {
+	Loan         AssetAssignment    `json:"Loan"`         // The loan as it was before it ended
+	Stock        AssetAssignment    `json:"Stock"`        // The new assignment to the stock pool
+	StatusChange *AssetStatusChange `json:"StatusChange"` // Nil if the asset kept its status
+}
+
+// ReservationStatus is the state of a reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusReserved  ReservationStatus = "reserved"
+	ReservationStatusPickedUp  ReservationStatus = "picked_up" // Converted into an assignment
+	ReservationStatusCancelled ReservationStatus = "cancelled"
+)
+
+// IsValid reports whether s is a known reservation status
+func (s ReservationStatus) IsValid() bool {
+	return s == ReservationStatusReserved || s == ReservationStatusPickedUp || s == ReservationStatusCancelled
+}
+
+// Reservation books an asset, or any asset of a type, for a person from StartsAt until EndsAt
+type Reservation struct {
+	ID           int64             `db:"id" json:"ID"`
+	AssetID      *int64            `db:"asset_id" json:"AssetID"`          // The reserved asset, or the asset picked up for a type
+	AssetTypeID  *int64            `db:"asset_type_id" json:"AssetTypeID"` // Set when any asset of the type will do
+	PersonID     int64             `db:"person_id" json:"PersonID"`
+	StartsAt     time.Time         `db:"starts_at" json:"StartsAt"`
+	EndsAt       time.Time         `db:"ends_at" json:"EndsAt"`
+	Status       ReservationStatus `db:"status" json:"Status"`
+	AssignmentID *int64            `db:"assignment_id" json:"AssignmentID"` // The assignment created at pickup
+	Notes        string            `db:"notes" json:"Notes"`
+	CreatedAt    time.Time         `db:"created_at" json:"CreatedAt"`
+	UpdatedAt    time.Time         `db:"updated_at" json:"UpdatedAt"`
+
+	// Joined fields
+	AssetTag      string `db:"asset_tag" json:"AssetTag,omitempty"`
+	AssetName     string `db:"asset_name" json:"AssetName,omitempty"`
+	AssetTypeName string `db:"asset_type_name" json:"AssetTypeName,omitempty"`
+	PersonName    string `db:"person_name" json:"PersonName,omitempty"`
+}
+
+// PickupRequest converts a reservation into a loan. AssetID picks the asset for a reservation of
+// any asset of a type.
+type PickupRequest struct {
+	AssetID int64 `json:"AssetID"`
+}
+
+// ReservationConflictKind is what a reservation conflicts with
+type ReservationConflictKind string
+
+const (
+	ReservationConflictReservation ReservationConflictKind = "reservation" // Another reservation of the asset
+	ReservationConflictAssignment  ReservationConflictKind = "assignment"  // A person holds the asset
+	ReservationConflictCapacity    ReservationConflictKind = "capacity"    // Too few assets of the type are free
+)
+
+// ReservationConflict is something that prevents a reservation. For capacity conflicts From is
+// the first moment at which no asset of the type is left and ID is zero.
+type ReservationConflict struct {
+	Kind       ReservationConflictKind `json:"Kind"`
+	ID         int64                   `json:"ID"` // The conflicting reservation or assignment
+	AssetID    int64                   `json:"AssetID,omitempty"`
+	PersonName string                  `json:"PersonName,omitempty"`
+	From       time.Time               `json:"From"`
+	To         NullTime                `json:"To"`
+}
+
+// ScheduledChangeKind is the kind of a scheduled change of who holds an asset
+type ScheduledChangeKind string
+
+const (
+	ScheduledChangeAssignment ScheduledChangeKind = "assignment" // The asset moves to a person
+	ScheduledChangeReturn     ScheduledChangeKind = "return"     // The asset goes back to a stock pool
+	ScheduledChangeEnd        ScheduledChangeKind = "end"        // The assignment ends and nobody takes over
+)
+
+// ScheduledChange is an upcoming change of who holds an asset
+type ScheduledChange struct {
+	Kind           ScheduledChangeKind `db:"kind" json:"Kind"`
+	EffectiveAt    time.Time           `db:"effective_at" json:"EffectiveAt"`
+	AssignmentID   int64               `db:"assignment_id" json:"AssignmentID"`
+	AssetID        int64               `db:"asset_id" json:"AssetID"`
+	AssetTag       string              `db:"asset_tag" json:"AssetTag"`
+	AssetName      string              `db:"asset_name" json:"AssetName"`
+	FromPersonID   *int64              `db:"from_person_id" json:"FromPersonID"`
+	FromPersonName string              `db:"from_person_name" json:"FromPersonName"`
+	ToPersonID     *int64              `db:"to_person_id" json:"ToPersonID"`
+	ToPersonName   string              `db:"to_person_name" json:"ToPersonName"`
+	Notes          string              `db:"notes" json:"Notes"`
+}
+
+// ReturnCondition is the state an asset was handed back in
+type ReturnCondition string
+
+const (
+	ReturnConditionGood    ReturnCondition = "good"
+	ReturnConditionFair    ReturnCondition = "fair"
+	ReturnConditionDamaged ReturnCondition = "damaged"
+)
+
+// IsValid reports whether c is a known return condition
+func (c ReturnCondition) IsValid() bool {
+	return c == ReturnConditionGood || c == ReturnConditionFair || c == ReturnConditionDamaged
+}
+
+// OffboardingItem is the outcome for one asset held by a person being offboarded. Assets that are
+// not returned stay assigned to the person and are listed as missing.
+type OffboardingItem struct {
+	AssetID   int64           `json:"AssetID"`
+	Returned  bool            `json:"Returned"`
+	Condition ReturnCondition `json:"Condition"`
+	Notes     string          `json:"Notes"`
+}
+
+// OffboardingRequest is the body of an offboarding. Held assets without an item count as missing.
+type OffboardingRequest struct {
+	LeaveDate   NullTime          `json:"LeaveDate"`
+	StockPoolID int64             `json:"StockPoolID"` // 0 returns to the default stock pool
+	Items       []OffboardingItem `json:"Items"`
+}
+
+// Offboarding lists the records an offboarding changed, each as it was before
+type Offboarding struct {
+	Person        Person              `json:"Person"`
+	Returned      []AssetAssignment   `json:"Returned"`      // Ended assignments of the person
+	Stock         []AssetAssignment   `json:"Stock"`         // New assignments to the stock pool
+	StatusChanges []AssetStatusChange `json:"StatusChanges"` // Returned assets moved out of deployed
+	Missing       []AssetAssignment   `json:"Missing"`       // Assets the person still holds
+	Cancelled     []Reservation       `json:"Cancelled"`     // Reservations of the person not picked up
+	Pending       []AssetAssignment   `json:"Pending"`       // Cancelled assignments to the person that had not started
+	Extended      []AssetAssignment   `json:"Extended"`      // Assignments continuing in place of a cancelled one
+}
+
+// OffboardingChecklistItem is an asset on the checklist of a person who left, either still held
+// or returned with a condition
+type OffboardingChecklistItem struct {
+	AssetID           int64           `db:"asset_id" json:"AssetID"`
+	AssetTag          string          `db:"asset_tag" json:"AssetTag"`
+	AssetName         string          `db:"asset_name" json:"AssetName"`
+	AssetTypeName     string          `db:"asset_type_name" json:"AssetTypeName"`
+	AssetSerialNumber string          `db:"asset_serial_number" json:"AssetSerialNumber"`
+	Returned          bool            `db:"returned" json:"Returned"`
+	ReturnedAt        NullTime        `db:"returned_at" json:"ReturnedAt,omitempty"`
+	Condition         ReturnCondition `db:"return_condition" json:"Condition,omitempty"`
+	Notes             string          `db:"return_notes" json:"Notes,omitempty"`
+}
+
+// LocationKind classifies a level of the location hierarchy
+type LocationKind string
+
+const (
+	LocationKindSite     LocationKind = "site"
+	LocationKindBuilding LocationKind = "building"
+	LocationKindFloor    LocationKind = "floor"
+	LocationKindRoom     LocationKind = "room"
+	LocationKindRack     LocationKind = "rack"
+	LocationKindOther    LocationKind = "other"
+)
+
+// LocationKinds lists every location kind, from the top of the hierarchy down
+var LocationKinds = []LocationKind{
+	LocationKindSite,
+	LocationKindBuilding,
+	LocationKindFloor,
+	LocationKindRoom,
+	LocationKindRack,
+	LocationKindOther,
+}
+
+// IsValid reports whether the kind is a known location kind
+func (k LocationKind) IsValid() bool {
+	for _, kind := range LocationKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Location is a physical place such as a site, building, room or rack. Locations form a tree
+// through ParentID.
+type Location struct {
+	BaseModel
+	ParentID    *int64       `db:"parent_id" json:"ParentID,omitempty"`
+	Name        string       `db:"name" json:"Name"`
+	Kind        LocationKind `db:"kind" json:"Kind"`
+	Description string       `db:"description" json:"Description"`
+
+	// Computed fields
+	Path string `db:"-" json:"Path,omitempty"`
+}
+
+// AssetLocation tracks the placement of an asset at a location
+type AssetLocation struct {
+	BaseModel
+	AssetID       int64    `db:"asset_id" json:"AssetID"`
+	LocationID    int64    `db:"location_id" json:"LocationID"`
+	EffectiveFrom NullTime `db:"effective_from" json:"EffectiveFrom"`
+	EffectiveTo   NullTime `db:"effective_to" json:"EffectiveTo,omitempty"`
+	Notes         string   `db:"notes" json:"Notes"`
+
+	// Joined fields
+	AssetName    string `db:"asset_name" json:"AssetName,omitempty"`
+	LocationName string `db:"location_name" json:"LocationName,omitempty"`
+	LocationPath string `db:"-" json:"LocationPath,omitempty"`
+}
+
+// ContractKind distinguishes warranties, support contracts and leases
+type ContractKind string
+
+const (
+	ContractKindWarranty ContractKind = "warranty"
+	ContractKindSupport  ContractKind = "support"
+	ContractKindLease    ContractKind = "lease"
+)
+
+// IsValid reports whether the kind is a known contract kind
+func (k ContractKind) IsValid() bool {
+	return k == ContractKindWarranty || k == ContractKindSupport || k == ContractKindLease
+}
+
+// AssetContract is a warranty, support contract or lease covering an asset. For leases EndsAt
+// is the date the asset must be returned by.
+type AssetContract struct {
+	BaseModel
+	AssetID        int64        `db:"asset_id" json:"AssetID"`
+	Kind           ContractKind `db:"kind" json:"Kind"`
+	Vendor         string       `db:"vendor" json:"Vendor"`
+	ContractNumber string       `db:"contract_number" json:"ContractNumber"`
+	StartsAt       NullTime     `db:"starts_at" json:"StartsAt,omitempty"`
+	EndsAt         NullTime     `db:"ends_at" json:"EndsAt"`
+	Notes          string       `db:"notes" json:"Notes"`
+
+	// Joined fields
+	AssetName string `db:"asset_name" json:"AssetName,omitempty"`
+}
+
+// ExpiringContract is a contract ending within the requested period
+type ExpiringContract struct {
+	AssetContract
+	DaysLeft int      `db:"days_left" json:"DaysLeft"`
+	RaisedAt NullTime `db:"raised_at" json:"RaisedAt,omitempty"` // When the daily job first raised the alert
+}
+
+// BookValue is the depreciated value of an asset as of a date. Amounts are in the currency of
+// the asset.
+type BookValue struct {
+	AssetID                 int64              `db:"asset_id" json:"AssetID"`
+	AssetName               string             `db:"asset_name" json:"AssetName"`
+	AssetTypeID             int64              `db:"asset_type_id" json:"AssetTypeID"`
+	AssetTypeName           string             `db:"asset_type_name" json:"AssetTypeName"`
+	PurchasedAt             NullTime           `db:"purchased_at" json:"PurchasedAt"`
+	PurchaseCost            float64            `db:"purchase_cost" json:"PurchaseCost"`
+	Currency                string             `db:"currency" json:"Currency"`
+	Method                  DepreciationMethod `db:"depreciation_method" json:"Method"`
+	UsefulLifeMonths        int                `db:"useful_life_months" json:"UsefulLifeMonths"`
+	AsOf                    time.Time          `db:"-" json:"AsOf"`
+	MonthsElapsed           int                `db:"-" json:"MonthsElapsed"`
+	AccumulatedDepreciation float64            `db:"-" json:"AccumulatedDepreciation"`
+	BookValue               float64            `db:"-" json:"BookValue"`
+	FullyDepreciated        bool               `db:"-" json:"FullyDepreciated"`
+	Schedule                []DepreciationYear `db:"-" json:"Schedule,omitempty"`
+}
+
+// DepreciationYear is one year of the depreciation schedule of an asset, counted from its
+// purchase date
+type DepreciationYear struct {
+	Year         int       `json:"Year"`
+	EndsAt       time.Time `json:"EndsAt"`
+	Depreciation float64   `json:"Depreciation"`
+	BookValue    float64   `json:"BookValue"`
+}
+
+// DepreciationSummary totals the book values of the assets of one type and currency
+type DepreciationSummary struct {
+	AssetTypeID             int64   `json:"AssetTypeID"`
+	AssetTypeName           string  `json:"AssetTypeName"`
+	Currency                string  `json:"Currency"`
+	AssetCount              int     `json:"AssetCount"`
+	PurchaseCost            float64 `json:"PurchaseCost"`
+	AccumulatedDepreciation float64 `json:"AccumulatedDepreciation"`
+	BookValue               float64 `json:"BookValue"`
+}
+
+// AttachmentEntity names the kind of record a file is attached to
+type AttachmentEntity string
+
+const (
+	AttachmentEntityAsset      AttachmentEntity = "asset"
+	AttachmentEntityPerson     AttachmentEntity = "person"
+	AttachmentEntityAssignment AttachmentEntity = "assignment"
+)
+
+// Attachment describes a file attached to an asset, person or assignment. The content is kept
+// in the storage backend under StorageKey.
+type Attachment struct {
+	BaseModel
+	EntityType     AttachmentEntity `db:"entity_type" json:"EntityType"`
+	EntityID       int64            `db:"entity_id" json:"EntityID"`
+	FileName       string           `db:"file_name" json:"FileName"`
+	ContentType    string           `db:"content_type" json:"ContentType"`
+	Size           int64            `db:"size_bytes" json:"Size"`
+	SHA256         string           `db:"sha256" json:"SHA256"`
+	StorageKey     string           `db:"storage_key" json:"-"`
+	Description    string           `db:"description" json:"Description"`
+	UploadedBy     *int64           `db:"uploaded_by" json:"UploadedBy,omitempty"`
+	UploadedByName string           `db:"uploaded_by_name" json:"UploadedByName"`
+}
+
 // AssetWithAssignment combines asset info with current assignment
 type AssetWithAssignment struct {
 	Asset
 	AssetTypeName     string   `db:"asset_type_name" json:"AssetTypeName,omitempty"`
 	CurrentAssignee   *string  `db:"currentassignee" json:"CurrentAssignee,omitempty"`
 	CurrentAssigneeID *int64   `db:"currentassigneeid" json:"CurrentAssigneeID,omitempty"`
+	InStockPool       bool     `db:"instockpool" json:"InStockPool"`
 	AssignedFrom      NullTime `db:"assignedfrom" json:"AssignedFrom,omitempty"`
+	LocationID        *int64   `db:"locationid" json:"LocationID,omitempty"`
+	LocationName      *string  `db:"locationname" json:"LocationName,omitempty"`
 }
 
 // LoginRequest represents a login attempt
@@ -155,3 +735,85 @@ type LoginResponse struct {
 	ExpiresAt int64  `json:"ExpiresAt"`
 	User      User   `json:"User"`
 }
+
+// AuditAction represents the kind of mutation recorded in the audit log
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// AuditEntityType names the kind of record an audit log entry refers to
+type AuditEntityType string
+
+const (
+	AuditEntityAsset           AuditEntityType = "asset"
+	AuditEntityAssetType       AuditEntityType = "asset_type"
+	AuditEntityAssetProperty   AuditEntityType = "asset_property"
+	AuditEntityProperty        AuditEntityType = "property"
+	AuditEntityPerson          AuditEntityType = "person"
+	AuditEntityPersonAttribute AuditEntityType = "person_attribute"
+	AuditEntityAttribute       AuditEntityType = "attribute"
+	AuditEntityAssignment      AuditEntityType = "assignment"
+	AuditEntityUser            AuditEntityType = "user"
+	AuditEntityLocation        AuditEntityType = "location"
+	AuditEntityAssetLocation   AuditEntityType = "asset_location"
+	AuditEntityAssetContract   AuditEntityType = "asset_contract"
+	AuditEntityAttachment      AuditEntityType = "attachment"
+	AuditEntityReservation     AuditEntityType = "reservation"
+)
+
+// AuditLog records a single mutation together with the acting user
+type AuditLog struct {
+	ID         int64           `db:"id" json:"ID"`
+	UserID     *int64          `db:"user_id" json:"UserID,omitempty"`
+	Username   string          `db:"username" json:"Username"`
+	EntityType AuditEntityType `db:"entity_type" json:"EntityType"`
+	EntityID   int64           `db:"entity_id" json:"EntityID"`
+	AssetID    *int64          `db:"asset_id" json:"AssetID,omitempty"`
+	Action     AuditAction     `db:"action" json:"Action"`
+	Changes    JSONData        `db:"changes" json:"Changes"`
+	CreatedAt  time.Time       `db:"created_at" json:"CreatedAt"`
+}
+
+// AssetImportRow is a validated row of a bulk asset import
+type AssetImportRow struct {
+	Row        int             `json:"Row"`
+	Asset      Asset           `json:"Asset"`
+	Properties []AssetProperty `json:"Properties,omitempty"`
+	AssigneeID int64           `json:"AssigneeID,omitempty"`
+}
+
+// ImportError describes why a row of an import was rejected
+type ImportError struct {
+	Row    int    `json:"Row"`
+	Column string `json:"Column,omitempty"`
+	Error  string `json:"Error"`
+}
+
+// ReportColumn describes a column of a report result
+type ReportColumn struct {
+	Key   string `json:"Key"`
+	Label string `json:"Label"`
+}
+
+// FieldError describes a property or attribute value that does not match its definition
+type FieldError struct {
+	Field   string `json:"Field"`
+	FieldID int64  `json:"FieldID"`
+	Value   string `json:"Value"`
+	Error   string `json:"Error"`
+}
+
+// CustomValue is a stored property or attribute value together with its definition
+type CustomValue struct {
+	ID          int64    `db:"id" json:"ID"`
+	OwnerID     int64    `db:"owner_id" json:"OwnerID"` // Asset or person ID
+	FieldID     int64    `db:"field_id" json:"FieldID"`
+	FieldName   string   `db:"field_name" json:"FieldName"`
+	DataType    DataType `db:"data_type" json:"DataType"`
+	EnumOptions string   `db:"enum_options" json:"EnumOptions"`
+	Value       string   `db:"value" json:"Value"`
+}
@@ -0,0 +1,26 @@
+package notify
+
+import (
+	"context"
+	"log"
+)
+
+// Log writes messages to a logger instead of delivering them, for testing and for installations
+// without a mail server
+type Log struct {
+	logger *log.Logger
+}
+
+// NewLog creates a log backend writing to logger, or to the standard logger if it is nil
+func NewLog(logger *log.Logger) *Log {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &Log{logger: logger}
+}
+
+// Notify logs the recipient, subject and body of the message
+func (l *Log) Notify(ctx context.Context, msg Message) error {
+	l.logger.Printf("Notification to %s <%s>: %s\n%s", msg.ToName, msg.ToAddress, msg.Subject, msg.Body)
+	return nil
+}
@@ -0,0 +1,38 @@
+// Package notify sends notifications to persons through a pluggable backend, either mail over
+// SMTP or the server log.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"assetManager/internal/config"
+)
+
+var ErrNoAddress = errors.New("recipient has no email address")
+
+// Message is a plain text notification to one person
+type Message struct {
+	ToName    string
+	ToAddress string
+	Subject   string
+	Body      string
+}
+
+// Notifier delivers messages
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+// New creates the notifier selected in the configuration
+func New(cfg config.NotificationsConfig) (Notifier, error) {
+	switch cfg.Backend {
+	case "", "log":
+		return NewLog(nil), nil
+	case "smtp":
+		return NewSMTP(cfg.SMTP)
+	default:
+		return nil, fmt.Errorf("unknown notification backend %q, must be log or smtp", cfg.Backend)
+	}
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+
+	"assetManager/internal/config"
+)
+
+func TestNew(t *testing.T) {
+	if n, err := New(config.NotificationsConfig{}); err != nil {
+		t.Errorf("Expected the log backend by default, got %v", err)
+	} else if _, ok := n.(*Log); !ok {
+		t.Errorf("Expected the log backend by default, got %T", n)
+	}
+	if _, err := New(config.NotificationsConfig{Backend: "smtp"}); err == nil {
+		t.Error("Expected smtp without a host to be rejected")
+	}
+	if _, err := New(config.NotificationsConfig{Backend: "smtp", SMTP: config.SMTPConfig{Host: "mail.example.com", From: "not an address"}}); err == nil {
+		t.Error("Expected an invalid sender to be rejected")
+	}
+	if _, err := New(config.NotificationsConfig{Backend: "pigeon"}); err == nil {
+		t.Error("Expected an unknown backend to be rejected")
+	}
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	msg := Message{ToName: "Ada Lovelace", ToAddress: "ada@example.com", Subject: "Loan overdue", Body: "Please return LPT-0001"}
+	if err := NewLog(log.New(&buf, "", 0)).Notify(context.Background(), msg); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	for _, want := range []string{"Ada Lovelace <ada@example.com>", "Loan overdue", "Please return LPT-0001"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Expected the log to contain %q, got %q", want, buf.String())
+		}
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	from := mustParseAddress(t, "Asset Manager <assets@example.com>")
+	to := mustParseAddress(t, "Jürgen Müller <jm@example.com>")
+	date := time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC)
+
+	data, err := buildMessage(from, to, "Loan due\r\nBcc: evil@example.com", "Line one\nLine two", date)
+	if err != nil {
+		t.Fatalf("buildMessage failed: %v", err)
+	}
+	header, body, _ := strings.Cut(string(data), "\r\n\r\n")
+	for _, want := range []string{
+		`From: "Asset Manager" <assets@example.com>`,
+		"To: =?utf-8?q?J=C3=BCrgen_M=C3=BCller?= <jm@example.com>",
+		"Subject: Loan due Bcc: evil@example.com",
+		"Date: Sun, 10 Mar 2024 08:00:00 +0000",
+	} {
+		if !strings.Contains(header, want+"\r\n") {
+			t.Errorf("Expected header %q, got %q", want, header)
+		}
+	}
+	if strings.Contains(header, "\r\nBcc:") {
+		t.Errorf("Expected the subject not to add headers, got %q", header)
+	}
+	if body != "Line one\r\nLine two" {
+		t.Errorf("Expected CRLF line breaks in the body, got %q", body)
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go serveSMTP(ln, received)
+
+	addr := ln.Addr().(*net.TCPAddr)
+	s, err := NewSMTP(config.SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "assets@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP failed: %v", err)
+	}
+	if err := s.Notify(context.Background(), Message{ToName: "Ada", Subject: "Loan due"}); err != ErrNoAddress {
+		t.Errorf("Expected ErrNoAddress without an address, got %v", err)
+	}
+	if err := s.Notify(context.Background(), Message{ToName: "Ada", ToAddress: "ada@example.com", Subject: "Loan due", Body: "Please return it"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	select {
+	case session := <-received:
+		for _, want := range []string{"MAIL FROM:<assets@example.com>", "RCPT TO:<ada@example.com>", "Subject: Loan due", "Please return it"} {
+			if !strings.Contains(session, want) {
+				t.Errorf("Expected the session to contain %q, got %q", want, session)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the mail")
+	}
+}
+
+func TestSMTP_Cancelled(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer ln.Close()
+
+	// A server that accepts the connection but never greets the client
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.Copy(io.Discard, conn)
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	s, err := NewSMTP(config.SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "assets@example.com"})
+	if err != nil {
+		t.Fatalf("NewSMTP failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Notify(ctx, Message{ToName: "Ada", ToAddress: "ada@example.com", Subject: "Loan due"})
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected an error from a server that does not answer")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Notify to give up once the context is done")
+	}
+}
+
+// serveSMTP accepts one connection and answers like a minimal SMTP server without extensions,
+// sending everything the client wrote to received
+func serveSMTP(ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var session strings.Builder
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP")
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			received <- session.String()
+			return
+		}
+		session.WriteString(line)
+		switch {
+		case inData:
+			if line == ".\r\n" {
+				inData = false
+				reply("250 OK")
+			}
+		case strings.HasPrefix(line, "EHLO"), strings.HasPrefix(line, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(line, "DATA"):
+			inData = true
+			reply("354 End data with <CR><LF>.<CR><LF>")
+		case strings.HasPrefix(line, "QUIT"):
+			reply("221 Bye")
+			received <- session.String()
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func mustParseAddress(t *testing.T, s string) *mail.Address {
+	t.Helper()
+	a, err := mail.ParseAddress(s)
+	if err != nil {
+		t.Fatalf("ParseAddress(%q) failed: %v", s, err)
+	}
+	return a
+}
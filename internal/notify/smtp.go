@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"assetManager/internal/config"
+)
+
+// smtpTimeout bounds the delivery of one message, from connecting to the end of the session
+const smtpTimeout = 30 * time.Second
+
+// SMTP delivers messages as mail through an SMTP server, using STARTTLS when the server offers it
+type SMTP struct {
+	addr string
+	auth smtp.Auth
+	from *mail.Address
+}
+
+// NewSMTP creates an SMTP backend, checking that the server and sender are configured
+func NewSMTP(cfg config.SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, errors.New("smtp notifications need a host")
+	}
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp sender %q: %w", cfg.From, err)
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 587
+	}
+	s := &SMTP{addr: net.JoinHostPort(cfg.Host, strconv.Itoa(port)), from: from}
+	if cfg.Username != "" {
+		s.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return s, nil
+}
+
+// Notify sends the message to the recipient's address, giving up when ctx is done or after
+// smtpTimeout
+func (s *SMTP) Notify(ctx context.Context, msg Message) error {
+	if msg.ToAddress == "" {
+		return ErrNoAddress
+	}
+	to := &mail.Address{Name: msg.ToName, Address: msg.ToAddress}
+	data, err := buildMessage(s.from, to, msg.Subject, msg.Body, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	// Cancelling the context aborts a session in progress
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	return s.send(conn, to.Address, data)
+}
+
+// send delivers one mail over an open connection like smtp.SendMail, which cannot be given a
+// connection with a deadline
+func (s *SMTP) send(conn net.Conn, to string, data []byte) error {
+	host, _, err := net.SplitHostPort(s.addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if s.auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("smtp server does not support authentication")
+		}
+		if err := c.Auth(s.auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(s.from.Address); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// buildMessage formats a plain text mail, encoding non-ASCII names and subjects and the body as
+// quoted-printable. Line breaks in the subject are replaced, so it cannot add headers.
+func buildMessage(from, to *mail.Address, subject, body string, date time.Time) ([]byte, error) {
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var b bytes.Buffer
+	b.WriteString("From: " + from.String() + "\r\n")
+	b.WriteString("To: " + to.String() + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n")
+	b.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	body = strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
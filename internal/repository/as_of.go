@@ -0,0 +1,53 @@
+package repository
+
+import "time"
+
+// asOfArg is the argument of a point in time compared with COALESCE(?, NOW()): NULL for the zero
+// time, so the query uses the current time
+func asOfArg(asOf time.Time) interface{} {
+	if asOf.IsZero() {
+		return nil
+	}
+	return asOf
+}
+
+// assetsAsOf returns a table expression to use in place of the assets table, with its arguments.
+// At a point in time it holds the assets that existed then, with later deletions undone and the
+// status taken from the status history; other fields keep their current values. For the zero
+// time it is the assets table itself.
+func assetsAsOf(asOf time.Time) (string, []interface{}) {
+	if asOf.IsZero() {
+		return "assets", nil
+	}
+	table := `(SELECT x.id, x.asset_type_id, x.tag,
+			  COALESCE(
+			      (SELECT h.to_status FROM asset_status_history h
+			       WHERE h.asset_id = x.id AND h.changed_at <= ?
+			       ORDER BY h.changed_at DESC, h.id DESC LIMIT 1),
+			      (SELECT h.from_status FROM asset_status_history h
+			       WHERE h.asset_id = x.id AND h.changed_at > ?
+			       ORDER BY h.changed_at, h.id LIMIT 1),
+			      x.status) as status,
+			  x.name, x.model, x.serial_number, x.order_no, x.license_number, x.notes,
+			  x.purchased_at, x.purchase_cost, x.currency, x.created_at, x.updated_at,
+			  CASE WHEN x.deleted_at <= ? THEN x.deleted_at END as deleted_at
+			  FROM assets x WHERE x.created_at <= ?)`
+	return table, []interface{}{asOf, asOf, asOf, asOf}
+}
+
+// personsAsOf returns a table expression to use in place of the persons table, with its
+// arguments. At a point in time it holds the persons that existed then, with later deletions and
+// departures undone; other fields keep their current values. For the zero time it is the persons
+// table itself.
+func personsAsOf(asOf time.Time) (string, []interface{}) {
+	if asOf.IsZero() {
+		return "persons", nil
+	}
+	table := `(SELECT y.id, y.name, y.email, y.phone, y.is_stock,
+			  y.is_active OR COALESCE(y.left_at > ?, FALSE) as is_active,
+			  CASE WHEN y.left_at <= ? THEN y.left_at END as left_at,
+			  y.created_at, y.updated_at,
+			  CASE WHEN y.deleted_at <= ? THEN y.deleted_at END as deleted_at
+			  FROM persons y WHERE y.created_at <= ?)`
+	return table, []interface{}{asOf, asOf, asOf, asOf}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 
 	"assetManager/internal/models"
+	"assetManager/internal/uniqueness"
 )
 
 var ErrAssetNotFound = errors.New("asset not found")
@@ -25,13 +29,13 @@ func NewAssetRepository(db *sqlx.DB) *AssetRepository {
 // GetByID retrieves an asset by ID
 func (r *AssetRepository) GetByID(ctx context.Context, id int64) (*models.Asset, error) {
 	var asset models.Asset
-	query := `SELECT a.id, a.asset_type_id, a.name, 
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
 			  COALESCE(a.model, '') as model, 
 			  COALESCE(a.serial_number, '') as serial_number, 
 			  COALESCE(a.order_no, '') as order_no, 
 			  COALESCE(a.license_number, '') as license_number, 
 			  COALESCE(a.notes, '') as notes, 
-			  a.purchased_at,
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
 			  a.created_at, a.updated_at, a.deleted_at,
 			  COALESCE(at.name, '') as asset_type_name
 			  FROM assets a
@@ -44,6 +48,75 @@ func (r *AssetRepository) GetByID(ctx context.Context, id int64) (*models.Asset,
 	return &asset, err
 }
 
+// GetByTag retrieves an asset by its tag
+func (r *AssetRepository) GetByTag(ctx context.Context, tag string) (*models.Asset, error) {
+	var asset models.Asset
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
+			  COALESCE(a.model, '') as model, 
+			  COALESCE(a.serial_number, '') as serial_number, 
+			  COALESCE(a.order_no, '') as order_no, 
+			  COALESCE(a.license_number, '') as license_number, 
+			  COALESCE(a.notes, '') as notes, 
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
+			  a.created_at, a.updated_at, a.deleted_at,
+			  COALESCE(at.name, '') as asset_type_name
+			  FROM assets a
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE a.tag = ? AND a.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &asset, query, tag)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetNotFound
+	}
+	return &asset, err
+}
+
+// FindConflict returns the non-deleted asset other than the given one that has the same value in
+// the field of a uniqueness rule, or nil if there is none. Values are compared ignoring case and
+// surrounding spaces.
+func (r *AssetRepository) FindConflict(ctx context.Context, rule uniqueness.Rule, asset *models.Asset) (*models.Asset, error) {
+	return findAssetConflict(ctx, r.db, rule, asset)
+}
+
+func findAssetConflict(ctx context.Context, q queryer, rule uniqueness.Rule, asset *models.Asset) (*models.Asset, error) {
+	value := rule.AssetValue(asset)
+	if value == "" {
+		return nil, nil
+	}
+	column := rule.Column()
+	if rule.Entity != uniqueness.EntityAsset || column == "" {
+		return nil, fmt.Errorf("invalid asset uniqueness rule on %q", rule.Field)
+	}
+
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
+			  COALESCE(a.model, '') as model, 
+			  COALESCE(a.serial_number, '') as serial_number, 
+			  COALESCE(a.order_no, '') as order_no, 
+			  COALESCE(a.license_number, '') as license_number, 
+			  COALESCE(a.notes, '') as notes, 
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
+			  a.created_at, a.updated_at, a.deleted_at,
+			  COALESCE(at.name, '') as asset_type_name
+			  FROM assets a
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE a.deleted_at IS NULL AND a.id <> ? AND LOWER(TRIM(a.` + column + `)) = LOWER(?)`
+	args := []interface{}{asset.ID, value}
+	if rule.Scope == uniqueness.ScopeAssetType {
+		query += ` AND a.asset_type_id = ?`
+		args = append(args, asset.AssetTypeID)
+	}
+	query += ` ORDER BY a.id LIMIT 1`
+
+	var existing models.Asset
+	err := q.GetContext(ctx, &existing, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
 // GetAll retrieves all assets. If includeDeleted is true, returns only soft-deleted records.
 func (r *AssetRepository) GetAll(ctx context.Context, includeDeleted bool) ([]models.Asset, error) {
 	var assets []models.Asset
@@ -51,13 +124,13 @@ func (r *AssetRepository) GetAll(ctx context.Context, includeDeleted bool) ([]mo
 	if includeDeleted {
 		deletedFilter = "a.deleted_at IS NOT NULL"
 	}
-	query := `SELECT a.id, a.asset_type_id, a.name, 
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
 			  COALESCE(a.model, '') as model, 
 			  COALESCE(a.serial_number, '') as serial_number, 
 			  COALESCE(a.order_no, '') as order_no, 
 			  COALESCE(a.license_number, '') as license_number, 
 			  COALESCE(a.notes, '') as notes, 
-			  a.purchased_at,
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
 			  a.created_at, a.updated_at, a.deleted_at,
 			  COALESCE(at.name, '') as asset_type_name
 			  FROM assets a
@@ -70,13 +143,13 @@ func (r *AssetRepository) GetAll(ctx context.Context, includeDeleted bool) ([]mo
 // GetByAssetType retrieves all assets of a specific type
 func (r *AssetRepository) GetByAssetType(ctx context.Context, assetTypeID int64) ([]models.Asset, error) {
 	var assets []models.Asset
-	query := `SELECT a.id, a.asset_type_id, a.name, 
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
 			  COALESCE(a.model, '') as model, 
 			  COALESCE(a.serial_number, '') as serial_number, 
 			  COALESCE(a.order_no, '') as order_no, 
 			  COALESCE(a.license_number, '') as license_number, 
 			  COALESCE(a.notes, '') as notes, 
-			  a.purchased_at,
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
 			  a.created_at, a.updated_at, a.deleted_at,
 			  COALESCE(at.name, '') as asset_type_name
 			  FROM assets a
@@ -86,42 +159,112 @@ func (r *AssetRepository) GetByAssetType(ctx context.Context, assetTypeID int64)
 	return assets, err
 }
 
+// GetByIDs retrieves the assets with the given IDs. IDs of missing or deleted assets are skipped.
+func (r *AssetRepository) GetByIDs(ctx context.Context, ids []int64) ([]models.Asset, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	var assets []models.Asset
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
+			  COALESCE(a.model, '') as model, 
+			  COALESCE(a.serial_number, '') as serial_number, 
+			  COALESCE(a.order_no, '') as order_no, 
+			  COALESCE(a.license_number, '') as license_number, 
+			  COALESCE(a.notes, '') as notes, 
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
+			  a.created_at, a.updated_at, a.deleted_at,
+			  COALESCE(at.name, '') as asset_type_name
+			  FROM assets a
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE a.id IN (?` + strings.Repeat(", ?", len(ids)-1) + `) AND a.deleted_at IS NULL`
+	err := r.db.SelectContext(ctx, &assets, query, args...)
+	return assets, err
+}
+
 // GetWithCurrentAssignment retrieves all assets with their current assignment. If includeDeleted is true, returns only soft-deleted records.
-func (r *AssetRepository) GetWithCurrentAssignment(ctx context.Context, includeDeleted bool) ([]models.AssetWithAssignment, error) {
+// If statuses is not empty, only assets in one of these statuses are returned. A non-zero asOf
+// reconstructs the assets, their status, assignment and location as they were at that time.
+func (r *AssetRepository) GetWithCurrentAssignment(ctx context.Context, includeDeleted bool, statuses []models.AssetStatus, asOf time.Time) ([]models.AssetWithAssignment, error) {
 	var assets []models.AssetWithAssignment
 	deletedFilter := "a.deleted_at IS NULL"
 	if includeDeleted {
 		deletedFilter = "a.deleted_at IS NOT NULL"
 	}
-	query := `SELECT a.id, a.asset_type_id, a.name, 
+	assetsTable, args := assetsAsOf(asOf)
+	args = append(args, asOfArg(asOf), asOfArg(asOf), asOfArg(asOf), asOfArg(asOf))
+	if len(statuses) > 0 {
+		deletedFilter += " AND a.status IN (?" + strings.Repeat(", ?", len(statuses)-1) + ")"
+		for _, status := range statuses {
+			args = append(args, status)
+		}
+	}
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
 			  COALESCE(a.model, '') as model, 
 			  COALESCE(a.serial_number, '') as serial_number, 
 			  COALESCE(a.order_no, '') as order_no, 
 			  COALESCE(a.license_number, '') as license_number, 
 			  COALESCE(a.notes, '') as notes, 
-			  a.purchased_at,
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
 			  a.created_at, a.updated_at, a.deleted_at,
 			  COALESCE(at.name, '') as asset_type_name,
-			  p.name as currentassignee, p.id as currentassigneeid, aa.effective_from as assignedfrom
-			  FROM assets a
+			  p.name as currentassignee, p.id as currentassigneeid, aa.effective_from as assignedfrom,
+			  COALESCE(p.is_stock, FALSE) as instockpool,
+			  loc.id as locationid, loc.name as locationname
+			  FROM ` + assetsTable + ` a
 			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
 			  LEFT JOIN asset_assignments aa ON a.id = aa.asset_id 
 			      AND aa.deleted_at IS NULL 
-			      AND aa.effective_from <= NOW() 
-			      AND (aa.effective_to IS NULL OR aa.effective_to > NOW())
+			      AND aa.effective_from <= COALESCE(?, NOW()) 
+			      AND (aa.effective_to IS NULL OR aa.effective_to > COALESCE(?, NOW()))
 			  LEFT JOIN persons p ON aa.person_id = p.id
+			  LEFT JOIN asset_locations al ON a.id = al.asset_id
+			      AND al.deleted_at IS NULL
+			      AND al.effective_from <= COALESCE(?, NOW())
+			      AND (al.effective_to IS NULL OR al.effective_to > COALESCE(?, NOW()))
+			  LEFT JOIN locations loc ON al.location_id = loc.id
 			  WHERE ` + deletedFilter + ` 
 			  ORDER BY a.name`
-	err := r.db.SelectContext(ctx, &assets, query)
+	err := r.db.SelectContext(ctx, &assets, query, args...)
 	return assets, err
 }
 
 // Create creates a new asset
 func (r *AssetRepository) Create(ctx context.Context, asset *models.Asset) error {
-	query := `INSERT INTO assets (asset_type_id, name, model, serial_number, order_no, license_number, notes, purchased_at) 
-			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
-	result, err := r.db.ExecContext(ctx, query, asset.AssetTypeID, asset.Name, asset.Model,
-		asset.SerialNumber, asset.OrderNo, asset.LicenseNumber, asset.Notes, asset.PurchasedAt)
+	return r.CreateWithProperties(ctx, asset, nil, nil)
+}
+
+// createAsset inserts an asset using the given connection or transaction and records its
+// initial status. Assets without a status start in stock and assets without a tag get the
+// next tag of their type's pattern.
+func createAsset(ctx context.Context, q queryer, asset *models.Asset) error {
+	if asset.Status == "" {
+		asset.Status = models.AssetStatusInStock
+	}
+	if asset.Tag == "" {
+		tag, err := nextTag(ctx, q, asset.AssetTypeID, time.Now())
+		if err != nil {
+			return err
+		}
+		asset.Tag = tag
+	} else if taken, err := tagTaken(ctx, q, asset.Tag, 0); err != nil {
+		return err
+	} else if taken {
+		return ErrDuplicateTag
+	}
+
+	query := `INSERT INTO assets (asset_type_id, tag, status, name, model, serial_number, order_no, license_number, notes, purchased_at,
+			  purchase_cost, currency) 
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULLIF(?, ''))`
+	result, err := q.ExecContext(ctx, query, asset.AssetTypeID, asset.Tag, asset.Status, asset.Name, asset.Model,
+		asset.SerialNumber, asset.OrderNo, asset.LicenseNumber, asset.Notes, asset.PurchasedAt,
+		asset.PurchaseCost, asset.Currency)
+	if isDuplicateKey(err) {
+		return ErrDuplicateTag
+	}
 	if err != nil {
 		return err
 	}
@@ -130,16 +273,63 @@ func (r *AssetRepository) Create(ctx context.Context, asset *models.Asset) error
 		return err
 	}
 	asset.ID = id
-	return nil
+	return createStatusChange(ctx, q, &models.AssetStatusChange{AssetID: id, ToStatus: asset.Status, Reason: "Created"})
+}
+
+// CreateWithProperties creates an asset together with its property values in one transaction.
+// It returns a *UniqueConflictError if another asset has a value the rules require to be unique.
+func (r *AssetRepository) CreateWithProperties(ctx context.Context, asset *models.Asset, properties []models.AssetProperty, unique uniqueness.Rules) error {
+	return withUniqueLock(ctx, r.db, unique, uniqueness.EntityAsset, func(tx *sqlx.Tx) error {
+		if err := checkUniqueAsset(ctx, tx, unique, asset); err != nil {
+			return err
+		}
+		if err := createAsset(ctx, tx, asset); err != nil {
+			return err
+		}
+		return writeAssetProperties(ctx, tx, asset.ID, properties)
+	})
 }
 
 // Update updates an existing asset
 func (r *AssetRepository) Update(ctx context.Context, asset *models.Asset) error {
-	query := `UPDATE assets SET asset_type_id = ?, name = ?, model = ?, serial_number = ?, 
-			  order_no = ?, license_number = ?, notes = ?, purchased_at = ?, updated_at = NOW() 
+	return updateAsset(ctx, r.db, asset)
+}
+
+// UpdateWithProperties updates an asset and writes its property values in one transaction.
+// Empty property values clear the property. It returns a *UniqueConflictError if another asset
+// has a value the rules require to be unique.
+func (r *AssetRepository) UpdateWithProperties(ctx context.Context, asset *models.Asset, properties []models.AssetProperty, unique uniqueness.Rules) error {
+	return withUniqueLock(ctx, r.db, unique, uniqueness.EntityAsset, func(tx *sqlx.Tx) error {
+		if err := checkUniqueAsset(ctx, tx, unique, asset); err != nil {
+			return err
+		}
+		if err := updateAsset(ctx, tx, asset); err != nil {
+			return err
+		}
+		return writeAssetProperties(ctx, tx, asset.ID, properties)
+	})
+}
+
+// updateAsset updates an asset using the given connection or transaction. An empty tag keeps
+// the current one.
+func updateAsset(ctx context.Context, q queryer, asset *models.Asset) error {
+	if asset.Tag != "" {
+		if taken, err := tagTaken(ctx, q, asset.Tag, asset.ID); err != nil {
+			return err
+		} else if taken {
+			return ErrDuplicateTag
+		}
+	}
+	query := `UPDATE assets SET asset_type_id = ?, tag = COALESCE(NULLIF(?, ''), tag), name = ?, model = ?, serial_number = ?, 
+			  order_no = ?, license_number = ?, notes = ?, purchased_at = ?, purchase_cost = ?,
+			  currency = NULLIF(?, ''), updated_at = NOW() 
 			  WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, asset.AssetTypeID, asset.Name, asset.Model,
-		asset.SerialNumber, asset.OrderNo, asset.LicenseNumber, asset.Notes, asset.PurchasedAt, asset.ID)
+	_, err := q.ExecContext(ctx, query, asset.AssetTypeID, asset.Tag, asset.Name, asset.Model,
+		asset.SerialNumber, asset.OrderNo, asset.LicenseNumber, asset.Notes, asset.PurchasedAt,
+		asset.PurchaseCost, asset.Currency, asset.ID)
+	if isDuplicateKey(err) {
+		return ErrDuplicateTag
+	}
 	return err
 }
 
@@ -150,24 +340,24 @@ func (r *AssetRepository) Delete(ctx context.Context, id int64) error {
 	return err
 }
 
-// Search searches assets by name, serial number, or model
+// Search searches assets by tag, name, serial number, or model
 func (r *AssetRepository) Search(ctx context.Context, term string) ([]models.Asset, error) {
 	var assets []models.Asset
 	searchTerm := "%" + term + "%"
-	query := `SELECT a.id, a.asset_type_id, a.name, 
+	query := `SELECT a.id, a.asset_type_id, COALESCE(a.tag, '') as tag, a.status, a.name, 
 			  COALESCE(a.model, '') as model, 
 			  COALESCE(a.serial_number, '') as serial_number, 
 			  COALESCE(a.order_no, '') as order_no, 
 			  COALESCE(a.license_number, '') as license_number, 
 			  COALESCE(a.notes, '') as notes, 
-			  a.purchased_at,
+			  a.purchased_at, a.purchase_cost, COALESCE(a.currency, '') as currency,
 			  a.created_at, a.updated_at, a.deleted_at,
 			  COALESCE(at.name, '') as asset_type_name
 			  FROM assets a
 			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
 			  WHERE a.deleted_at IS NULL 
-			  AND (a.name LIKE ? OR a.serial_number LIKE ? OR a.model LIKE ?)
+			  AND (a.tag LIKE ? OR a.name LIKE ? OR a.serial_number LIKE ? OR a.model LIKE ?)
 			  ORDER BY a.name`
-	err := r.db.SelectContext(ctx, &assets, query, searchTerm, searchTerm, searchTerm)
+	err := r.db.SelectContext(ctx, &assets, query, searchTerm, searchTerm, searchTerm, searchTerm)
 	return assets, err
 }
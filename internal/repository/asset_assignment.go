@@ -8,12 +8,17 @@ import (
 
 	"github.com/jmoiron/sqlx"
 
+	"assetManager/internal/lifecycle"
 	"assetManager/internal/models"
 )
 
 var (
 	ErrAssetAssignmentNotFound = errors.New("asset assignment not found")
 	ErrOverlappingAssignment   = errors.New("overlapping assignment exists")
+	ErrAssetNotAssignable      = errors.New("asset cannot be assigned in its current status")
+	ErrPersonInactive          = errors.New("person is inactive")
+	ErrAssignmentNotPending    = errors.New("assignment has already started")
+	ErrInvalidAssignmentEnd    = errors.New("assignment must end after it starts")
 )
 
 // AssetAssignmentRepository handles asset assignment data operations
@@ -29,9 +34,11 @@ func NewAssetAssignmentRepository(db *sqlx.DB) *AssetAssignmentRepository {
 // GetByID retrieves an asset assignment by ID
 func (r *AssetAssignmentRepository) GetByID(ctx context.Context, id int64) (*models.AssetAssignment, error) {
 	var aa models.AssetAssignment
-	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, aa.due_at, COALESCE(aa.notes, '') as notes,
+			  COALESCE(aa.return_condition, '') as return_condition, COALESCE(aa.return_notes, '') as return_notes,
 			  aa.created_at, aa.updated_at, aa.deleted_at,
-			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name
+			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock
 			  FROM asset_assignments aa
 			  LEFT JOIN assets a ON aa.asset_id = a.id
 			  LEFT JOIN persons p ON aa.person_id = p.id
@@ -48,7 +55,8 @@ func (r *AssetAssignmentRepository) GetCurrentByAssetID(ctx context.Context, ass
 	var aa models.AssetAssignment
 	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
 			  aa.created_at, aa.updated_at, aa.deleted_at,
-			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name
+			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock
 			  FROM asset_assignments aa
 			  LEFT JOIN assets a ON aa.asset_id = a.id
 			  LEFT JOIN persons p ON aa.person_id = p.id
@@ -66,9 +74,11 @@ func (r *AssetAssignmentRepository) GetCurrentByAssetID(ctx context.Context, ass
 // GetHistoryByAssetID retrieves assignment history for an asset
 func (r *AssetAssignmentRepository) GetHistoryByAssetID(ctx context.Context, assetID int64) ([]models.AssetAssignment, error) {
 	var aas []models.AssetAssignment
-	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, aa.due_at, COALESCE(aa.notes, '') as notes,
+			  COALESCE(aa.return_condition, '') as return_condition, COALESCE(aa.return_notes, '') as return_notes,
 			  aa.created_at, aa.updated_at, aa.deleted_at,
-			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name
+			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock
 			  FROM asset_assignments aa
 			  LEFT JOIN assets a ON aa.asset_id = a.id
 			  LEFT JOIN persons p ON aa.person_id = p.id
@@ -81,9 +91,11 @@ func (r *AssetAssignmentRepository) GetHistoryByAssetID(ctx context.Context, ass
 // GetByPersonID retrieves all assignments for a person
 func (r *AssetAssignmentRepository) GetByPersonID(ctx context.Context, personID int64) ([]models.AssetAssignment, error) {
 	var aas []models.AssetAssignment
-	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, aa.due_at, COALESCE(aa.notes, '') as notes,
+			  COALESCE(aa.return_condition, '') as return_condition, COALESCE(aa.return_notes, '') as return_notes,
 			  aa.created_at, aa.updated_at, aa.deleted_at,
-			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name
+			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock
 			  FROM asset_assignments aa
 			  LEFT JOIN assets a ON aa.asset_id = a.id
 			  LEFT JOIN persons p ON aa.person_id = p.id
@@ -93,12 +105,14 @@ func (r *AssetAssignmentRepository) GetByPersonID(ctx context.Context, personID
 	return aas, err
 }
 
-// GetCurrentByPersonID retrieves current assignments for a person
-func (r *AssetAssignmentRepository) GetCurrentByPersonID(ctx context.Context, personID int64) ([]models.AssetAssignment, error) {
+// GetCurrentByPersonID retrieves current assignments for a person, or those active at asOf if it
+// is not zero
+func (r *AssetAssignmentRepository) GetCurrentByPersonID(ctx context.Context, personID int64, asOf time.Time) ([]models.AssetAssignment, error) {
 	var aas []models.AssetAssignment
 	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
 			  aa.created_at, aa.updated_at, aa.deleted_at,
 			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock,
 			  COALESCE(at.name, '') as asset_type_name,
 			  COALESCE(a.model, '') as asset_model, COALESCE(a.serial_number, '') as asset_serial_number
 			  FROM asset_assignments aa
@@ -106,65 +120,452 @@ func (r *AssetAssignmentRepository) GetCurrentByPersonID(ctx context.Context, pe
 			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
 			  LEFT JOIN persons p ON aa.person_id = p.id
 			  WHERE aa.person_id = ? AND aa.deleted_at IS NULL
-			  AND aa.effective_from <= NOW()
-			  AND (aa.effective_to IS NULL OR aa.effective_to > NOW())
+			  AND aa.effective_from <= COALESCE(?, NOW())
+			  AND (aa.effective_to IS NULL OR aa.effective_to > COALESCE(?, NOW()))
 			  ORDER BY aa.effective_from DESC`
-	err := r.db.SelectContext(ctx, &aas, query, personID)
+	err := r.db.SelectContext(ctx, &aas, query, personID, asOfArg(asOf), asOfArg(asOf))
 	return aas, err
 }
 
-// CheckOverlap checks if there's an overlapping assignment for an asset
-func (r *AssetAssignmentRepository) CheckOverlap(ctx context.Context, assetID int64, from, to time.Time, excludeID int64) (bool, error) {
-	var count int
-	query := `SELECT COUNT(*) FROM asset_assignments 
-			  WHERE asset_id = ? AND deleted_at IS NULL AND id != ?
-			  AND effective_from < ? 
-			  AND (effective_to IS NULL OR effective_to > ?)`
-	err := r.db.GetContext(ctx, &count, query, assetID, excludeID, to, from)
-	return count > 0, err
+// CheckOverlap checks if an assignment from from until to, open-ended if to is null, overlaps
+// another assignment of an asset, including scheduled ones
+func (r *AssetAssignmentRepository) CheckOverlap(ctx context.Context, assetID int64, from time.Time, to models.NullTime, excludeID int64) (bool, error) {
+	return checkOverlap(ctx, r.db, assetID, from, to, excludeID)
 }
 
-// Create creates a new asset assignment
+// GetPending retrieves the scheduled assignments that have not started yet, soonest first
+func (r *AssetAssignmentRepository) GetPending(ctx context.Context) ([]models.AssetAssignment, error) {
+	var aas []models.AssetAssignment
+	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+			  aa.created_at, aa.updated_at, aa.deleted_at,
+			  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock,
+			  COALESCE(at.name, '') as asset_type_name,
+			  COALESCE(a.model, '') as asset_model, COALESCE(a.serial_number, '') as asset_serial_number
+			  FROM asset_assignments aa
+			  JOIN assets a ON aa.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  LEFT JOIN persons p ON aa.person_id = p.id
+			  WHERE aa.deleted_at IS NULL AND aa.effective_from > NOW()
+			  ORDER BY aa.effective_from, a.name`
+	err := r.db.SelectContext(ctx, &aas, query)
+	return aas, err
+}
+
+// GetUpcoming retrieves the changes of who holds which asset scheduled after from until until:
+// scheduled assignments, with the holder they take over from, and assignments that end with
+// nobody scheduled to take over
+func (r *AssetAssignmentRepository) GetUpcoming(ctx context.Context, from, until time.Time) ([]models.ScheduledChange, error) {
+	var changes []models.ScheduledChange
+	query := `SELECT CASE WHEN COALESCE(tp.is_stock, FALSE) THEN 'return' ELSE 'assignment' END as kind,
+			  aa.effective_from as effective_at, aa.id as assignment_id, aa.asset_id,
+			  COALESCE(a.tag, '') as asset_tag, COALESCE(a.name, '') as asset_name,
+			  prev.person_id as from_person_id, COALESCE(fp.name, '') as from_person_name,
+			  aa.person_id as to_person_id, COALESCE(tp.name, '') as to_person_name,
+			  COALESCE(aa.notes, '') as notes
+			  FROM asset_assignments aa
+			  JOIN assets a ON aa.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN persons tp ON aa.person_id = tp.id
+			  LEFT JOIN asset_assignments prev ON prev.asset_id = aa.asset_id AND prev.deleted_at IS NULL
+			      AND prev.effective_to = aa.effective_from
+			  LEFT JOIN persons fp ON prev.person_id = fp.id
+			  WHERE aa.deleted_at IS NULL AND aa.effective_from > ? AND aa.effective_from <= ?
+			  UNION ALL
+			  SELECT 'end' as kind,
+			  aa.effective_to as effective_at, aa.id as assignment_id, aa.asset_id,
+			  COALESCE(a.tag, '') as asset_tag, COALESCE(a.name, '') as asset_name,
+			  aa.person_id as from_person_id, COALESCE(p.name, '') as from_person_name,
+			  NULL as to_person_id, '' as to_person_name,
+			  COALESCE(aa.notes, '') as notes
+			  FROM asset_assignments aa
+			  JOIN assets a ON aa.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN persons p ON aa.person_id = p.id
+			  WHERE aa.deleted_at IS NULL AND aa.effective_to > ? AND aa.effective_to <= ?
+			  AND NOT EXISTS (SELECT 1 FROM asset_assignments n
+			                  WHERE n.asset_id = aa.asset_id AND n.deleted_at IS NULL AND n.effective_from = aa.effective_to)
+			  ORDER BY effective_at, asset_name`
+	err := r.db.SelectContext(ctx, &changes, query, from, until, from, until)
+	return changes, err
+}
+
+// Create creates a new asset assignment. Retired and disposed assets cannot be assigned, and
+// persons who left cannot be assigned anything. Assignments to persons fail with a
+// *ReservationConflictError while the asset is reserved.
 func (r *AssetAssignmentRepository) Create(ctx context.Context, aa *models.AssetAssignment) error {
-	// Check for overlapping assignments
-	toTime := time.Now().AddDate(100, 0, 0) // Far future if no end date
-	if aa.EffectiveTo.Valid {
-		toTime = aa.EffectiveTo.Time
+	return withAssetLock(ctx, r.db, aa.AssetID, func(tx *sqlx.Tx) error {
+		if err := checkAssignable(ctx, tx, aa.AssetID); err != nil {
+			return err
+		}
+		if err := checkPersonActive(ctx, tx, aa.PersonID); err != nil {
+			return err
+		}
+		if err := checkReserved(ctx, tx, aa, 0); err != nil {
+			return err
+		}
+		return createAssignment(ctx, tx, aa)
+	})
+}
+
+// Update updates the person, dates and notes of an existing asset assignment of an assignable
+// asset. The asset of an assignment never changes, so aa.AssetID is set to the stored one.
+func (r *AssetAssignmentRepository) Update(ctx context.Context, aa *models.AssetAssignment) error {
+	return r.withAssignmentLock(ctx, aa.ID, func(tx *sqlx.Tx, current models.AssetAssignment) error {
+		aa.AssetID = current.AssetID
+		aa.DueAt = current.DueAt
+		if err := checkAssignable(ctx, tx, aa.AssetID); err != nil {
+			return err
+		}
+		// Past assignments of persons who left can still be corrected, but not handed to them
+		if aa.PersonID != current.PersonID {
+			if err := checkPersonActive(ctx, tx, aa.PersonID); err != nil {
+				return err
+			}
+		}
+		if aa.EffectiveTo.Valid && !aa.EffectiveTo.Time.After(aa.EffectiveFrom.Time) {
+			return ErrInvalidAssignmentEnd
+		}
+
+		// Check for overlapping assignments (excluding this one)
+		overlap, err := checkOverlap(ctx, tx, aa.AssetID, aa.EffectiveFrom.Time, aa.EffectiveTo, aa.ID)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			return ErrOverlappingAssignment
+		}
+		if err := checkReserved(ctx, tx, aa, 0); err != nil {
+			return err
+		}
+
+		query := `UPDATE asset_assignments SET person_id = ?, effective_from = ?, effective_to = ?, 
+				  notes = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+		var effectiveTo interface{}
+		if aa.EffectiveTo.Valid {
+			effectiveTo = aa.EffectiveTo.Time
+		}
+		_, err = tx.ExecContext(ctx, query, aa.PersonID, aa.EffectiveFrom.Time, effectiveTo, aa.Notes, aa.ID)
+		return err
+	})
+}
+
+// CancelPending removes an assignment that has not started yet and gives the time it covered back
+// to the assignment it would have taken over from. It returns the cancelled assignment and the
+// extended assignment as it was before (nil if there was none).
+func (r *AssetAssignmentRepository) CancelPending(ctx context.Context, id int64) (*models.AssetAssignment, *models.AssetAssignment, error) {
+	cancelled, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
 	}
-	overlap, err := r.CheckOverlap(ctx, aa.AssetID, aa.EffectiveFrom.Time, toTime, 0)
+
+	var previous *models.AssetAssignment
+	err = withAssetLock(ctx, r.db, cancelled.AssetID, func(tx *sqlx.Tx) error {
+		var pending models.AssetAssignment
+		query := `SELECT id, asset_id, person_id, effective_from, effective_to, COALESCE(notes, '') as notes,
+				  created_at, updated_at, deleted_at
+				  FROM asset_assignments WHERE id = ? AND deleted_at IS NULL`
+		err := tx.GetContext(ctx, &pending, query, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssetAssignmentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if !pending.EffectiveFrom.Time.After(time.Now()) {
+			return ErrAssignmentNotPending
+		}
+		previous, err = cancelPending(ctx, tx, pending)
+		return err
+	})
 	if err != nil {
+		return nil, nil, err
+	}
+	return cancelled, previous, nil
+}
+
+// cancelPending removes an assignment that has not started yet in a transaction holding the lock
+// on its asset and extends the assignment it would have taken over from to where it would have
+// ended. It returns the extended assignment as it was before, nil if there was none.
+func cancelPending(ctx context.Context, tx *sqlx.Tx, pending models.AssetAssignment) (*models.AssetAssignment, error) {
+	var previous *models.AssetAssignment
+	var before models.AssetAssignment
+	query := `SELECT id, asset_id, person_id, effective_from, effective_to, COALESCE(notes, '') as notes,
+			  created_at, updated_at, deleted_at
+			  FROM asset_assignments
+			  WHERE asset_id = ? AND deleted_at IS NULL AND effective_to = ?
+			  ORDER BY effective_from DESC LIMIT 1`
+	err := tx.GetContext(ctx, &before, query, pending.AssetID, pending.EffectiveFrom.Time)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	default:
+		previous = &before
+	}
+
+	query = `UPDATE asset_assignments SET deleted_at = NOW() WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, pending.ID); err != nil {
+		return nil, err
+	}
+	if previous != nil {
+		var effectiveTo interface{}
+		if pending.EffectiveTo.Valid {
+			effectiveTo = pending.EffectiveTo.Time
+		}
+		query = `UPDATE asset_assignments SET effective_to = ?, updated_at = NOW() WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, effectiveTo, previous.ID); err != nil {
+			return nil, err
+		}
+	}
+	return previous, nil
+}
+
+// EndAssignment ends an assignment by setting the effective_to date, which must be after the
+// assignment starts and must not run into the next assignment of the asset
+func (r *AssetAssignmentRepository) EndAssignment(ctx context.Context, id int64, endDate time.Time) error {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	endDate = endDate.Truncate(time.Second)
+
+	return r.withAssignmentLock(ctx, id, func(tx *sqlx.Tx, current models.AssetAssignment) error {
+		if !endDate.After(current.EffectiveFrom.Time) {
+			return ErrInvalidAssignmentEnd
+		}
+		overlap, err := checkOverlap(ctx, tx, current.AssetID, current.EffectiveFrom.Time, models.NewNullTime(endDate), id)
+		if err != nil {
+			return err
+		}
+		if overlap {
+			return ErrOverlappingAssignment
+		}
+		return endAssignment(ctx, tx, id, endDate)
+	})
+}
+
+// Delete soft-deletes an asset assignment
+func (r *AssetAssignmentRepository) Delete(ctx context.Context, id int64) error {
+	return r.withAssignmentLock(ctx, id, func(tx *sqlx.Tx, current models.AssetAssignment) error {
+		query := `UPDATE asset_assignments SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+		_, err := tx.ExecContext(ctx, query, id)
 		return err
+	})
+}
+
+// withAssignmentLock runs fn in a transaction that holds the lock on the asset of an assignment,
+// passing the assignment as stored once the lock is held
+func (r *AssetAssignmentRepository) withAssignmentLock(ctx context.Context, id int64, fn func(tx *sqlx.Tx, current models.AssetAssignment) error) error {
+	// The asset of an assignment never changes, so it can be read before taking the lock
+	var assetID int64
+	err := r.db.GetContext(ctx, &assetID, `SELECT asset_id FROM asset_assignments WHERE id = ? AND deleted_at IS NULL`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAssetAssignmentNotFound
 	}
-	if overlap {
-		return ErrOverlappingAssignment
+	if err != nil {
+		return err
 	}
 
-	query := `INSERT INTO asset_assignments (asset_id, person_id, effective_from, effective_to, notes) 
-			  VALUES (?, ?, ?, ?, ?)`
-	var effectiveTo interface{}
-	if aa.EffectiveTo.Valid {
-		effectiveTo = aa.EffectiveTo.Time
+	return withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		var current models.AssetAssignment
+		query := `SELECT id, asset_id, person_id, effective_from, effective_to, due_at, COALESCE(notes, '') as notes,
+				  created_at, updated_at, deleted_at
+				  FROM asset_assignments WHERE id = ? AND deleted_at IS NULL`
+		err := tx.GetContext(ctx, &current, query, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssetAssignmentNotFound
+		}
+		if err != nil {
+			return err
+		}
+		return fn(tx, current)
+	})
+}
+
+// AssignAsset assigns an asset to a person, ending the assignment active at the effective date.
+// An effective date in the future schedules the change, and if another change is already
+// scheduled after the effective date the new assignment lasts until then. All steps run in one
+// transaction holding a lock on the asset row, so concurrent reassignments of the same asset are
+// serialized. It returns the previous assignment as it was before being
+// ended (nil if there was none) and the new assignment. Retired and disposed assets cannot be
+// assigned, persons who left cannot be assigned anything, and assets cannot be assigned over a
+// reservation, which fails with a *ReservationConflictError. Assets in stock given to a person
+// now are deployed where the transitions allow it; changedBy carries the user of that status
+// change, which is returned, nil if the status stayed.
+func (r *AssetAssignmentRepository) AssignAsset(ctx context.Context, assetID, personID int64, notes string, effectiveDate time.Time, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.AssetAssignment, *models.AssetAssignment, *models.AssetStatusChange, error) {
+	return r.reassign(ctx, assetID, personID, notes, effectiveDate, true, transitions, changedBy)
+}
+
+// UnassignAsset returns an asset to a stock pool, ending the assignment active at the effective
+// date, which may be in the future like for AssignAsset. Unlike AssignAsset it accepts assets in
+// any status, so retired assets can be taken back. Deployed assets returned now are back in stock
+// where the transitions allow it.
+func (r *AssetAssignmentRepository) UnassignAsset(ctx context.Context, assetID, stockPoolID int64, notes string, effectiveDate time.Time, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.AssetAssignment, *models.AssetAssignment, *models.AssetStatusChange, error) {
+	return r.reassign(ctx, assetID, stockPoolID, notes, effectiveDate, false, transitions, changedBy)
+}
+
+// reassign ends the assignment active at the effective date and creates a new one, checking the
+// asset status and the person first if checkStatus is set, and moves the asset to the status for
+// its new holder if the change is not scheduled
+func (r *AssetAssignmentRepository) reassign(ctx context.Context, assetID, personID int64, notes string, effectiveDate time.Time, checkStatus bool, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.AssetAssignment, *models.AssetAssignment, *models.AssetStatusChange, error) {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	now := time.Now().Truncate(time.Second)
+	effectiveDate = effectiveDate.Truncate(time.Second)
+
+	var previous *models.AssetAssignment
+	var change *models.AssetStatusChange
+	aa := &models.AssetAssignment{
+		AssetID:       assetID,
+		PersonID:      personID,
+		EffectiveFrom: models.NewNullTime(effectiveDate),
+		Notes:         notes,
 	}
-	result, err := r.db.ExecContext(ctx, query, aa.AssetID, aa.PersonID, aa.EffectiveFrom.Time, effectiveTo, aa.Notes)
+
+	err := withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		if checkStatus {
+			if err := checkAssignable(ctx, tx, assetID); err != nil {
+				return err
+			}
+			if err := checkPersonActive(ctx, tx, personID); err != nil {
+				return err
+			}
+		}
+
+		// End the assignment active at the effective date, if any
+		var current models.AssetAssignment
+		query := `SELECT id, asset_id, person_id, effective_from, effective_to, COALESCE(notes, '') as notes,
+				  created_at, updated_at, deleted_at
+				  FROM asset_assignments
+				  WHERE asset_id = ? AND deleted_at IS NULL
+				  AND effective_from <= ?
+				  AND (effective_to IS NULL OR effective_to > ?)
+				  ORDER BY effective_from DESC LIMIT 1`
+		err := tx.GetContext(ctx, &current, query, assetID, effectiveDate, effectiveDate)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		case current.EffectiveFrom.Time.Equal(effectiveDate):
+			// Another change starts at the same time
+			return ErrOverlappingAssignment
+		default:
+			previous = &current
+			if err := endAssignment(ctx, tx, current.ID, effectiveDate); err != nil {
+				return err
+			}
+		}
+
+		// The new assignment lasts until the next scheduled change, if any
+		if aa.EffectiveTo, err = nextScheduled(ctx, tx, assetID, effectiveDate); err != nil {
+			return err
+		}
+		if err := checkReserved(ctx, tx, aa, 0); err != nil {
+			return err
+		}
+		if err := createAssignment(ctx, tx, aa); err != nil {
+			return err
+		}
+		change, err = updateHolderStatus(ctx, tx, aa, now, transitions, changedBy)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return previous, aa, change, nil
+}
+
+// queryer is implemented by both *sqlx.DB and *sqlx.Tx
+type queryer interface {
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// withAssetLock runs fn in a transaction that holds a row lock on the asset.
+// Every statement that changes the assignments or placements of an asset must go through it.
+func withAssetLock(ctx context.Context, db *sqlx.DB, assetID int64, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	id, err := result.LastInsertId()
+	defer tx.Rollback()
+
+	var lockedID int64
+	err = tx.GetContext(ctx, &lockedID, `SELECT id FROM assets WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAssetNotFound
+	}
 	if err != nil {
 		return err
 	}
-	aa.ID = id
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkAssignable returns ErrAssetNotAssignable if the status of the asset does not allow assignments
+func checkAssignable(ctx context.Context, q queryer, assetID int64) error {
+	var status models.AssetStatus
+	if err := q.GetContext(ctx, &status, `SELECT status FROM assets WHERE id = ?`, assetID); err != nil {
+		return err
+	}
+	if !status.IsAssignable() {
+		return ErrAssetNotAssignable
+	}
 	return nil
 }
 
-// Update updates an existing asset assignment
-func (r *AssetAssignmentRepository) Update(ctx context.Context, aa *models.AssetAssignment) error {
-	// Check for overlapping assignments (excluding this one)
-	toTime := time.Now().AddDate(100, 0, 0)
-	if aa.EffectiveTo.Valid {
-		toTime = aa.EffectiveTo.Time
+// checkPersonActive returns ErrPersonInactive if the person was offboarded
+func checkPersonActive(ctx context.Context, q queryer, personID int64) error {
+	var active bool
+	err := q.GetContext(ctx, &active, `SELECT is_active FROM persons WHERE id = ? AND deleted_at IS NULL`, personID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPersonNotFound
 	}
-	overlap, err := r.CheckOverlap(ctx, aa.AssetID, aa.EffectiveFrom.Time, toTime, aa.ID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrPersonInactive
+	}
+	return nil
+}
+
+// checkOverlap checks if an assignment from from until to, open-ended if to is null, overlaps
+// another assignment of an asset. Open-ended assignments overlap every later assignment,
+// including scheduled ones however far in the future they start.
+func checkOverlap(ctx context.Context, q queryer, assetID int64, from time.Time, to models.NullTime, excludeID int64) (bool, error) {
+	var end interface{}
+	if to.Valid {
+		end = to.Time
+	}
+	var count int
+	query := `SELECT COUNT(*) FROM asset_assignments 
+			  WHERE asset_id = ? AND deleted_at IS NULL AND id != ?
+			  AND (? IS NULL OR effective_from < ?) 
+			  AND (effective_to IS NULL OR effective_to > ?)`
+	err := q.GetContext(ctx, &count, query, assetID, excludeID, end, end, from)
+	return count > 0, err
+}
+
+// nextScheduled returns the start of the first scheduled assignment of an asset after the given
+// time, or null if none is scheduled
+func nextScheduled(ctx context.Context, q queryer, assetID int64, after time.Time) (models.NullTime, error) {
+	var next time.Time
+	query := `SELECT effective_from FROM asset_assignments
+			  WHERE asset_id = ? AND deleted_at IS NULL AND effective_from > ? AND effective_from > NOW()
+			  ORDER BY effective_from LIMIT 1`
+	err := q.GetContext(ctx, &next, query, assetID, after)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.NullTime{}, nil
+	}
+	if err != nil {
+		return models.NullTime{}, err
+	}
+	return models.NewNullTime(next), nil
+}
+
+// createAssignment checks for overlaps and inserts the assignment
+func createAssignment(ctx context.Context, q queryer, aa *models.AssetAssignment) error {
+	overlap, err := checkOverlap(ctx, q, aa.AssetID, aa.EffectiveFrom.Time, aa.EffectiveTo, 0)
 	if err != nil {
 		return err
 	}
@@ -172,47 +573,31 @@ func (r *AssetAssignmentRepository) Update(ctx context.Context, aa *models.Asset
 		return ErrOverlappingAssignment
 	}
 
-	query := `UPDATE asset_assignments SET person_id = ?, effective_from = ?, effective_to = ?, 
-			  notes = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL`
-	var effectiveTo interface{}
+	query := `INSERT INTO asset_assignments (asset_id, person_id, effective_from, effective_to, due_at, notes) 
+			  VALUES (?, ?, ?, ?, ?, ?)`
+	var effectiveTo, dueAt interface{}
 	if aa.EffectiveTo.Valid {
 		effectiveTo = aa.EffectiveTo.Time
 	}
-	_, err = r.db.ExecContext(ctx, query, aa.PersonID, aa.EffectiveFrom.Time, effectiveTo, aa.Notes, aa.ID)
-	return err
+	if aa.DueAt.Valid {
+		dueAt = aa.DueAt.Time
+	}
+	result, err := q.ExecContext(ctx, query, aa.AssetID, aa.PersonID, aa.EffectiveFrom.Time, effectiveTo, dueAt, aa.Notes)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	aa.ID = id
+	return nil
 }
 
-// EndAssignment ends an assignment by setting the effective_to date
-func (r *AssetAssignmentRepository) EndAssignment(ctx context.Context, id int64, endDate time.Time) error {
+// endAssignment sets the effective_to date of an assignment
+func endAssignment(ctx context.Context, q queryer, id int64, endDate time.Time) error {
 	query := `UPDATE asset_assignments SET effective_to = ?, updated_at = NOW() 
 			  WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, endDate, id)
-	return err
-}
-
-// Delete soft-deletes an asset assignment
-func (r *AssetAssignmentRepository) Delete(ctx context.Context, id int64) error {
-	query := `UPDATE asset_assignments SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := q.ExecContext(ctx, query, endDate, id)
 	return err
 }
-
-// AssignAsset assigns an asset to a person, ending any current assignment
-func (r *AssetAssignmentRepository) AssignAsset(ctx context.Context, assetID, personID int64, notes string, effectiveDate time.Time) error {
-	// End current assignment if exists
-	current, err := r.GetCurrentByAssetID(ctx, assetID)
-	if err == nil && current != nil {
-		if err := r.EndAssignment(ctx, current.ID, effectiveDate); err != nil {
-			return err
-		}
-	}
-
-	// Create new assignment
-	aa := &models.AssetAssignment{
-		AssetID:       assetID,
-		PersonID:      personID,
-		EffectiveFrom: models.NewNullTime(effectiveDate),
-		Notes:         notes,
-	}
-	return r.Create(ctx, aa)
-}
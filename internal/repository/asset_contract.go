@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+var ErrAssetContractNotFound = errors.New("asset contract not found")
+
+// assetContractColumns selects a contract joined with the asset name
+const assetContractColumns = `ac.id, ac.asset_id, ac.kind, COALESCE(ac.vendor, '') as vendor,
+	COALESCE(ac.contract_number, '') as contract_number, ac.starts_at, ac.ends_at,
+	COALESCE(ac.notes, '') as notes, ac.created_at, ac.updated_at, ac.deleted_at,
+	COALESCE(a.name, '') as asset_name`
+
+// AssetContractRepository handles warranty, support contract and lease data operations
+type AssetContractRepository struct {
+	db *sqlx.DB
+}
+
+// NewAssetContractRepository creates a new asset contract repository
+func NewAssetContractRepository(db *sqlx.DB) *AssetContractRepository {
+	return &AssetContractRepository{db: db}
+}
+
+// GetByID retrieves a contract by ID
+func (r *AssetContractRepository) GetByID(ctx context.Context, id int64) (*models.AssetContract, error) {
+	var contract models.AssetContract
+	query := `SELECT ` + assetContractColumns + `
+			  FROM asset_contracts ac
+			  LEFT JOIN assets a ON ac.asset_id = a.id
+			  WHERE ac.id = ? AND ac.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &contract, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetContractNotFound
+	}
+	return &contract, err
+}
+
+// GetByAssetID retrieves the contracts of an asset, latest ending first
+func (r *AssetContractRepository) GetByAssetID(ctx context.Context, assetID int64) ([]models.AssetContract, error) {
+	var contracts []models.AssetContract
+	query := `SELECT ` + assetContractColumns + `
+			  FROM asset_contracts ac
+			  LEFT JOIN assets a ON ac.asset_id = a.id
+			  WHERE ac.asset_id = ? AND ac.deleted_at IS NULL
+			  ORDER BY ac.ends_at DESC`
+	err := r.db.SelectContext(ctx, &contracts, query, assetID)
+	return contracts, err
+}
+
+// GetExpiring retrieves the contracts of active assets ending between from and to inclusive,
+// soonest first, with the number of days left as of from
+func (r *AssetContractRepository) GetExpiring(ctx context.Context, from, to time.Time) ([]models.ExpiringContract, error) {
+	var contracts []models.ExpiringContract
+	query := `SELECT ` + assetContractColumns + `,
+			  DATEDIFF(ac.ends_at, ?) as days_left, ca.raised_at
+			  FROM asset_contracts ac
+			  JOIN assets a ON ac.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN contract_alerts ca ON ca.contract_id = ac.id AND ca.ends_at = ac.ends_at
+			  WHERE ac.deleted_at IS NULL AND ac.ends_at BETWEEN ? AND ?
+			  ORDER BY ac.ends_at, a.name`
+	fromDate, toDate := from.Format("2006-01-02"), to.Format("2006-01-02")
+	err := r.db.SelectContext(ctx, &contracts, query, fromDate, fromDate, toDate)
+	return contracts, err
+}
+
+// RaiseExpiryAlerts records an alert for every contract of an active asset ending between from
+// and to that has none yet for its current end date. It returns the number of new alerts.
+func (r *AssetContractRepository) RaiseExpiryAlerts(ctx context.Context, from, to time.Time) (int64, error) {
+	query := `INSERT IGNORE INTO contract_alerts (contract_id, ends_at)
+			  SELECT ac.id, ac.ends_at
+			  FROM asset_contracts ac
+			  JOIN assets a ON ac.asset_id = a.id AND a.deleted_at IS NULL
+			  WHERE ac.deleted_at IS NULL AND ac.ends_at BETWEEN ? AND ?`
+	result, err := r.db.ExecContext(ctx, query, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Create creates a new contract
+func (r *AssetContractRepository) Create(ctx context.Context, contract *models.AssetContract) error {
+	query := `INSERT INTO asset_contracts (asset_id, kind, vendor, contract_number, starts_at, ends_at, notes)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, contract.AssetID, contract.Kind, contract.Vendor,
+		contract.ContractNumber, contract.StartsAt, contract.EndsAt, contract.Notes)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	contract.ID = id
+	return nil
+}
+
+// Update updates an existing contract
+func (r *AssetContractRepository) Update(ctx context.Context, contract *models.AssetContract) error {
+	query := `UPDATE asset_contracts SET kind = ?, vendor = ?, contract_number = ?, starts_at = ?, ends_at = ?,
+			  notes = ?, updated_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, contract.Kind, contract.Vendor, contract.ContractNumber,
+		contract.StartsAt, contract.EndsAt, contract.Notes, contract.ID)
+	return err
+}
+
+// Delete soft-deletes a contract
+func (r *AssetContractRepository) Delete(ctx context.Context, id int64) error {
+	query := `UPDATE asset_contracts SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
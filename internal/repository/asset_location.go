@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+var (
+	ErrAssetLocationNotFound = errors.New("asset location not found")
+	ErrOverlappingPlacement  = errors.New("overlapping placement exists")
+)
+
+// assetLocationColumns selects a placement joined with the asset and location names
+const assetLocationColumns = `al.id, al.asset_id, al.location_id, al.effective_from, al.effective_to,
+	COALESCE(al.notes, '') as notes, al.created_at, al.updated_at, al.deleted_at,
+	COALESCE(a.name, '') as asset_name, COALESCE(l.name, '') as location_name`
+
+// AssetLocationRepository handles asset placement data operations
+type AssetLocationRepository struct {
+	db *sqlx.DB
+}
+
+// NewAssetLocationRepository creates a new asset location repository
+func NewAssetLocationRepository(db *sqlx.DB) *AssetLocationRepository {
+	return &AssetLocationRepository{db: db}
+}
+
+// GetCurrentByAssetID retrieves the current placement of an asset
+func (r *AssetLocationRepository) GetCurrentByAssetID(ctx context.Context, assetID int64) (*models.AssetLocation, error) {
+	var al models.AssetLocation
+	query := `SELECT ` + assetLocationColumns + `
+			  FROM asset_locations al
+			  LEFT JOIN assets a ON al.asset_id = a.id
+			  LEFT JOIN locations l ON al.location_id = l.id
+			  WHERE al.asset_id = ? AND al.deleted_at IS NULL
+			  AND al.effective_from <= NOW()
+			  AND (al.effective_to IS NULL OR al.effective_to > NOW())
+			  ORDER BY al.effective_from DESC LIMIT 1`
+	err := r.db.GetContext(ctx, &al, query, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetLocationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	placements := []models.AssetLocation{al}
+	if err := r.setPaths(ctx, placements); err != nil {
+		return nil, err
+	}
+	return &placements[0], nil
+}
+
+// GetHistoryByAssetID retrieves the placement history of an asset, latest first
+func (r *AssetLocationRepository) GetHistoryByAssetID(ctx context.Context, assetID int64) ([]models.AssetLocation, error) {
+	var placements []models.AssetLocation
+	query := `SELECT ` + assetLocationColumns + `
+			  FROM asset_locations al
+			  LEFT JOIN assets a ON al.asset_id = a.id
+			  LEFT JOIN locations l ON al.location_id = l.id
+			  WHERE al.asset_id = ? AND al.deleted_at IS NULL
+			  ORDER BY al.effective_from DESC`
+	if err := r.db.SelectContext(ctx, &placements, query, assetID); err != nil {
+		return nil, err
+	}
+	return placements, r.setPaths(ctx, placements)
+}
+
+// GetCurrentByLocationID retrieves the current placements at a location. With includeSubtree
+// placements at all locations below it are included as well.
+func (r *AssetLocationRepository) GetCurrentByLocationID(ctx context.Context, locationID int64, includeSubtree bool) ([]models.AssetLocation, error) {
+	locationFilter := "al.location_id = ?"
+	if includeSubtree {
+		locationFilter = "al.location_id IN (" + locationSubtreeQuery + ")"
+	}
+
+	var placements []models.AssetLocation
+	query := `SELECT ` + assetLocationColumns + `
+			  FROM asset_locations al
+			  JOIN assets a ON al.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN locations l ON al.location_id = l.id
+			  WHERE ` + locationFilter + ` AND al.deleted_at IS NULL
+			  AND al.effective_from <= NOW()
+			  AND (al.effective_to IS NULL OR al.effective_to > NOW())
+			  ORDER BY a.name`
+	if err := r.db.SelectContext(ctx, &placements, query, locationID); err != nil {
+		return nil, err
+	}
+	return placements, r.setPaths(ctx, placements)
+}
+
+// MoveAsset places an asset at a location, ending the placement active at the effective date.
+// Both steps run in one transaction holding a lock on the asset row. It returns the previous
+// placement as it was before being ended (nil if there was none) and the new placement.
+func (r *AssetLocationRepository) MoveAsset(ctx context.Context, assetID, locationID int64, notes string, effectiveDate time.Time) (*models.AssetLocation, *models.AssetLocation, error) {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	effectiveDate = effectiveDate.Truncate(time.Second)
+
+	var previous *models.AssetLocation
+	al := &models.AssetLocation{
+		AssetID:       assetID,
+		LocationID:    locationID,
+		EffectiveFrom: models.NewNullTime(effectiveDate),
+		Notes:         notes,
+	}
+
+	err := withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		if err := checkLocationExists(ctx, tx, locationID); err != nil {
+			return err
+		}
+
+		var err error
+		if previous, err = endPlacementAt(ctx, tx, assetID, effectiveDate); err != nil {
+			return err
+		}
+		return createPlacement(ctx, tx, al)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return previous, al, nil
+}
+
+// RemoveAsset ends the placement of an asset active at the effective date, leaving it without a
+// location. It returns the placement as it was before being ended.
+func (r *AssetLocationRepository) RemoveAsset(ctx context.Context, assetID int64, effectiveDate time.Time) (*models.AssetLocation, error) {
+	effectiveDate = effectiveDate.Truncate(time.Second)
+
+	var previous *models.AssetLocation
+	err := withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		var err error
+		previous, err = endPlacementAt(ctx, tx, assetID, effectiveDate)
+		if err == nil && previous == nil {
+			return ErrAssetLocationNotFound
+		}
+		return err
+	})
+	return previous, err
+}
+
+// setPaths fills in the location path of each placement
+func (r *AssetLocationRepository) setPaths(ctx context.Context, placements []models.AssetLocation) error {
+	if len(placements) == 0 {
+		return nil
+	}
+	paths, err := allLocationPaths(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	for i := range placements {
+		placements[i].LocationPath = paths[placements[i].LocationID]
+	}
+	return nil
+}
+
+// endPlacementAt ends the placement of an asset active at the given date, returning it as it
+// was before being ended, or nil if there was none
+func endPlacementAt(ctx context.Context, q queryer, assetID int64, date time.Time) (*models.AssetLocation, error) {
+	var current models.AssetLocation
+	query := `SELECT id, asset_id, location_id, effective_from, effective_to, COALESCE(notes, '') as notes,
+			  created_at, updated_at, deleted_at
+			  FROM asset_locations
+			  WHERE asset_id = ? AND deleted_at IS NULL
+			  AND effective_from <= ?
+			  AND (effective_to IS NULL OR effective_to > ?)
+			  ORDER BY effective_from DESC LIMIT 1`
+	err := q.GetContext(ctx, &current, query, assetID, date, date)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE asset_locations SET effective_to = ?, updated_at = NOW()
+			 WHERE id = ? AND deleted_at IS NULL`
+	if _, err := q.ExecContext(ctx, query, date, current.ID); err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// createPlacement checks for overlaps and inserts an open-ended placement
+func createPlacement(ctx context.Context, q queryer, al *models.AssetLocation) error {
+	var count int
+	query := `SELECT COUNT(*) FROM asset_locations
+			  WHERE asset_id = ? AND deleted_at IS NULL
+			  AND (effective_to IS NULL OR effective_to > ?)`
+	if err := q.GetContext(ctx, &count, query, al.AssetID, al.EffectiveFrom.Time); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrOverlappingPlacement
+	}
+
+	query = `INSERT INTO asset_locations (asset_id, location_id, effective_from, notes) VALUES (?, ?, ?, ?)`
+	result, err := q.ExecContext(ctx, query, al.AssetID, al.LocationID, al.EffectiveFrom.Time, al.Notes)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	al.ID = id
+	return nil
+}
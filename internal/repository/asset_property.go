@@ -50,10 +50,43 @@ func (r *AssetPropertyRepository) GetByAssetID(ctx context.Context, assetID int6
 	return aps, err
 }
 
+// GetByAssetAndPropertyID retrieves the value of a property for an asset
+func (r *AssetPropertyRepository) GetByAssetAndPropertyID(ctx context.Context, assetID, propertyID int64) (*models.AssetProperty, error) {
+	var ap models.AssetProperty
+	query := `SELECT ap.id, ap.asset_id, ap.property_id, COALESCE(ap.value, '') as value, ap.created_at, ap.updated_at, ap.deleted_at,
+			  COALESCE(p.name, '') as property_name, COALESCE(p.data_type, 'string') as data_type
+			  FROM assets_properties ap
+			  LEFT JOIN properties p ON ap.property_id = p.id
+			  WHERE ap.asset_id = ? AND ap.property_id = ? AND ap.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &ap, query, assetID, propertyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetPropertyNotFound
+	}
+	return &ap, err
+}
+
+// GetAllValues retrieves every stored property value with its property definition
+func (r *AssetPropertyRepository) GetAllValues(ctx context.Context) ([]models.CustomValue, error) {
+	var values []models.CustomValue
+	query := `SELECT ap.id, ap.asset_id as owner_id, ap.property_id as field_id, COALESCE(ap.value, '') as value,
+			  p.name as field_name, p.data_type, COALESCE(p.enum_options, '') as enum_options
+			  FROM assets_properties ap
+			  JOIN properties p ON ap.property_id = p.id
+			  WHERE ap.deleted_at IS NULL
+			  ORDER BY ap.asset_id, p.name`
+	err := r.db.SelectContext(ctx, &values, query)
+	return values, err
+}
+
 // Create creates a new asset property
 func (r *AssetPropertyRepository) Create(ctx context.Context, ap *models.AssetProperty) error {
+	return createAssetProperty(ctx, r.db, ap)
+}
+
+// createAssetProperty inserts an asset property using the given connection or transaction
+func createAssetProperty(ctx context.Context, q queryer, ap *models.AssetProperty) error {
 	query := `INSERT INTO assets_properties (asset_id, property_id, value) VALUES (?, ?, ?)`
-	result, err := r.db.ExecContext(ctx, query, ap.AssetID, ap.PropertyID, ap.Value)
+	result, err := q.ExecContext(ctx, query, ap.AssetID, ap.PropertyID, ap.Value)
 	if err != nil {
 		return err
 	}
@@ -89,16 +122,43 @@ func (r *AssetPropertyRepository) DeleteByAssetID(ctx context.Context, assetID i
 
 // Upsert creates or updates an asset property
 func (r *AssetPropertyRepository) Upsert(ctx context.Context, ap *models.AssetProperty) error {
+	return upsertAssetProperty(ctx, r.db, ap)
+}
+
+// upsertAssetProperty creates or updates an asset property using the given connection or transaction
+func upsertAssetProperty(ctx context.Context, q queryer, ap *models.AssetProperty) error {
 	// Check if exists
 	var existing models.AssetProperty
 	query := `SELECT id FROM assets_properties WHERE asset_id = ? AND property_id = ? AND deleted_at IS NULL`
-	err := r.db.GetContext(ctx, &existing, query, ap.AssetID, ap.PropertyID)
+	err := q.GetContext(ctx, &existing, query, ap.AssetID, ap.PropertyID)
 	if errors.Is(err, sql.ErrNoRows) {
-		return r.Create(ctx, ap)
+		return createAssetProperty(ctx, q, ap)
 	}
 	if err != nil {
 		return err
 	}
 	ap.ID = existing.ID
-	return r.Update(ctx, ap)
+	query = `UPDATE assets_properties SET value = ?, updated_at = NOW() 
+			  WHERE id = ? AND deleted_at IS NULL`
+	_, err = q.ExecContext(ctx, query, ap.Value, ap.ID)
+	return err
+}
+
+// writeAssetProperties stores property values of an asset; empty values clear the property
+func writeAssetProperties(ctx context.Context, q queryer, assetID int64, aps []models.AssetProperty) error {
+	for i := range aps {
+		ap := &aps[i]
+		ap.AssetID = assetID
+		if ap.Value == "" {
+			query := `UPDATE assets_properties SET deleted_at = NOW() WHERE asset_id = ? AND property_id = ? AND deleted_at IS NULL`
+			if _, err := q.ExecContext(ctx, query, assetID, ap.PropertyID); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := upsertAssetProperty(ctx, q, ap); err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+)
+
+// ChangeStatus moves an asset to change.ToStatus if the transitions allow it and records the
+// transition. The asset row is locked while its current status is checked, so concurrent changes
+// are serialized. On success change holds the previous status and the time of the change.
+func (r *AssetRepository) ChangeStatus(ctx context.Context, change *models.AssetStatusChange, transitions lifecycle.Transitions) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current models.AssetStatus
+	err = tx.GetContext(ctx, &current, `SELECT status FROM assets WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, change.AssetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAssetNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if err := transitions.Check(current, change.ToStatus); err != nil {
+		return err
+	}
+
+	query := `UPDATE assets SET status = ?, updated_at = NOW() WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, change.ToStatus, change.AssetID); err != nil {
+		return err
+	}
+	change.FromStatus = current
+	if err := createStatusChange(ctx, tx, change); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetStatusHistory retrieves the status transitions of an asset, most recent first
+func (r *AssetRepository) GetStatusHistory(ctx context.Context, assetID int64) ([]models.AssetStatusChange, error) {
+	var changes []models.AssetStatusChange
+	query := `SELECT id, asset_id, COALESCE(from_status, '') as from_status, to_status, COALESCE(reason, '') as reason,
+			  user_id, username, changed_at
+			  FROM asset_status_history
+			  WHERE asset_id = ?
+			  ORDER BY changed_at DESC, id DESC`
+	err := r.db.SelectContext(ctx, &changes, query, assetID)
+	return changes, err
+}
+
+// createStatusChange inserts a status history entry using the given connection or transaction
+func createStatusChange(ctx context.Context, q queryer, change *models.AssetStatusChange) error {
+	change.ChangedAt = time.Now().Truncate(time.Second)
+	var fromStatus interface{}
+	if change.FromStatus != "" {
+		fromStatus = change.FromStatus
+	}
+	query := `INSERT INTO asset_status_history (asset_id, from_status, to_status, reason, user_id, username, changed_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	result, err := q.ExecContext(ctx, query, change.AssetID, fromStatus, change.ToStatus, change.Reason,
+		change.UserID, change.Username, change.ChangedAt)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	change.ID = id
+	return nil
+}
+
+// holderStatus is the status an asset passing to a new holder moves to: assets in stock given to a
+// person are deployed and deployed assets given to a stock pool are back in stock
+func holderStatus(current models.AssetStatus, toStock bool) models.AssetStatus {
+	switch {
+	case !toStock && current == models.AssetStatusInStock:
+		return models.AssetStatusDeployed
+	case toStock && current == models.AssetStatusDeployed:
+		return models.AssetStatusInStock
+	default:
+		return current
+	}
+}
+
+// updateHolderStatus moves the asset of an assignment starting by now to the status for its new
+// holder where the transitions allow it. Scheduled assignments leave the status as it is. It
+// returns the recorded status change, nil if the status stayed.
+func updateHolderStatus(ctx context.Context, tx *sqlx.Tx, aa *models.AssetAssignment, now time.Time, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.AssetStatusChange, error) {
+	if aa.EffectiveFrom.Time.After(now) {
+		return nil, nil
+	}
+	var holder struct {
+		Status  models.AssetStatus `db:"status"`
+		IsStock bool               `db:"is_stock"`
+		Name    string             `db:"name"`
+	}
+	query := `SELECT a.status, p.is_stock, p.name FROM assets a JOIN persons p ON p.id = ? WHERE a.id = ?`
+	if err := tx.GetContext(ctx, &holder, query, aa.PersonID, aa.AssetID); err != nil {
+		return nil, err
+	}
+	reason := "Assigned to " + holder.Name
+	if holder.IsStock {
+		reason = "Returned to " + holder.Name
+	}
+	return moveStatus(ctx, tx, aa.AssetID, holder.Status, holderStatus(holder.Status, holder.IsStock), transitions, changedBy, reason)
+}
+
+// moveStatus moves an asset from status to the status to if it differs and the transitions allow
+// it, recording the change with reason. It returns the change, nil if the status stayed.
+func moveStatus(ctx context.Context, tx *sqlx.Tx, assetID int64, status, to models.AssetStatus, transitions lifecycle.Transitions, changedBy models.AssetStatusChange, reason string) (*models.AssetStatusChange, error) {
+	if to == status || transitions.Check(status, to) != nil {
+		return nil, nil
+	}
+	query := `UPDATE assets SET status = ?, updated_at = NOW() WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, to, assetID); err != nil {
+		return nil, err
+	}
+	change := changedBy
+	change.AssetID = assetID
+	change.FromStatus = status
+	change.ToStatus = to
+	change.Reason = reason
+	if err := createStatusChange(ctx, tx, &change); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
@@ -25,7 +25,8 @@ func NewAssetTypeRepository(db *sqlx.DB) *AssetTypeRepository {
 // GetByID retrieves an asset type by ID
 func (r *AssetTypeRepository) GetByID(ctx context.Context, id int64) (*models.AssetType, error) {
 	var assetType models.AssetType
-	query := `SELECT id, name, COALESCE(description, '') as description, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, COALESCE(description, '') as description, COALESCE(tag_pattern, '') as tag_pattern, depreciation_method,
+			  COALESCE(useful_life_months, 0) as useful_life_months, label_code, label_content, label_text, created_at, updated_at, deleted_at 
 			  FROM asset_types WHERE id = ? AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &assetType, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -37,7 +38,8 @@ func (r *AssetTypeRepository) GetByID(ctx context.Context, id int64) (*models.As
 // GetAll retrieves all asset types
 func (r *AssetTypeRepository) GetAll(ctx context.Context) ([]models.AssetType, error) {
 	var assetTypes []models.AssetType
-	query := `SELECT id, name, COALESCE(description, '') as description, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, COALESCE(description, '') as description, COALESCE(tag_pattern, '') as tag_pattern, depreciation_method,
+			  COALESCE(useful_life_months, 0) as useful_life_months, label_code, label_content, label_text, created_at, updated_at, deleted_at 
 			  FROM asset_types WHERE deleted_at IS NULL ORDER BY name`
 	err := r.db.SelectContext(ctx, &assetTypes, query)
 	return assetTypes, err
@@ -45,8 +47,13 @@ func (r *AssetTypeRepository) GetAll(ctx context.Context) ([]models.AssetType, e
 
 // Create creates a new asset type
 func (r *AssetTypeRepository) Create(ctx context.Context, assetType *models.AssetType) error {
-	query := `INSERT INTO asset_types (name, description) VALUES (?, ?)`
-	result, err := r.db.ExecContext(ctx, query, assetType.Name, assetType.Description)
+	query := `INSERT INTO asset_types (name, description, tag_pattern, depreciation_method, useful_life_months,
+			  label_code, label_content, label_text)
+			  VALUES (?, ?, NULLIF(?, ''), COALESCE(NULLIF(?, ''), 'straight_line'), NULLIF(?, 0),
+			  COALESCE(NULLIF(?, ''), 'qr'), COALESCE(NULLIF(?, ''), 'url'), COALESCE(NULLIF(?, ''), '{name}'))`
+	result, err := r.db.ExecContext(ctx, query, assetType.Name, assetType.Description, assetType.TagPattern,
+		assetType.DepreciationMethod, assetType.UsefulLifeMonths,
+		assetType.LabelCode, assetType.LabelContent, assetType.LabelText)
 	if err != nil {
 		return err
 	}
@@ -60,9 +67,13 @@ func (r *AssetTypeRepository) Create(ctx context.Context, assetType *models.Asse
 
 // Update updates an existing asset type
 func (r *AssetTypeRepository) Update(ctx context.Context, assetType *models.AssetType) error {
-	query := `UPDATE asset_types SET name = ?, description = ?, updated_at = NOW() 
+	query := `UPDATE asset_types SET name = ?, description = ?, tag_pattern = NULLIF(?, ''), depreciation_method = COALESCE(NULLIF(?, ''), 'straight_line'),
+			  useful_life_months = NULLIF(?, 0), label_code = COALESCE(NULLIF(?, ''), 'qr'),
+			  label_content = COALESCE(NULLIF(?, ''), 'url'), label_text = COALESCE(NULLIF(?, ''), '{name}'), updated_at = NOW() 
 			  WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, assetType.Name, assetType.Description, assetType.ID)
+	_, err := r.db.ExecContext(ctx, query, assetType.Name, assetType.Description, assetType.TagPattern,
+		assetType.DepreciationMethod, assetType.UsefulLifeMonths,
+		assetType.LabelCode, assetType.LabelContent, assetType.LabelText, assetType.ID)
 	return err
 }
 
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+// AssetTypePropertyRepository handles the property schemas of asset types
+type AssetTypePropertyRepository struct {
+	db *sqlx.DB
+}
+
+// NewAssetTypePropertyRepository creates a new asset type property repository
+func NewAssetTypePropertyRepository(db *sqlx.DB) *AssetTypePropertyRepository {
+	return &AssetTypePropertyRepository{db: db}
+}
+
+// GetByAssetTypeID retrieves the properties that apply to an asset type, in display order
+func (r *AssetTypePropertyRepository) GetByAssetTypeID(ctx context.Context, assetTypeID int64) ([]models.AssetTypeProperty, error) {
+	return getAssetTypeProperties(ctx, r.db, assetTypeID)
+}
+
+// getAssetTypeProperties loads an asset type schema using the given connection or transaction
+func getAssetTypeProperties(ctx context.Context, q queryer, assetTypeID int64) ([]models.AssetTypeProperty, error) {
+	schema := []models.AssetTypeProperty{}
+	query := `SELECT atp.id, atp.asset_type_id, atp.property_id, atp.is_required, atp.display_order,
+			  COALESCE(atp.default_value, '') as default_value,
+			  p.name as property_name, p.data_type, COALESCE(p.enum_options, '') as enum_options
+			  FROM asset_type_properties atp
+			  JOIN properties p ON atp.property_id = p.id
+			  WHERE atp.asset_type_id = ? AND p.deleted_at IS NULL
+			  ORDER BY atp.display_order, p.name`
+	err := q.SelectContext(ctx, &schema, query, assetTypeID)
+	return schema, err
+}
+
+// Replace sets the properties that apply to an asset type, replacing the previous schema
+func (r *AssetTypePropertyRepository) Replace(ctx context.Context, assetTypeID int64, schema []models.AssetTypeProperty) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM asset_type_properties WHERE asset_type_id = ?`, assetTypeID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO asset_type_properties (asset_type_id, property_id, is_required, display_order, default_value)
+			  VALUES (?, ?, ?, ?, ?)`
+	for i := range schema {
+		atp := &schema[i]
+		atp.AssetTypeID = assetTypeID
+		var defaultValue interface{}
+		if atp.DefaultValue != "" {
+			defaultValue = atp.DefaultValue
+		}
+		result, err := tx.ExecContext(ctx, query, assetTypeID, atp.PropertyID, atp.IsRequired, atp.DisplayOrder, defaultValue)
+		if err != nil {
+			return err
+		}
+		if atp.ID, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+// attachmentColumns selects an attachment
+const attachmentColumns = `id, entity_type, entity_id, file_name, content_type, size_bytes, sha256, storage_key,
+	COALESCE(description, '') as description, uploaded_by, uploaded_by_name,
+	created_at, updated_at, deleted_at`
+
+// attachmentTables maps the record kinds files can be attached to to their tables
+var attachmentTables = map[models.AttachmentEntity]string{
+	models.AttachmentEntityAsset:      "assets",
+	models.AttachmentEntityPerson:     "persons",
+	models.AttachmentEntityAssignment: "asset_assignments",
+}
+
+// AttachmentRepository handles attachment metadata operations
+type AttachmentRepository struct {
+	db *sqlx.DB
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *sqlx.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// GetByID retrieves an attachment by ID
+func (r *AttachmentRepository) GetByID(ctx context.Context, id int64) (*models.Attachment, error) {
+	var attachment models.Attachment
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = ? AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &attachment, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAttachmentNotFound
+	}
+	return &attachment, err
+}
+
+// GetByEntity retrieves the attachments of an asset, person or assignment, newest first
+func (r *AttachmentRepository) GetByEntity(ctx context.Context, entityType models.AttachmentEntity, entityID int64) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	query := `SELECT ` + attachmentColumns + ` FROM attachments
+			  WHERE entity_type = ? AND entity_id = ? AND deleted_at IS NULL
+			  ORDER BY created_at DESC, id DESC`
+	err := r.db.SelectContext(ctx, &attachments, query, entityType, entityID)
+	return attachments, err
+}
+
+// EntityExists reports whether the asset, person or assignment a file is attached to exists
+func (r *AttachmentRepository) EntityExists(ctx context.Context, entityType models.AttachmentEntity, entityID int64) (bool, error) {
+	table, ok := attachmentTables[entityType]
+	if !ok {
+		return false, nil
+	}
+	var count int
+	query := `SELECT COUNT(*) FROM ` + table + ` WHERE id = ? AND deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &count, query, entityID)
+	return count > 0, err
+}
+
+// Create records a stored file
+func (r *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	query := `INSERT INTO attachments (entity_type, entity_id, file_name, content_type, size_bytes, sha256,
+			  storage_key, description, uploaded_by, uploaded_by_name)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, attachment.EntityType, attachment.EntityID, attachment.FileName,
+		attachment.ContentType, attachment.Size, attachment.SHA256, attachment.StorageKey, attachment.Description,
+		attachment.UploadedBy, attachment.UploadedByName)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	attachment.ID = id
+	return nil
+}
+
+// Delete soft-deletes an attachment. The stored content is kept.
+func (r *AttachmentRepository) Delete(ctx context.Context, id int64) error {
+	query := `UPDATE attachments SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return err
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+// DefaultAuditLogLimit caps the number of audit entries returned when no limit is given
+const DefaultAuditLogLimit = 500
+
+// AuditLogFilter narrows down audit log queries. Zero values are ignored.
+type AuditLogFilter struct {
+	EntityType models.AuditEntityType
+	EntityID   int64
+	UserID     int64
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
+
+// AuditLogRepository handles audit log data operations
+type AuditLogRepository struct {
+	db *sqlx.DB
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *sqlx.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+// Create inserts a new audit log entry
+func (r *AuditLogRepository) Create(ctx context.Context, entry *models.AuditLog) error {
+	query := `INSERT INTO audit_log (user_id, username, entity_type, entity_id, asset_id, action, changes)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	var changes interface{}
+	if len(entry.Changes) > 0 {
+		changes = string(entry.Changes)
+	}
+	result, err := r.db.ExecContext(ctx, query, entry.UserID, entry.Username, entry.EntityType,
+		entry.EntityID, entry.AssetID, entry.Action, changes)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	entry.ID = id
+	return nil
+}
+
+// List retrieves audit log entries matching the filter, newest first
+func (r *AuditLogRepository) List(ctx context.Context, filter AuditLogFilter) ([]models.AuditLog, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.EntityType != "" {
+		conditions = append(conditions, "entity_type = ?")
+		args = append(args, filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.UserID != 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, *filter.From)
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, *filter.To)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditLogLimit
+	}
+
+	query := `SELECT id, user_id, username, entity_type, entity_id, asset_id, action, changes, created_at
+			  FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	var entries []models.AuditLog
+	err := r.db.SelectContext(ctx, &entries, query, args...)
+	return entries, err
+}
+
+// GetByAssetID retrieves the changelog of an asset, including its property values and assignments
+func (r *AuditLogRepository) GetByAssetID(ctx context.Context, assetID int64) ([]models.AuditLog, error) {
+	var entries []models.AuditLog
+	query := `SELECT id, user_id, username, entity_type, entity_id, asset_id, action, changes, created_at
+			  FROM audit_log WHERE asset_id = ?
+			  ORDER BY created_at DESC, id DESC`
+	err := r.db.SelectContext(ctx, &entries, query, assetID)
+	return entries, err
+}
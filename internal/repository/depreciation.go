@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+// ErrNotDepreciable is returned for assets without a purchase cost or purchase date
+var ErrNotDepreciable = errors.New("asset has no purchase cost or purchase date")
+
+// bookValueColumns selects what the book value of an asset is calculated from
+const bookValueColumns = `a.id as asset_id, a.name as asset_name, a.asset_type_id,
+	COALESCE(at.name, '') as asset_type_name, a.purchased_at, COALESCE(a.purchase_cost, 0) as purchase_cost,
+	COALESCE(a.currency, '') as currency,
+	COALESCE(at.depreciation_method, 'straight_line') as depreciation_method,
+	COALESCE(at.useful_life_months, 0) as useful_life_months`
+
+// DepreciationRepository loads the data book values are calculated from
+type DepreciationRepository struct {
+	db *sqlx.DB
+}
+
+// NewDepreciationRepository creates a new depreciation repository
+func NewDepreciationRepository(db *sqlx.DB) *DepreciationRepository {
+	return &DepreciationRepository{db: db}
+}
+
+// GetByAssetID retrieves the cost, purchase date and depreciation settings of an asset
+func (r *DepreciationRepository) GetByAssetID(ctx context.Context, assetID int64) (*models.BookValue, error) {
+	var row struct {
+		models.BookValue
+		HasCost bool `db:"has_cost"`
+	}
+	query := `SELECT ` + bookValueColumns + `, a.purchase_cost IS NOT NULL as has_cost
+			  FROM assets a
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE a.id = ? AND a.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &row, query, assetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !row.HasCost || !row.PurchasedAt.Valid {
+		return nil, ErrNotDepreciable
+	}
+	return &row.BookValue, nil
+}
+
+// GetAsOf retrieves every asset held at the end of a day that has a purchase cost and was bought
+// by then. Assets deleted or disposed of by then are left out.
+func (r *DepreciationRepository) GetAsOf(ctx context.Context, asOf time.Time) ([]models.BookValue, error) {
+	endOfDay := time.Date(asOf.Year(), asOf.Month(), asOf.Day()+1, 0, 0, 0, 0, asOf.Location())
+
+	var values []models.BookValue
+	query := `SELECT ` + bookValueColumns + `
+			  FROM assets a
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE a.purchase_cost IS NOT NULL AND a.purchased_at < ?
+			  AND (a.deleted_at IS NULL OR a.deleted_at >= ?)
+			  AND COALESCE((
+			      SELECT h.to_status FROM asset_status_history h
+			      WHERE h.asset_id = a.id AND h.changed_at < ?
+			      ORDER BY h.changed_at DESC, h.id DESC LIMIT 1
+			  ), '') != ?
+			  ORDER BY at.name, a.name`
+	err := r.db.SelectContext(ctx, &values, query, endOfDay, endOfDay, endOfDay, models.AssetStatusDisposed)
+	return values, err
+}
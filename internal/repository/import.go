@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+	"assetManager/internal/uniqueness"
+)
+
+// errImportDryRun rolls back the transaction of a dry run
+var errImportDryRun = errors.New("import dry run")
+
+// ImportRowError is returned by imports when a row cannot be imported as it is, such as an
+// assignment to a person who left or one the asset's reservations do not allow
+type ImportRowError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+func (e *ImportRowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *ImportRowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportRepository handles bulk imports
+type ImportRepository struct {
+	db *sqlx.DB
+}
+
+// NewImportRepository creates a new import repository
+func NewImportRepository(db *sqlx.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// ImportAssets creates the assets of all rows, their property values and assignments in a single
+// transaction. Either every row is imported or none is. It returns a *UniqueConflictError if a
+// row has a value the rules require to be unique that an existing asset or an earlier row has,
+// and an *ImportRowError if an assignment of a row is refused. Assets assigned to a person are
+// deployed as on AssignAsset, with their status updated in rows. A dry run checks the rows the
+// same way, then rolls everything back and leaves rows as they were.
+func (r *ImportRepository) ImportAssets(ctx context.Context, rows []models.AssetImportRow, assignedAt time.Time, dryRun bool, unique uniqueness.Rules, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) error {
+	if dryRun {
+		original := make([]models.AssetImportRow, len(rows))
+		for i, row := range rows {
+			original[i] = row
+			original[i].Properties = append([]models.AssetProperty(nil), row.Properties...)
+		}
+		defer copy(rows, original)
+	}
+
+	err := withUniqueLock(ctx, r.db, unique, uniqueness.EntityAsset, func(tx *sqlx.Tx) error {
+		assignedAt = assignedAt.Truncate(time.Second)
+		for i := range rows {
+			row := &rows[i]
+			if err := checkUniqueAsset(ctx, tx, unique, &row.Asset); err != nil {
+				return err
+			}
+			if err := createAsset(ctx, tx, &row.Asset); err != nil {
+				return err
+			}
+
+			for j := range row.Properties {
+				row.Properties[j].AssetID = row.Asset.ID
+				if err := createAssetProperty(ctx, tx, &row.Properties[j]); err != nil {
+					return err
+				}
+			}
+
+			if row.AssigneeID != 0 {
+				aa := &models.AssetAssignment{
+					AssetID:       row.Asset.ID,
+					PersonID:      row.AssigneeID,
+					EffectiveFrom: models.NewNullTime(assignedAt),
+					Notes:         "Imported",
+				}
+				if err := checkPersonActive(ctx, tx, aa.PersonID); err != nil {
+					return &ImportRowError{Row: row.Row, Column: "Assignee", Err: err}
+				}
+				if err := checkReserved(ctx, tx, aa, 0); err != nil {
+					return &ImportRowError{Row: row.Row, Column: "Assignee", Err: err}
+				}
+				if err := createAssignment(ctx, tx, aa); err != nil {
+					return err
+				}
+				change, err := updateHolderStatus(ctx, tx, aa, assignedAt, transitions, changedBy)
+				if err != nil {
+					return err
+				}
+				if change != nil {
+					row.Asset.Status = change.ToStatus
+				}
+			}
+		}
+		if dryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err == errImportDryRun {
+		return nil
+	}
+	return err
+}
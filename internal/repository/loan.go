@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+)
+
+var (
+	ErrAssetNotInStock = errors.New("asset is not held by a stock pool")
+	ErrAssetNotOnLoan  = errors.New("asset is not on loan")
+)
+
+// CheckOut lends an asset held by a stock pool, or by nobody, to a person until the due date. The
+// loan starts now and ends the stock pool's assignment; it fails with ErrOverlappingAssignment if
+// another change of the asset is scheduled before the due date, and with a
+// *ReservationConflictError if the asset is reserved before the due date. Assets in stock are
+// deployed where the transitions allow it; changedBy carries the user of that status change.
+func (r *AssetAssignmentRepository) CheckOut(ctx context.Context, req models.CheckOutRequest, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.CheckOut, error) {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	now := time.Now().Truncate(time.Second)
+
+	var checkedOut *models.CheckOut
+	err := withAssetLock(ctx, r.db, req.AssetID, func(tx *sqlx.Tx) error {
+		var err error
+		checkedOut, err = checkOut(ctx, tx, req, now, 0, transitions, changedBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkedOut, nil
+}
+
+// checkOut lends an asset as CheckOut does, in a transaction holding the asset lock. The loan
+// must not overlap reservations of the asset other than reservationID, the one picked up if any.
+func checkOut(ctx context.Context, tx *sqlx.Tx, req models.CheckOutRequest, now time.Time, reservationID int64, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.CheckOut, error) {
+	if err := checkAssignable(ctx, tx, req.AssetID); err != nil {
+		return nil, err
+	}
+	if err := checkPersonActive(ctx, tx, req.PersonID); err != nil {
+		return nil, err
+	}
+
+	result := &models.CheckOut{}
+	var current models.AssetAssignment
+	query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+			  aa.created_at, aa.updated_at, aa.deleted_at,
+			  COALESCE(p.is_stock, FALSE) as person_is_stock
+			  FROM asset_assignments aa
+			  LEFT JOIN persons p ON aa.person_id = p.id
+			  WHERE aa.asset_id = ? AND aa.deleted_at IS NULL
+			  AND aa.effective_from <= ?
+			  AND (aa.effective_to IS NULL OR aa.effective_to > ?)
+			  ORDER BY aa.effective_from DESC LIMIT 1`
+	err := tx.GetContext(ctx, &current, query, req.AssetID, now, now)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return nil, err
+	case !current.PersonIsStock:
+		return nil, ErrAssetNotInStock
+	case current.EffectiveFrom.Time.Equal(now):
+		return nil, ErrOverlappingAssignment
+	default:
+		result.Previous = &current
+		if err := endAssignment(ctx, tx, current.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	next, err := nextScheduled(ctx, tx, req.AssetID, now)
+	if err != nil {
+		return nil, err
+	}
+	if next.Valid && next.Time.Before(req.DueAt.Time) {
+		return nil, ErrOverlappingAssignment
+	}
+	result.Loan = models.AssetAssignment{
+		AssetID:       req.AssetID,
+		PersonID:      req.PersonID,
+		EffectiveFrom: models.NewNullTime(now),
+		EffectiveTo:   next,
+		DueAt:         req.DueAt,
+		Notes:         req.Notes,
+	}
+	if err := checkReserved(ctx, tx, &result.Loan, reservationID); err != nil {
+		return nil, err
+	}
+	if err := createAssignment(ctx, tx, &result.Loan); err != nil {
+		return nil, err
+	}
+	if result.StatusChange, err = updateHolderStatus(ctx, tx, &result.Loan, now, transitions, changedBy); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CheckIn takes a loaned asset back into a stock pool, recording the condition and notes it was
+// returned in, and moves it to repair if damaged where the transitions allow it; changedBy
+// carries the user of that status change
+func (r *AssetAssignmentRepository) CheckIn(ctx context.Context, assetID int64, pool *models.Person, req models.CheckInRequest, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.CheckIn, error) {
+	now := time.Now().Truncate(time.Second)
+
+	checkIn := &models.CheckIn{}
+	err := withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		query := `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, aa.due_at, COALESCE(aa.notes, '') as notes,
+				  aa.created_at, aa.updated_at, aa.deleted_at,
+				  COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name
+				  FROM asset_assignments aa
+				  LEFT JOIN assets a ON aa.asset_id = a.id
+				  LEFT JOIN persons p ON aa.person_id = p.id
+				  WHERE aa.asset_id = ? AND aa.deleted_at IS NULL AND aa.due_at IS NOT NULL
+				  AND aa.effective_from <= ?
+				  AND (aa.effective_to IS NULL OR aa.effective_to > ?)
+				  ORDER BY aa.effective_from DESC LIMIT 1`
+		err := tx.GetContext(ctx, &checkIn.Loan, query, assetID, now, now)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssetNotOnLoan
+		}
+		if err != nil {
+			return err
+		}
+
+		reason := "Returned in " + string(req.Condition) + " condition by " + checkIn.Loan.PersonName + " at the loan desk"
+		checkIn.Stock, checkIn.StatusChange, err = returnAsset(ctx, tx, checkIn.Loan, pool, req.Condition, req.Notes, false, now, transitions, changedBy, reason)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checkIn, nil
+}
+
+// GetActiveLoans retrieves the loans not yet checked in, soonest due first
+func (r *AssetAssignmentRepository) GetActiveLoans(ctx context.Context) ([]models.Loan, error) {
+	return r.selectLoans(ctx, "")
+}
+
+// GetOverdueLoans retrieves the loans not yet checked in that were due back before now, longest
+// overdue first
+func (r *AssetAssignmentRepository) GetOverdueLoans(ctx context.Context, now time.Time) ([]models.Loan, error) {
+	return r.selectLoans(ctx, "AND aa.due_at < ?", now)
+}
+
+// GetLoansToRemind retrieves the loans not yet checked in that are due back by dueBy and whose
+// borrower was not reminded since remindedSince
+func (r *AssetAssignmentRepository) GetLoansToRemind(ctx context.Context, dueBy, remindedSince time.Time) ([]models.Loan, error) {
+	return r.selectLoans(ctx, "AND aa.due_at <= ? AND (aa.reminded_at IS NULL OR aa.reminded_at < ?)", dueBy, remindedSince)
+}
+
+// MarkReminded records that the borrower of a loan was reminded at the given time
+func (r *AssetAssignmentRepository) MarkReminded(ctx context.Context, id int64, at time.Time) error {
+	query := `UPDATE asset_assignments SET reminded_at = ? WHERE id = ? AND deleted_at IS NULL`
+	_, err := r.db.ExecContext(ctx, query, at, id)
+	return err
+}
+
+// selectLoans retrieves the current loans matching the extra conditions, ordered by due date
+func (r *AssetAssignmentRepository) selectLoans(ctx context.Context, conditions string, args ...interface{}) ([]models.Loan, error) {
+	var loans []models.Loan
+	query := `SELECT aa.id as assignment_id, aa.asset_id, COALESCE(a.tag, '') as asset_tag, COALESCE(a.name, '') as asset_name,
+			  COALESCE(at.name, '') as asset_type_name,
+			  aa.person_id, COALESCE(p.name, '') as person_name, COALESCE(p.email, '') as person_email,
+			  aa.effective_from as checked_out_at, aa.due_at, aa.reminded_at, COALESCE(aa.notes, '') as notes
+			  FROM asset_assignments aa
+			  JOIN assets a ON aa.asset_id = a.id AND a.deleted_at IS NULL
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  LEFT JOIN persons p ON aa.person_id = p.id
+			  WHERE aa.deleted_at IS NULL AND aa.due_at IS NOT NULL
+			  AND aa.effective_from <= NOW()
+			  AND (aa.effective_to IS NULL OR aa.effective_to > NOW()) ` + conditions + `
+			  ORDER BY aa.due_at, a.name`
+	err := r.db.SelectContext(ctx, &loans, query, args...)
+	return loans, err
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+)
+
+var (
+	ErrLocationNotFound = errors.New("location not found")
+	ErrLocationCycle    = errors.New("location cannot be moved below itself")
+	ErrLocationInUse    = errors.New("location has sub-locations or placed assets")
+)
+
+// locationPathSeparator joins the names of a location and its ancestors
+const locationPathSeparator = " / "
+
+// locationSubtreeQuery selects the ID of a location and of all its descendants
+const locationSubtreeQuery = `WITH RECURSIVE subtree AS (
+		SELECT id FROM locations WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT l.id FROM locations l JOIN subtree s ON l.parent_id = s.id WHERE l.deleted_at IS NULL
+	) SELECT id FROM subtree`
+
+// LocationRepository handles location data operations
+type LocationRepository struct {
+	db *sqlx.DB
+}
+
+// NewLocationRepository creates a new location repository
+func NewLocationRepository(db *sqlx.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+// GetByID retrieves a location by ID, with its path
+func (r *LocationRepository) GetByID(ctx context.Context, id int64) (*models.Location, error) {
+	locations, err := getLocations(ctx, r.db, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, location := range locations {
+		if location.ID == id {
+			return &location, nil
+		}
+	}
+	return nil, ErrLocationNotFound
+}
+
+// GetAll retrieves all locations with their paths, ordered by path
+func (r *LocationRepository) GetAll(ctx context.Context) ([]models.Location, error) {
+	return getLocations(ctx, r.db, false)
+}
+
+// GetSubtreeIDs returns the ID of a location and of all locations below it
+func (r *LocationRepository) GetSubtreeIDs(ctx context.Context, id int64) ([]int64, error) {
+	var ids []int64
+	if err := r.db.SelectContext(ctx, &ids, locationSubtreeQuery, id); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrLocationNotFound
+	}
+	return ids, nil
+}
+
+// Create creates a new location below an existing parent, or at the top level without one
+func (r *LocationRepository) Create(ctx context.Context, location *models.Location) error {
+	if location.ParentID != nil {
+		if err := checkLocationExists(ctx, r.db, *location.ParentID); err != nil {
+			return err
+		}
+	}
+
+	query := `INSERT INTO locations (parent_id, name, kind, description) VALUES (?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, location.ParentID, location.Name, location.Kind, location.Description)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	location.ID = id
+	return nil
+}
+
+// Update updates an existing location. Moving a location below itself or one of its
+// descendants returns ErrLocationCycle.
+func (r *LocationRepository) Update(ctx context.Context, location *models.Location) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the hierarchy so concurrent moves cannot combine into a cycle
+	locations, err := getLocations(ctx, tx, true)
+	if err != nil {
+		return err
+	}
+	parents := make(map[int64]*int64, len(locations))
+	for _, l := range locations {
+		parents[l.ID] = l.ParentID
+	}
+	if _, ok := parents[location.ID]; !ok {
+		return ErrLocationNotFound
+	}
+	if location.ParentID != nil {
+		if _, ok := parents[*location.ParentID]; !ok {
+			return ErrLocationNotFound
+		}
+		if isLocationAncestor(parents, location.ID, *location.ParentID) {
+			return ErrLocationCycle
+		}
+	}
+
+	query := `UPDATE locations SET parent_id = ?, name = ?, kind = ?, description = ?, updated_at = NOW()
+			  WHERE id = ? AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, location.ParentID, location.Name, location.Kind, location.Description, location.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Delete soft-deletes a location. Locations with sub-locations or currently placed assets
+// cannot be deleted.
+func (r *LocationRepository) Delete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the hierarchy so no sub-location can be added or moved below the location, and no
+	// asset placed in it, between the check and the delete
+	locations, err := getLocations(ctx, tx, true)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(locations, func(l models.Location) bool { return l.ID == id }) {
+		return ErrLocationNotFound
+	}
+
+	var count int
+	query := `SELECT (SELECT COUNT(*) FROM locations WHERE parent_id = ? AND deleted_at IS NULL) +
+			  (SELECT COUNT(*) FROM asset_locations WHERE location_id = ? AND deleted_at IS NULL
+			   AND (effective_to IS NULL OR effective_to > NOW()))`
+	if err := tx.GetContext(ctx, &count, query, id, id); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLocationInUse
+	}
+
+	query = `UPDATE locations SET deleted_at = NOW() WHERE id = ? AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getLocations loads all active locations with their paths, ordered by path. With forUpdate
+// the rows stay locked until the transaction ends.
+func getLocations(ctx context.Context, q queryer, forUpdate bool) ([]models.Location, error) {
+	var locations []models.Location
+	query := `SELECT id, parent_id, name, kind, COALESCE(description, '') as description,
+			  created_at, updated_at, deleted_at
+			  FROM locations WHERE deleted_at IS NULL`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+	if err := q.SelectContext(ctx, &locations, query); err != nil {
+		return nil, err
+	}
+
+	paths := LocationPaths(locations)
+	for i := range locations {
+		locations[i].Path = paths[locations[i].ID]
+	}
+	sortLocations(locations)
+	return locations, nil
+}
+
+// allLocationPaths maps the ID of every location, including deleted ones, to its path
+func allLocationPaths(ctx context.Context, q queryer) (map[int64]string, error) {
+	var locations []models.Location
+	query := `SELECT id, parent_id, name, kind, COALESCE(description, '') as description,
+			  created_at, updated_at, deleted_at
+			  FROM locations`
+	if err := q.SelectContext(ctx, &locations, query); err != nil {
+		return nil, err
+	}
+	return LocationPaths(locations), nil
+}
+
+// checkLocationExists returns ErrLocationNotFound unless the location exists and is not deleted
+func checkLocationExists(ctx context.Context, q queryer, id int64) error {
+	var found int64
+	err := q.GetContext(ctx, &found, `SELECT id FROM locations WHERE id = ? AND deleted_at IS NULL`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrLocationNotFound
+	}
+	return err
+}
+
+// LocationPaths maps each location ID to the names of its ancestors and itself, such as
+// "Berlin / Building A / Room 101". Parents missing from the list end the path.
+func LocationPaths(locations []models.Location) map[int64]string {
+	byID := make(map[int64]models.Location, len(locations))
+	for _, l := range locations {
+		byID[l.ID] = l
+	}
+
+	paths := make(map[int64]string, len(locations))
+	for _, l := range locations {
+		names := []string{l.Name}
+		seen := map[int64]bool{l.ID: true}
+		for parentID := l.ParentID; parentID != nil && !seen[*parentID]; {
+			parent, ok := byID[*parentID]
+			if !ok {
+				break
+			}
+			seen[parent.ID] = true
+			names = append([]string{parent.Name}, names...)
+			parentID = parent.ParentID
+		}
+		paths[l.ID] = strings.Join(names, locationPathSeparator)
+	}
+	return paths
+}
+
+// isLocationAncestor reports whether ancestorID is candidateID itself or one of its ancestors
+func isLocationAncestor(parents map[int64]*int64, ancestorID, candidateID int64) bool {
+	seen := make(map[int64]bool)
+	for current := &candidateID; current != nil && !seen[*current]; current = parents[*current] {
+		if *current == ancestorID {
+			return true
+		}
+		seen[*current] = true
+	}
+	return false
+}
+
+// sortLocations orders locations by path, so that each location follows its parent
+func sortLocations(locations []models.Location) {
+	slices.SortStableFunc(locations, func(a, b models.Location) int {
+		return strings.Compare(strings.ToLower(a.Path), strings.ToLower(b.Path))
+	})
+}
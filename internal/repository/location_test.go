@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func testLocations() []models.Location {
+	berlin, building, room := int64(1), int64(2), int64(3)
+	return []models.Location{
+		{BaseModel: models.BaseModel{ID: room}, ParentID: &building, Name: "Room 101"},
+		{BaseModel: models.BaseModel{ID: berlin}, Name: "Berlin"},
+		{BaseModel: models.BaseModel{ID: building}, ParentID: &berlin, Name: "Building A"},
+		{BaseModel: models.BaseModel{ID: 4}, Name: "Munich"},
+	}
+}
+
+func TestLocationPaths(t *testing.T) {
+	paths := LocationPaths(testLocations())
+
+	want := map[int64]string{
+		1: "Berlin",
+		2: "Berlin / Building A",
+		3: "Berlin / Building A / Room 101",
+		4: "Munich",
+	}
+	for id, path := range want {
+		if paths[id] != path {
+			t.Errorf("Expected path %q for %d, got %q", path, id, paths[id])
+		}
+	}
+}
+
+func TestSortLocations(t *testing.T) {
+	locations := testLocations()
+	paths := LocationPaths(locations)
+	for i := range locations {
+		locations[i].Path = paths[locations[i].ID]
+	}
+	sortLocations(locations)
+
+	var order []int64
+	for _, l := range locations {
+		order = append(order, l.ID)
+	}
+	if want := []int64{1, 2, 3, 4}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected each location after its parent %v, got %v", want, order)
+	}
+}
+
+func TestIsLocationAncestor(t *testing.T) {
+	parents := make(map[int64]*int64)
+	for _, l := range testLocations() {
+		parents[l.ID] = l.ParentID
+	}
+
+	if !isLocationAncestor(parents, 1, 3) {
+		t.Error("Expected Berlin to be an ancestor of Room 101")
+	}
+	if !isLocationAncestor(parents, 2, 2) {
+		t.Error("Expected a location to count as its own ancestor")
+	}
+	if isLocationAncestor(parents, 3, 1) {
+		t.Error("Expected Room 101 not to be an ancestor of Berlin")
+	}
+	if isLocationAncestor(parents, 1, 4) {
+		t.Error("Expected Berlin not to be an ancestor of Munich")
+	}
+}
@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+)
+
+var (
+	ErrOffboardStockPool = errors.New("stock pools cannot be offboarded")
+	ErrAssetNotHeld      = errors.New("asset is not held by the person")
+)
+
+// Offboard hands the returned assets of a leaving person to a stock pool and marks the person
+// inactive as of the leave date, all in one transaction. Each returned assignment ends now with
+// the condition and notes of its item, and returned assets move out of deployed (to repair if
+// damaged) where the transitions allow it; changedBy carries the user of those status changes.
+// Held assets without a returned item stay with the person and are listed as missing, so
+// offboarding a person again records late returns. Assignments to the person that have not
+// started yet are cancelled like CancelPending does.
+func (r *PersonRepository) Offboard(ctx context.Context, personID int64, pool *models.Person, req models.OffboardingRequest, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.Offboarding, error) {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	now := time.Now().Truncate(time.Second)
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Assets held now or later are locked before persons, in the order withAssetLock takes its locks
+	var assetIDs []int64
+	query := `SELECT a.id FROM assets a
+			  JOIN asset_assignments aa ON aa.asset_id = a.id
+			  WHERE aa.person_id = ? AND aa.deleted_at IS NULL
+			  AND (aa.effective_to IS NULL OR aa.effective_to > ?)
+			  ORDER BY a.id FOR UPDATE`
+	if err := tx.SelectContext(ctx, &assetIDs, query, personID, now); err != nil {
+		return nil, err
+	}
+
+	offboarding := &models.Offboarding{}
+	query = `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at
+			 FROM persons WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+	err = tx.GetContext(ctx, &offboarding.Person, query, personID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPersonNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if offboarding.Person.IsStock {
+		return nil, ErrOffboardStockPool
+	}
+
+	// Cancelled first, so a cancelled assignment following one the person holds extends that one
+	// before it is returned
+	query = `SELECT id, asset_id, person_id, effective_from, effective_to, COALESCE(notes, '') as notes,
+			 created_at, updated_at, deleted_at
+			 FROM asset_assignments
+			 WHERE person_id = ? AND deleted_at IS NULL AND effective_from > ?
+			 ORDER BY effective_from`
+	if err := tx.SelectContext(ctx, &offboarding.Pending, query, personID, now); err != nil {
+		return nil, err
+	}
+	for _, pending := range offboarding.Pending {
+		previous, err := cancelPending(ctx, tx, pending)
+		if err != nil {
+			return nil, err
+		}
+		if previous != nil {
+			offboarding.Extended = append(offboarding.Extended, *previous)
+		}
+	}
+
+	var held []models.AssetAssignment
+	query = `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+			 aa.created_at, aa.updated_at, aa.deleted_at,
+			 COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			 COALESCE(p.is_stock, FALSE) as person_is_stock,
+			 COALESCE(at.name, '') as asset_type_name,
+			 COALESCE(a.model, '') as asset_model, COALESCE(a.serial_number, '') as asset_serial_number
+			 FROM asset_assignments aa
+			 LEFT JOIN assets a ON aa.asset_id = a.id
+			 LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			 LEFT JOIN persons p ON aa.person_id = p.id
+			 WHERE aa.person_id = ? AND aa.deleted_at IS NULL
+			 AND aa.effective_from <= ?
+			 AND (aa.effective_to IS NULL OR aa.effective_to > ?)
+			 ORDER BY a.name`
+	if err := tx.SelectContext(ctx, &held, query, personID, now, now); err != nil {
+		return nil, err
+	}
+
+	items := make(map[int64]models.OffboardingItem, len(req.Items))
+	for _, item := range req.Items {
+		items[item.AssetID] = item
+	}
+	heldAssets := make(map[int64]bool, len(held))
+	for _, aa := range held {
+		heldAssets[aa.AssetID] = true
+	}
+	for assetID := range items {
+		if !heldAssets[assetID] {
+			return nil, ErrAssetNotHeld
+		}
+	}
+
+	for _, aa := range held {
+		item, ok := items[aa.AssetID]
+		if !ok || !item.Returned {
+			offboarding.Missing = append(offboarding.Missing, aa)
+			continue
+		}
+
+		reason := "Returned in " + string(item.Condition) + " condition by " + offboarding.Person.Name + " on offboarding"
+		stock, change, err := returnAsset(ctx, tx, aa, pool, item.Condition, item.Notes, true, now, transitions, changedBy, reason)
+		if err != nil {
+			return nil, err
+		}
+		offboarding.Returned = append(offboarding.Returned, aa)
+		offboarding.Stock = append(offboarding.Stock, stock)
+		if change != nil {
+			offboarding.StatusChanges = append(offboarding.StatusChanges, *change)
+		}
+	}
+
+	// Persons who left cannot pick anything up, so their reservations only hold assets back
+	query = `SELECT ` + reservationColumns + `
+			 FROM reservations r
+			 ` + reservationJoins + `
+			 WHERE r.person_id = ? AND r.status = 'reserved'
+			 ORDER BY r.starts_at`
+	if err := tx.SelectContext(ctx, &offboarding.Cancelled, query, personID); err != nil {
+		return nil, err
+	}
+	query = `UPDATE reservations SET status = 'cancelled' WHERE person_id = ? AND status = 'reserved'`
+	if _, err := tx.ExecContext(ctx, query, personID); err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE persons SET is_active = FALSE, left_at = ?, updated_at = NOW() WHERE id = ?`
+	// left_at is a date, written as such so the driver does not shift it to another time zone
+	if _, err := tx.ExecContext(ctx, query, req.LeaveDate.Time.Format("2006-01-02"), personID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return offboarding, nil
+}
+
+// GetOffboardingChecklist lists the assets a person still holds, as missing, and the assets the
+// person returned on offboarding. Loans the person checked in at the loan desk are not listed.
+func (r *PersonRepository) GetOffboardingChecklist(ctx context.Context, personID int64) ([]models.OffboardingChecklistItem, error) {
+	var items []models.OffboardingChecklistItem
+	query := `SELECT aa.asset_id, COALESCE(a.tag, '') as asset_tag, COALESCE(a.name, '') as asset_name,
+			  COALESCE(at.name, '') as asset_type_name, COALESCE(a.serial_number, '') as asset_serial_number,
+			  aa.returned_on_offboarding as returned,
+			  CASE WHEN aa.returned_on_offboarding THEN aa.effective_to END as returned_at,
+			  COALESCE(aa.return_condition, '') as return_condition, COALESCE(aa.return_notes, '') as return_notes
+			  FROM asset_assignments aa
+			  LEFT JOIN assets a ON aa.asset_id = a.id
+			  LEFT JOIN asset_types at ON a.asset_type_id = at.id
+			  WHERE aa.person_id = ? AND aa.deleted_at IS NULL
+			  AND (aa.returned_on_offboarding
+			       OR (aa.effective_from <= NOW() AND (aa.effective_to IS NULL OR aa.effective_to > NOW())))
+			  ORDER BY returned, a.name, aa.effective_to`
+	err := r.db.SelectContext(ctx, &items, query, personID)
+	return items, err
+}
+
+// returnAsset ends a held assignment at now with the condition and notes the asset was handed
+// back in, marked as a return on offboarding if offboarding is set. It gives the asset to the
+// stock pool until its next scheduled change and moves it out of deployed (to repair if damaged)
+// where the transitions allow it, recording reason with the status change. It returns the stock
+// assignment and the status change, nil if the status stayed.
+func returnAsset(ctx context.Context, tx *sqlx.Tx, aa models.AssetAssignment, pool *models.Person, condition models.ReturnCondition, notes string, offboarding bool, now time.Time, transitions lifecycle.Transitions, changedBy models.AssetStatusChange, reason string) (models.AssetAssignment, *models.AssetStatusChange, error) {
+	query := `UPDATE asset_assignments SET effective_to = ?, return_condition = ?, return_notes = ?,
+			  returned_on_offboarding = ?, updated_at = NOW()
+			  WHERE id = ? AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, now, condition, notes, offboarding, aa.ID); err != nil {
+		return models.AssetAssignment{}, nil, err
+	}
+
+	stock := models.AssetAssignment{
+		AssetID:       aa.AssetID,
+		PersonID:      pool.ID,
+		EffectiveFrom: models.NewNullTime(now),
+		Notes:         "Returned to " + pool.Name,
+	}
+	var err error
+	if stock.EffectiveTo, err = nextScheduled(ctx, tx, aa.AssetID, now); err != nil {
+		return models.AssetAssignment{}, nil, err
+	}
+	if err := createAssignment(ctx, tx, &stock); err != nil {
+		return models.AssetAssignment{}, nil, err
+	}
+
+	var status models.AssetStatus
+	if err := tx.GetContext(ctx, &status, `SELECT status FROM assets WHERE id = ?`, aa.AssetID); err != nil {
+		return models.AssetAssignment{}, nil, err
+	}
+	change, err := moveStatus(ctx, tx, aa.AssetID, status, returnStatus(status, condition), transitions, changedBy, reason)
+	if err != nil {
+		return models.AssetAssignment{}, nil, err
+	}
+	return stock, change, nil
+}
+
+// returnStatus is the status an asset handed back in the given condition moves to: damaged assets
+// in use or in stock go to repair, deployed and lost assets go back to stock, others keep their status
+func returnStatus(current models.AssetStatus, condition models.ReturnCondition) models.AssetStatus {
+	switch {
+	case condition == models.ReturnConditionDamaged && (current == models.AssetStatusDeployed || current == models.AssetStatusInStock):
+		return models.AssetStatusInRepair
+	case current == models.AssetStatusDeployed || current == models.AssetStatusLostStolen:
+		return models.AssetStatusInStock
+	default:
+		return current
+	}
+}
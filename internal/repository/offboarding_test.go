@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func TestReturnStatus(t *testing.T) {
+	cases := []struct {
+		current   models.AssetStatus
+		condition models.ReturnCondition
+		want      models.AssetStatus
+	}{
+		{models.AssetStatusDeployed, models.ReturnConditionGood, models.AssetStatusInStock},
+		{models.AssetStatusDeployed, models.ReturnConditionFair, models.AssetStatusInStock},
+		{models.AssetStatusDeployed, models.ReturnConditionDamaged, models.AssetStatusInRepair},
+		{models.AssetStatusInStock, models.ReturnConditionGood, models.AssetStatusInStock},
+		{models.AssetStatusInStock, models.ReturnConditionDamaged, models.AssetStatusInRepair},
+		{models.AssetStatusLostStolen, models.ReturnConditionGood, models.AssetStatusInStock},
+		{models.AssetStatusLostStolen, models.ReturnConditionDamaged, models.AssetStatusInStock},
+		{models.AssetStatusInRepair, models.ReturnConditionGood, models.AssetStatusInRepair},
+		{models.AssetStatusRetired, models.ReturnConditionDamaged, models.AssetStatusRetired},
+	}
+	for _, c := range cases {
+		if got := returnStatus(c.current, c.condition); got != c.want {
+			t.Errorf("returnStatus(%s, %s) = %s, want %s", c.current, c.condition, got, c.want)
+		}
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 
 	"assetManager/internal/models"
+	"assetManager/internal/uniqueness"
 )
 
 var ErrPersonNotFound = errors.New("person not found")
@@ -25,7 +27,7 @@ func NewPersonRepository(db *sqlx.DB) *PersonRepository {
 // GetByID retrieves a person by ID
 func (r *PersonRepository) GetByID(ctx context.Context, id int64) (*models.Person, error) {
 	var person models.Person
-	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at 
 			  FROM persons WHERE id = ? AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &person, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -34,6 +36,18 @@ func (r *PersonRepository) GetByID(ctx context.Context, id int64) (*models.Perso
 	return &person, err
 }
 
+// GetMergedIntoID returns the surviving person a deleted person was merged into, or 0 if it was
+// not merged
+func (r *PersonRepository) GetMergedIntoID(ctx context.Context, id int64) (int64, error) {
+	var mergedIntoID sql.NullInt64
+	query := `SELECT merged_into_id FROM persons WHERE id = ?`
+	err := r.db.GetContext(ctx, &mergedIntoID, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return mergedIntoID.Int64, err
+}
+
 // GetAll retrieves all persons. If includeDeleted is true, returns only soft-deleted records.
 func (r *PersonRepository) GetAll(ctx context.Context, includeDeleted bool) ([]models.Person, error) {
 	var persons []models.Person
@@ -41,45 +55,95 @@ func (r *PersonRepository) GetAll(ctx context.Context, includeDeleted bool) ([]m
 	if includeDeleted {
 		deletedFilter = "deleted_at IS NOT NULL"
 	}
-	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, merged_into_id, created_at, updated_at, deleted_at 
 			  FROM persons WHERE ` + deletedFilter + ` ORDER BY name`
 	err := r.db.SelectContext(ctx, &persons, query)
 	return persons, err
 }
 
-// GetUnassigned retrieves the special 'Unassigned' person
-func (r *PersonRepository) GetUnassigned(ctx context.Context) (*models.Person, error) {
-	var person models.Person
-	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, created_at, updated_at, deleted_at 
-			  FROM persons WHERE name = 'Unassigned' AND deleted_at IS NULL LIMIT 1`
-	err := r.db.GetContext(ctx, &person, query)
+// GetStockPools retrieves all stock pools, oldest first
+func (r *PersonRepository) GetStockPools(ctx context.Context) ([]models.Person, error) {
+	var pools []models.Person
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at 
+			  FROM persons WHERE is_stock = TRUE AND deleted_at IS NULL ORDER BY id`
+	err := r.db.SelectContext(ctx, &pools, query)
+	return pools, err
+}
+
+// GetStockPool retrieves a stock pool by ID, or the default stock pool (the oldest one) if id is 0
+func (r *PersonRepository) GetStockPool(ctx context.Context, id int64) (*models.Person, error) {
+	var pool models.Person
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at 
+			  FROM persons WHERE is_stock = TRUE AND deleted_at IS NULL AND (? = 0 OR id = ?)
+			  ORDER BY id LIMIT 1`
+	err := r.db.GetContext(ctx, &pool, query, id, id)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, ErrPersonNotFound
 	}
-	return &person, err
+	return &pool, err
 }
 
-// Create creates a new person
-func (r *PersonRepository) Create(ctx context.Context, person *models.Person) error {
-	query := `INSERT INTO persons (name, email, phone) VALUES (?, ?, ?)`
-	result, err := r.db.ExecContext(ctx, query, person.Name, person.Email, person.Phone)
-	if err != nil {
-		return err
+// findPersonConflict returns the non-deleted person other than the given one that has the same
+// value in the field of a uniqueness rule, or nil if there is none. Values are compared ignoring
+// case and surrounding spaces.
+func findPersonConflict(ctx context.Context, q queryer, rule uniqueness.Rule, person *models.Person) (*models.Person, error) {
+	value := rule.PersonValue(person)
+	if value == "" {
+		return nil, nil
+	}
+	column := rule.Column()
+	if rule.Entity != uniqueness.EntityPerson || column == "" {
+		return nil, fmt.Errorf("invalid person uniqueness rule on %q", rule.Field)
+	}
+
+	var existing models.Person
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at 
+			  FROM persons WHERE deleted_at IS NULL AND id <> ? AND LOWER(TRIM(` + column + `)) = LOWER(?)
+			  ORDER BY id LIMIT 1`
+	err := q.GetContext(ctx, &existing, query, person.ID, value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
 	}
-	id, err := result.LastInsertId()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	person.ID = id
-	return nil
+	return &existing, nil
 }
 
-// Update updates an existing person
-func (r *PersonRepository) Update(ctx context.Context, person *models.Person) error {
-	query := `UPDATE persons SET name = ?, email = ?, phone = ?, updated_at = NOW() 
-			  WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, person.Name, person.Email, person.Phone, person.ID)
-	return err
+// Create creates a new person. It returns a *UniqueConflictError if another person has a value
+// the rules require to be unique.
+func (r *PersonRepository) Create(ctx context.Context, person *models.Person, unique uniqueness.Rules) error {
+	return withUniqueLock(ctx, r.db, unique, uniqueness.EntityPerson, func(tx *sqlx.Tx) error {
+		if err := checkUniquePerson(ctx, tx, unique, person); err != nil {
+			return err
+		}
+		query := `INSERT INTO persons (name, email, phone, is_stock) VALUES (?, ?, ?, ?)`
+		result, err := tx.ExecContext(ctx, query, person.Name, person.Email, person.Phone, person.IsStock)
+		if err != nil {
+			return err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		person.ID = id
+		person.IsActive = true
+		return nil
+	})
+}
+
+// Update updates an existing person. It returns a *UniqueConflictError if another person has a
+// value the rules require to be unique.
+func (r *PersonRepository) Update(ctx context.Context, person *models.Person, unique uniqueness.Rules) error {
+	return withUniqueLock(ctx, r.db, unique, uniqueness.EntityPerson, func(tx *sqlx.Tx) error {
+		if err := checkUniquePerson(ctx, tx, unique, person); err != nil {
+			return err
+		}
+		query := `UPDATE persons SET name = ?, email = ?, phone = ?, is_stock = ?, updated_at = NOW() 
+				  WHERE id = ? AND deleted_at IS NULL`
+		_, err := tx.ExecContext(ctx, query, person.Name, person.Email, person.Phone, person.IsStock, person.ID)
+		return err
+	})
 }
 
 // Delete soft-deletes a person
@@ -93,7 +157,7 @@ func (r *PersonRepository) Delete(ctx context.Context, id int64) error {
 func (r *PersonRepository) Search(ctx context.Context, term string) ([]models.Person, error) {
 	var persons []models.Person
 	searchTerm := "%" + term + "%"
-	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, created_at, updated_at, deleted_at 
+	query := `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at 
 			  FROM persons WHERE deleted_at IS NULL 
 			  AND (name LIKE ? OR email LIKE ?)
 			  ORDER BY name`
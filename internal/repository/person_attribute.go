@@ -50,6 +50,34 @@ func (r *PersonAttributeRepository) GetByPersonID(ctx context.Context, personID
 	return pas, err
 }
 
+// GetByPersonAndAttributeID retrieves the value of an attribute for a person
+func (r *PersonAttributeRepository) GetByPersonAndAttributeID(ctx context.Context, personID, attributeID int64) (*models.PersonAttribute, error) {
+	var pa models.PersonAttribute
+	query := `SELECT pa.id, pa.person_id, pa.attribute_id, COALESCE(pa.value, '') as value, pa.created_at, pa.updated_at, pa.deleted_at,
+			  COALESCE(a.name, '') as attribute_name, COALESCE(a.data_type, 'string') as data_type
+			  FROM persons_attributes pa
+			  LEFT JOIN attributes a ON pa.attribute_id = a.id
+			  WHERE pa.person_id = ? AND pa.attribute_id = ? AND pa.deleted_at IS NULL`
+	err := r.db.GetContext(ctx, &pa, query, personID, attributeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPersonAttributeNotFound
+	}
+	return &pa, err
+}
+
+// GetAllValues retrieves every stored attribute value with its attribute definition
+func (r *PersonAttributeRepository) GetAllValues(ctx context.Context) ([]models.CustomValue, error) {
+	var values []models.CustomValue
+	query := `SELECT pa.id, pa.person_id as owner_id, pa.attribute_id as field_id, COALESCE(pa.value, '') as value,
+			  a.name as field_name, a.data_type, COALESCE(a.enum_options, '') as enum_options
+			  FROM persons_attributes pa
+			  JOIN attributes a ON pa.attribute_id = a.id
+			  WHERE pa.deleted_at IS NULL
+			  ORDER BY pa.person_id, a.name`
+	err := r.db.SelectContext(ctx, &values, query)
+	return values, err
+}
+
 // Create creates a new person attribute
 func (r *PersonAttributeRepository) Create(ctx context.Context, pa *models.PersonAttribute) error {
 	query := `INSERT INTO persons_attributes (person_id, attribute_id, value) VALUES (?, ?, ?)`
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"assetManager/internal/models"
+)
+
+var (
+	ErrMergeSamePerson = errors.New("a person cannot be merged into itself")
+	ErrMergeStockPool  = errors.New("stock pools cannot be merged")
+)
+
+// MergeConflictError lists the attributes both persons of a merge have set to different values
+// that no rule of the request resolves
+type MergeConflictError struct {
+	Conflicts []models.AttributeConflict
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%d conflicting attributes", len(e.Conflicts))
+}
+
+// Merge moves the assignments, attribute values, attachments and reservations not picked up yet
+// of the source person of the request to the target and soft-deletes the source with a pointer to the target, all in one
+// transaction. Persons merged into the source before now point to the target as well. Stock pools
+// cannot be merged, and persons who left cannot be merged into.
+func (r *PersonRepository) Merge(ctx context.Context, targetID int64, req models.PersonMergeRequest) (*models.PersonMerge, error) {
+	sourceID := req.SourceID
+	if sourceID == targetID {
+		return nil, ErrMergeSamePerson
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Assets are locked before persons, in the order withAssetLock takes its locks
+	var assetIDs []int64
+	query := `SELECT a.id FROM assets a
+			  JOIN asset_assignments aa ON aa.asset_id = a.id
+			  WHERE aa.person_id = ? AND aa.deleted_at IS NULL
+			  ORDER BY a.id FOR UPDATE`
+	if err := tx.SelectContext(ctx, &assetIDs, query, sourceID); err != nil {
+		return nil, err
+	}
+
+	var persons []models.Person
+	query = `SELECT id, name, COALESCE(email, '') as email, COALESCE(phone, '') as phone, is_stock, is_active, left_at, created_at, updated_at, deleted_at
+			 FROM persons WHERE id IN (?, ?) AND deleted_at IS NULL ORDER BY id FOR UPDATE`
+	if err := tx.SelectContext(ctx, &persons, query, sourceID, targetID); err != nil {
+		return nil, err
+	}
+	if len(persons) != 2 {
+		return nil, ErrPersonNotFound
+	}
+	for _, p := range persons {
+		if p.IsStock {
+			return nil, ErrMergeStockPool
+		}
+	}
+	// The target receives the assignments of the source, which persons who left cannot hold
+	if err := checkPersonActive(ctx, tx, targetID); err != nil {
+		return nil, err
+	}
+	merge := &models.PersonMerge{Source: persons[0]}
+	if merge.Source.ID != sourceID {
+		merge.Source = persons[1]
+	}
+
+	var sourceAttributes, targetAttributes []models.PersonAttribute
+	query = `SELECT pa.id, pa.person_id, pa.attribute_id, COALESCE(pa.value, '') as value, pa.created_at, pa.updated_at, pa.deleted_at,
+			 COALESCE(a.name, '') as attribute_name, COALESCE(a.data_type, 'string') as data_type
+			 FROM persons_attributes pa
+			 LEFT JOIN attributes a ON pa.attribute_id = a.id
+			 WHERE pa.person_id = ? AND pa.deleted_at IS NULL
+			 ORDER BY a.name`
+	if err := tx.SelectContext(ctx, &sourceAttributes, query, sourceID); err != nil {
+		return nil, err
+	}
+	if err := tx.SelectContext(ctx, &targetAttributes, query, targetID); err != nil {
+		return nil, err
+	}
+	var conflicts []models.AttributeConflict
+	merge.MovedAttributes, merge.DroppedAttributes, conflicts = planAttributeMerge(sourceAttributes, targetAttributes, req)
+	if len(conflicts) > 0 {
+		return nil, &MergeConflictError{Conflicts: conflicts}
+	}
+
+	query = `SELECT aa.id, aa.asset_id, aa.person_id, aa.effective_from, aa.effective_to, COALESCE(aa.notes, '') as notes,
+			 aa.created_at, aa.updated_at, aa.deleted_at,
+			 COALESCE(a.name, '') as asset_name, COALESCE(p.name, '') as person_name,
+			 COALESCE(p.is_stock, FALSE) as person_is_stock
+			 FROM asset_assignments aa
+			 LEFT JOIN assets a ON aa.asset_id = a.id
+			 LEFT JOIN persons p ON aa.person_id = p.id
+			 WHERE aa.person_id = ? AND aa.deleted_at IS NULL
+			 ORDER BY aa.effective_from`
+	if err := tx.SelectContext(ctx, &merge.Assignments, query, sourceID); err != nil {
+		return nil, err
+	}
+	query = `UPDATE asset_assignments SET person_id = ?, updated_at = NOW() WHERE person_id = ? AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+		return nil, err
+	}
+
+	for _, pa := range merge.DroppedAttributes {
+		query := `UPDATE persons_attributes SET deleted_at = NOW() WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, pa.ID); err != nil {
+			return nil, err
+		}
+	}
+	for _, pa := range merge.MovedAttributes {
+		query := `UPDATE persons_attributes SET person_id = ?, updated_at = NOW() WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, query, targetID, pa.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `SELECT id FROM attachments WHERE entity_type = ? AND entity_id = ? AND deleted_at IS NULL ORDER BY id`
+	if err := tx.SelectContext(ctx, &merge.Attachments, query, models.AttachmentEntityPerson, sourceID); err != nil {
+		return nil, err
+	}
+	query = `UPDATE attachments SET entity_id = ?, updated_at = NOW() WHERE entity_type = ? AND entity_id = ? AND deleted_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, targetID, models.AttachmentEntityPerson, sourceID); err != nil {
+		return nil, err
+	}
+
+	query = `SELECT ` + reservationColumns + `
+			 FROM reservations r
+			 ` + reservationJoins + `
+			 WHERE r.person_id = ? AND r.status = 'reserved'
+			 ORDER BY r.starts_at`
+	if err := tx.SelectContext(ctx, &merge.Reservations, query, sourceID); err != nil {
+		return nil, err
+	}
+	query = `UPDATE reservations SET person_id = ? WHERE person_id = ? AND status = 'reserved'`
+	if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+		return nil, err
+	}
+
+	query = `UPDATE persons SET merged_into_id = ? WHERE merged_into_id = ?`
+	if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+		return nil, err
+	}
+	query = `UPDATE persons SET merged_into_id = ?, deleted_at = NOW(), updated_at = NOW() WHERE id = ?`
+	if _, err := tx.ExecContext(ctx, query, targetID, sourceID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// planAttributeMerge decides what happens to the attribute values of both persons of a merge.
+// Source values the target lacks are moved, equal or empty source values are dropped and
+// different values are resolved by the rules of the request: keeping the target drops the
+// source value, keeping the source drops the target value and moves the source value.
+func planAttributeMerge(source, target []models.PersonAttribute, req models.PersonMergeRequest) (move, drop []models.PersonAttribute, conflicts []models.AttributeConflict) {
+	targetByAttribute := make(map[int64]models.PersonAttribute, len(target))
+	for _, pa := range target {
+		targetByAttribute[pa.AttributeID] = pa
+	}
+
+	for _, s := range source {
+		t, exists := targetByAttribute[s.AttributeID]
+		switch {
+		case !exists || t.Value == "":
+			if exists {
+				drop = append(drop, t)
+			}
+			move = append(move, s)
+		case s.Value == "" || s.Value == t.Value:
+			drop = append(drop, s)
+		default:
+			keep, ok := req.Attributes[s.AttributeID]
+			if !ok {
+				keep = req.Keep
+			}
+			switch keep {
+			case models.MergeKeepTarget:
+				drop = append(drop, s)
+			case models.MergeKeepSource:
+				drop = append(drop, t)
+				move = append(move, s)
+			default:
+				conflicts = append(conflicts, models.AttributeConflict{
+					AttributeID:   s.AttributeID,
+					AttributeName: s.AttributeName,
+					SourceValue:   s.Value,
+					TargetValue:   t.Value,
+				})
+			}
+		}
+	}
+	return move, drop, conflicts
+}
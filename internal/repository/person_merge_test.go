@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func testPersonAttribute(id, personID, attributeID int64, value string) models.PersonAttribute {
+	return models.PersonAttribute{BaseModel: models.BaseModel{ID: id}, PersonID: personID, AttributeID: attributeID, Value: value}
+}
+
+func attributeIDs(pas []models.PersonAttribute) []int64 {
+	ids := make([]int64, 0, len(pas))
+	for _, pa := range pas {
+		ids = append(ids, pa.ID)
+	}
+	return ids
+}
+
+func TestPlanAttributeMerge(t *testing.T) {
+	source := []models.PersonAttribute{
+		testPersonAttribute(1, 10, 100, "Sales"),      // Target lacks the attribute
+		testPersonAttribute(2, 10, 101, "Berlin"),     // Same value
+		testPersonAttribute(3, 10, 102, "E-1001"),     // Conflict, resolved per attribute
+		testPersonAttribute(4, 10, 103, "Manager"),    // Conflict, resolved by Keep
+		testPersonAttribute(5, 10, 104, "2024-01-01"), // Target value is empty
+	}
+	target := []models.PersonAttribute{
+		testPersonAttribute(11, 20, 101, "Berlin"),
+		testPersonAttribute(12, 20, 102, "E-2002"),
+		testPersonAttribute(13, 20, 103, "Engineer"),
+		testPersonAttribute(14, 20, 104, ""),
+	}
+
+	req := models.PersonMergeRequest{SourceID: 10, Keep: models.MergeKeepTarget, Attributes: map[int64]models.MergeKeep{102: models.MergeKeepSource}}
+	move, drop, conflicts := planAttributeMerge(source, target, req)
+	if len(conflicts) != 0 {
+		t.Fatalf("Expected no conflicts, got %+v", conflicts)
+	}
+	if got, want := attributeIDs(move), []int64{1, 3, 5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected moved %v, got %v", want, got)
+	}
+	if got, want := attributeIDs(drop), []int64{2, 12, 4, 14}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected dropped %v, got %v", want, got)
+	}
+
+	_, _, conflicts = planAttributeMerge(source, target, models.PersonMergeRequest{SourceID: 10})
+	if len(conflicts) != 2 || conflicts[0].AttributeID != 102 || conflicts[0].SourceValue != "E-1001" || conflicts[0].TargetValue != "E-2002" {
+		t.Errorf("Expected conflicts on attributes 102 and 103, got %+v", conflicts)
+	}
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
-	"fmt"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/datatype"
+	"assetManager/internal/models"
 )
 
 type ReportRepository struct {
@@ -16,94 +19,140 @@ func NewReportRepository(db *sqlx.DB) *ReportRepository {
 	return &ReportRepository{db: db}
 }
 
-type FilterCondition struct {
-	Field         string      `json:"Field"`
-	Operator      string      `json:"Operator"`
-	Value         interface{} `json:"Value"`
-	LogicOperator string      `json:"LogicOperator"` // AND or OR
-}
-
 type CustomReportRequest struct {
 	EntityType string            `json:"EntityType"`
-	Filters    []FilterCondition `json:"Filters"`
+	Filters    []FilterCondition `json:"Filters"` // Flat list joined left to right with each LogicOperator
+	Filter     *FilterGroup      `json:"Filter"`  // Filter tree, combined with Filters using AND
+	Sort       *ReportSort       `json:"Sort"`    // Optional ordering, by name if empty
+	Format     string            `json:"Format"`  // Optional export format, JSON if empty
+	AsOf       models.NullTime   `json:"AsOf"`    // Optional point in time to reconstruct, now if empty
+}
+
+// ReportSort orders report results by a result key such as "purchased_at" or "prop_RAM"
+type ReportSort struct {
+	Field string `json:"Field"`
+	Desc  bool   `json:"Desc"`
+}
+
+// FilterTree returns the flat filters and the filter tree of the request as a single tree
+func (req *CustomReportRequest) FilterTree() *FilterGroup {
+	flat := FlatFilterGroup(req.Filters)
+	switch {
+	case req.Filter == nil:
+		return flat
+	case flat == nil:
+		return req.Filter
+	default:
+		return &FilterGroup{Logic: "AND", Groups: []FilterGroup{*flat, *req.Filter}}
+	}
+}
+
+// customValueBatchSize limits the number of IDs per batched property or attribute query
+const customValueBatchSize = 1000
+
+// InvalidValuesKey is the result key listing stored property or attribute values that do not
+// match their data type, mapped to the parse error
+const InvalidValuesKey = "_invalid"
+
+// ExecuteAssetReport returns the assets matching the filter with their assignee, location and
+// properties. A non-zero asOf reconstructs the assets, their status, assignee and location as
+// they were at that time.
+func (r *ReportRepository) ExecuteAssetReport(ctx context.Context, filter *FilterGroup, sort *ReportSort, asOf time.Time) ([]map[string]interface{}, error) {
+	query, args, dataTypes, err := r.assetReportQuery(ctx, filter, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []map[string]interface{}
+	err = r.streamReport(ctx, query, args, r.assetDecorator(ctx), func(result map[string]interface{}) error {
+		results = append(results, result)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sortReport(results, sort, "asset", dataTypes); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// StreamAssetReport passes the rows of ExecuteAssetReport to fn one at a time, ordered by name,
+// without holding the whole report in memory
+func (r *ReportRepository) StreamAssetReport(ctx context.Context, filter *FilterGroup, asOf time.Time, fn func(result map[string]interface{}) error) error {
+	query, args, _, err := r.assetReportQuery(ctx, filter, asOf)
+	if err != nil {
+		return err
+	}
+	return r.streamReport(ctx, query, args, r.assetDecorator(ctx), fn)
 }
 
-func (r *ReportRepository) ExecuteAssetReport(ctx context.Context, filters []FilterCondition) ([]map[string]interface{}, error) {
-	// Separate filters into SQL filters (base fields) and post filters (properties)
-	sqlFilters, postFilters := separateFilters(filters, "asset")
+// assetReportQuery builds the query of the asset report and returns it with its arguments and
+// the data types of the properties
+func (r *ReportRepository) assetReportQuery(ctx context.Context, filter *FilterGroup, asOf time.Time) (string, []interface{}, map[string]models.DataType, error) {
+	dataTypes, err := r.customFieldTypes(ctx, "properties")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	assetsTable, args := assetsAsOf(asOf)
+	args = append(args, asOfArg(asOf), asOfArg(asOf), asOfArg(asOf), asOfArg(asOf))
 
 	query := `
 		SELECT 
-			a.id, a.asset_type_id, a.name, a.model, a.serial_number, 
-			a.order_no, a.license_number, a.notes, a.purchased_at,
+			a.id, a.tag, a.asset_type_id, a.status, a.name, a.model, a.serial_number, 
+			a.order_no, a.license_number, a.notes, a.purchased_at, a.purchase_cost, a.currency,
 			a.created_at, a.updated_at, a.deleted_at,
 			at.name as asset_type_name,
-			COALESCE(p.name, 'Unassigned') as current_assignee,
-			asgn.person_id as current_assignee_id
-		FROM assets a
+			p.name as current_assignee,
+			asgn.person_id as current_assignee_id,
+			cl.location_id as location_id,
+			loc.name as location
+		FROM ` + assetsTable + ` a
 		LEFT JOIN asset_types at ON a.asset_type_id = at.id
 		LEFT JOIN (
 			SELECT asset_id, person_id, ROW_NUMBER() OVER (PARTITION BY asset_id ORDER BY effective_from DESC) as rn
 			FROM asset_assignments
-			WHERE effective_to IS NULL OR effective_to > NOW()
+			WHERE deleted_at IS NULL
+				AND effective_from <= COALESCE(?, NOW())
+				AND (effective_to IS NULL OR effective_to > COALESCE(?, NOW()))
 		) asgn ON a.id = asgn.asset_id AND asgn.rn = 1
 		LEFT JOIN persons p ON asgn.person_id = p.id
+		LEFT JOIN asset_locations cl ON a.id = cl.asset_id
+			AND cl.deleted_at IS NULL
+			AND cl.effective_from <= COALESCE(?, NOW())
+			AND (cl.effective_to IS NULL OR cl.effective_to > COALESCE(?, NOW()))
+		LEFT JOIN locations loc ON cl.location_id = loc.id
 	`
 
-	var args []interface{}
-	argCounter := 1
-
-	whereClause, whereArgs := buildWhereClauseWithLogic(sqlFilters, &argCounter)
+	whereClause, whereArgs, err := buildFilterClause(filter, "asset", dataTypes)
+	if err != nil {
+		return "", nil, nil, err
+	}
 	if whereClause != "" {
 		query += " WHERE " + whereClause
 		args = append(args, whereArgs...)
 	}
 
 	query += " ORDER BY a.name"
+	return query, args, dataTypes, nil
+}
 
-	rows, err := r.db.QueryxContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
+func (r *ReportRepository) ExecuteMultipleAssetsReport(ctx context.Context, assetTypeID int64) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
-	for rows.Next() {
-		result := make(map[string]interface{})
-		err := rows.MapScan(result)
-		if err != nil {
-			return nil, err
-		}
-		// Convert byte arrays to strings
-		convertBytesToStrings(result)
+	err := r.StreamMultipleAssetsReport(ctx, assetTypeID, func(result map[string]interface{}) error {
 		results = append(results, result)
-	}
-
-	if err = rows.Err(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
-
-	// Load properties for each asset
-	for _, result := range results {
-		assetID := result["id"]
-		properties, err := r.getAssetProperties(ctx, assetID)
-		if err != nil {
-			continue
-		}
-		for k, v := range properties {
-			result[k] = v
-		}
-	}
-
-	// Apply post-filters (property filters)
-	if len(postFilters) > 0 {
-		results = applyPostFilters(results, postFilters)
-	}
-
 	return results, nil
 }
 
-func (r *ReportRepository) ExecuteMultipleAssetsReport(ctx context.Context, assetTypeID int64) ([]map[string]interface{}, error) {
+// StreamMultipleAssetsReport passes the rows of ExecuteMultipleAssetsReport to fn one at a time
+func (r *ReportRepository) StreamMultipleAssetsReport(ctx context.Context, assetTypeID int64, fn func(result map[string]interface{}) error) error {
 	query := `
 		SELECT 
 			p.id, p.name, p.email, p.phone,
@@ -112,200 +161,158 @@ func (r *ReportRepository) ExecuteMultipleAssetsReport(ctx context.Context, asse
 		FROM persons p
 		INNER JOIN asset_assignments aa ON p.id = aa.person_id
 		INNER JOIN assets a ON aa.asset_id = a.id
-		WHERE p.name != 'Unassigned'
+		WHERE p.is_stock = FALSE
 			AND a.asset_type_id = ?
+			AND aa.deleted_at IS NULL
+			AND aa.effective_from <= NOW()
 			AND (aa.effective_to IS NULL OR aa.effective_to > NOW())
 			AND a.deleted_at IS NULL
 		GROUP BY p.id, p.name, p.email, p.phone, p.created_at, p.updated_at, p.deleted_at
 		HAVING COUNT(DISTINCT aa.asset_id) > 1
 		ORDER BY asset_count DESC, p.name
 	`
+	return r.streamReport(ctx, query, []interface{}{assetTypeID}, r.personDecorator(ctx), fn)
+}
 
-	rows, err := r.db.QueryxContext(ctx, query, assetTypeID)
+// ExecutePersonReport returns the persons matching the filter with their attributes. A non-zero
+// asOf returns the persons as they were at that time.
+func (r *ReportRepository) ExecutePersonReport(ctx context.Context, filter *FilterGroup, sort *ReportSort, asOf time.Time) ([]map[string]interface{}, error) {
+	query, args, dataTypes, err := r.personReportQuery(ctx, filter, asOf)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	var results []map[string]interface{}
-	for rows.Next() {
-		result := make(map[string]interface{})
-		err := rows.MapScan(result)
-		if err != nil {
-			return nil, err
-		}
-		// Convert byte arrays to strings
-		convertBytesToStrings(result)
+	err = r.streamReport(ctx, query, args, r.personDecorator(ctx), func(result map[string]interface{}) error {
 		results = append(results, result)
-	}
-
-	if err = rows.Err(); err != nil {
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	// Load attributes for each person
-	for _, result := range results {
-		personID := result["id"]
-		attributes, err := r.getPersonAttributes(ctx, personID)
-		if err != nil {
-			continue
-		}
-		for k, v := range attributes {
-			result[k] = v
-		}
+	if err := sortReport(results, sort, "person", dataTypes); err != nil {
+		return nil, err
 	}
 
 	return results, nil
 }
 
-func (r *ReportRepository) ExecutePersonReport(ctx context.Context, filters []FilterCondition) ([]map[string]interface{}, error) {
-	// Separate filters into SQL filters (base fields) and post filters (attributes)
-	sqlFilters, postFilters := separateFilters(filters, "person")
+// StreamPersonReport passes the rows of ExecutePersonReport to fn one at a time, ordered by name,
+// without holding the whole report in memory
+func (r *ReportRepository) StreamPersonReport(ctx context.Context, filter *FilterGroup, asOf time.Time, fn func(result map[string]interface{}) error) error {
+	query, args, _, err := r.personReportQuery(ctx, filter, asOf)
+	if err != nil {
+		return err
+	}
+	return r.streamReport(ctx, query, args, r.personDecorator(ctx), fn)
+}
+
+// personReportQuery builds the query of the person report and returns it with its arguments and
+// the data types of the attributes
+func (r *ReportRepository) personReportQuery(ctx context.Context, filter *FilterGroup, asOf time.Time) (string, []interface{}, map[string]models.DataType, error) {
+	dataTypes, err := r.customFieldTypes(ctx, "attributes")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	personsTable, args := personsAsOf(asOf)
 
 	query := `
 		SELECT 
-			p.id, p.name, p.email, p.phone,
+			p.id, p.name, p.email, p.phone, p.is_active, p.left_at,
 			p.created_at, p.updated_at, p.deleted_at
-		FROM persons p
-		WHERE p.name != 'Unassigned'
+		FROM ` + personsTable + ` p
+		WHERE p.is_stock = FALSE
 	`
 
-	var args []interface{}
-	argCounter := 1
-
-	whereClause, whereArgs := buildWhereClauseWithLogic(sqlFilters, &argCounter)
+	whereClause, whereArgs, err := buildFilterClause(filter, "person", dataTypes)
+	if err != nil {
+		return "", nil, nil, err
+	}
 	if whereClause != "" {
 		query += " AND " + whereClause
 		args = append(args, whereArgs...)
 	}
 
 	query += " ORDER BY p.name"
+	return query, args, dataTypes, nil
+}
 
+// streamReport runs a report query and passes each row as a map to fn. Rows are read in chunks
+// of customValueBatchSize that decorate completes, such as with their property values, before
+// they are passed on.
+func (r *ReportRepository) streamReport(ctx context.Context, query string, args []interface{}, decorate func(chunk []map[string]interface{}) error, fn func(result map[string]interface{}) error) error {
 	rows, err := r.db.QueryxContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var results []map[string]interface{}
+	chunk := make([]map[string]interface{}, 0, customValueBatchSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := decorate(chunk); err != nil {
+			return err
+		}
+		for _, result := range chunk {
+			if err := fn(result); err != nil {
+				return err
+			}
+		}
+		chunk = chunk[:0]
+		return nil
+	}
+
 	for rows.Next() {
 		result := make(map[string]interface{})
-		err := rows.MapScan(result)
-		if err != nil {
-			return nil, err
+		if err := rows.MapScan(result); err != nil {
+			return err
 		}
 		// Convert byte arrays to strings
 		convertBytesToStrings(result)
-		results = append(results, result)
-	}
-
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
-
-	// Load attributes for each person
-	for _, result := range results {
-		personID := result["id"]
-		attributes, err := r.getPersonAttributes(ctx, personID)
-		if err != nil {
-			continue
-		}
-		for k, v := range attributes {
-			result[k] = v
+		chunk = append(chunk, result)
+		if len(chunk) == customValueBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
 		}
 	}
 
-	// Apply post-filters (attribute filters)
-	if len(postFilters) > 0 {
-		results = applyPostFilters(results, postFilters)
+	if err := rows.Err(); err != nil {
+		return err
 	}
-
-	return results, nil
+	return flush()
 }
 
-func buildWhereClauseWithLogic(filters []FilterCondition, argCounter *int) (string, []interface{}) {
-	if len(filters) == 0 {
-		return "", nil
-	}
-
-	var clauses []string
-	var args []interface{}
-
-	for i, filter := range filters {
-		clause, arg := buildWhereClause(filter, argCounter)
-		if clause == "" {
-			continue
+// assetDecorator returns a decorator for streamReport adding the properties of assets and the
+// full path of their location, loading the location paths once
+func (r *ReportRepository) assetDecorator(ctx context.Context) func(chunk []map[string]interface{}) error {
+	var paths map[int64]string
+	return func(chunk []map[string]interface{}) error {
+		if err := r.loadCustomValues(ctx, chunk, assetPropertiesQuery, "prop_"); err != nil {
+			return err
 		}
-
-		// Add logic operator before this clause (except for the first one)
-		if len(clauses) > 0 {
-			logicOp := filters[i-1].LogicOperator // Use previous filter's operator
-			if logicOp != "OR" {
-				logicOp = "AND" // Default to AND
+		if paths == nil {
+			var err error
+			if paths, err = allLocationPaths(ctx, r.db); err != nil {
+				return err
 			}
-			clauses = append(clauses, logicOp)
 		}
-
-		// Add the clause
-		clauses = append(clauses, clause)
-		if arg != nil {
-			args = append(args, arg)
+		for _, result := range chunk {
+			if id, ok := reportID(result["location_id"]); ok {
+				result["location"] = paths[id]
+			}
 		}
+		return nil
 	}
-
-	if len(clauses) == 0 {
-		return "", nil
-	}
-
-	// Join all parts with spaces
-	return "(" + strings.Join(clauses, " ") + ")", args
 }
 
-func buildWhereClause(filter FilterCondition, argCounter *int) (string, interface{}) {
-	field := sanitizeFieldName(filter.Field)
-	if field == "" {
-		return "", nil
-	}
-
-	switch filter.Operator {
-	case "=":
-		clause := fmt.Sprintf("%s = ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case "!=":
-		clause := fmt.Sprintf("%s != ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case ">":
-		clause := fmt.Sprintf("%s > ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case "<":
-		clause := fmt.Sprintf("%s < ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case ">=":
-		clause := fmt.Sprintf("%s >= ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case "<=":
-		clause := fmt.Sprintf("%s <= ?", field)
-		*argCounter++
-		return clause, filter.Value
-	case "LIKE":
-		clause := fmt.Sprintf("%s LIKE ?", field)
-		*argCounter++
-		return clause, fmt.Sprintf("%%%v%%", filter.Value)
-	case "NOT LIKE":
-		clause := fmt.Sprintf("%s NOT LIKE ?", field)
-		*argCounter++
-		return clause, fmt.Sprintf("%%%v%%", filter.Value)
-	case "IS NULL":
-		return fmt.Sprintf("%s IS NULL", field), nil
-	case "IS NOT NULL":
-		return fmt.Sprintf("%s IS NOT NULL", field), nil
-	default:
-		return "", nil
+// personDecorator returns a decorator for streamReport adding the attributes of persons
+func (r *ReportRepository) personDecorator(ctx context.Context) func(chunk []map[string]interface{}) error {
+	return func(chunk []map[string]interface{}) error {
+		return r.loadCustomValues(ctx, chunk, personAttributesQuery, "attr_")
 	}
 }
 
@@ -317,186 +324,194 @@ func convertBytesToStrings(m map[string]interface{}) {
 	}
 }
 
-func separateFilters(filters []FilterCondition, entityType string) (sqlFilters, postFilters []FilterCondition) {
-	for _, filter := range filters {
-		// Check if this is a property or attribute filter
-		if strings.HasPrefix(filter.Field, "prop_") || strings.HasPrefix(filter.Field, "attr_") {
-			postFilters = append(postFilters, filter)
-		} else {
-			sqlFilters = append(sqlFilters, filter)
-		}
-	}
-	return sqlFilters, postFilters
-}
-
-func applyPostFilters(results []map[string]interface{}, filters []FilterCondition) []map[string]interface{} {
-	var filtered []map[string]interface{}
+const (
+	assetPropertiesQuery = `
+		SELECT ap.asset_id, prop.name, prop.data_type, ap.value
+		FROM assets_properties ap
+		JOIN properties prop ON ap.property_id = prop.id
+		WHERE ap.asset_id IN (?) AND ap.deleted_at IS NULL
+	`
+	personAttributesQuery = `
+		SELECT pa.person_id, attr.name, attr.data_type, pa.value
+		FROM persons_attributes pa
+		JOIN attributes attr ON pa.attribute_id = attr.id
+		WHERE pa.person_id IN (?) AND pa.deleted_at IS NULL
+	`
+)
 
+// loadCustomValues adds the property or attribute values to each result, keyed by prefix
+// and name. Values that do not parse as their data type are listed under InvalidValuesKey.
+// The query must select owner ID, name, data type and value for the IDs bound to IN (?).
+func (r *ReportRepository) loadCustomValues(ctx context.Context, results []map[string]interface{}, query, prefix string) error {
+	byID := make(map[int64]map[string]interface{}, len(results))
+	ids := make([]int64, 0, len(results))
 	for _, result := range results {
-		if matchesPostFilters(result, filters) {
-			filtered = append(filtered, result)
+		id, ok := reportID(result["id"])
+		if !ok {
+			continue
 		}
+		byID[id] = result
+		ids = append(ids, id)
 	}
 
-	return filtered
-}
-
-func matchesPostFilters(record map[string]interface{}, filters []FilterCondition) bool {
-	// Track the current match state and logic operators
-	if len(filters) == 0 {
-		return true
-	}
+	for start := 0; start < len(ids); start += customValueBatchSize {
+		end := min(start+customValueBatchSize, len(ids))
 
-	// Evaluate filters with AND/OR logic
-	currentMatch := true
-	nextOperator := "AND"
-
-	for i, filter := range filters {
-		fieldMatch := matchesFilter(record, filter)
+		batchQuery, args, err := sqlx.In(query, ids[start:end])
+		if err != nil {
+			return err
+		}
+		rows, err := r.db.QueryxContext(ctx, r.db.Rebind(batchQuery), args...)
+		if err != nil {
+			return err
+		}
 
-		// Apply the logic operator
-		if i == 0 {
-			currentMatch = fieldMatch
-		} else {
-			if nextOperator == "OR" {
-				currentMatch = currentMatch || fieldMatch
-			} else { // AND
-				currentMatch = currentMatch && fieldMatch
+		for rows.Next() {
+			var id int64
+			var name string
+			var dataType models.DataType
+			var value []byte
+			if err := rows.Scan(&id, &name, &dataType, &value); err != nil {
+				rows.Close()
+				return err
+			}
+			result, ok := byID[id]
+			if !ok {
+				continue
+			}
+			// Convert byte array to string for TEXT columns
+			result[prefix+name] = string(value)
+			if len(value) > 0 {
+				if _, err := datatype.Parse(dataType, string(value)); err != nil {
+					markInvalid(result, prefix+name, err)
+				}
 			}
 		}
-
-		// Set next operator (from current filter's LogicOperator)
-		nextOperator = filter.LogicOperator
-		if nextOperator != "OR" {
-			nextOperator = "AND"
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
 		}
 	}
 
-	return currentMatch
+	return nil
 }
 
-func matchesFilter(record map[string]interface{}, filter FilterCondition) bool {
-	fieldValue, exists := record[filter.Field]
-
-	switch filter.Operator {
-	case "IS NULL":
-		return !exists || fieldValue == nil || fieldValue == ""
-	case "IS NOT NULL":
-		return exists && fieldValue != nil && fieldValue != ""
-	}
-
-	if !exists {
-		return false
-	}
-
-	// Convert to string for comparison
-	fieldStr := fmt.Sprintf("%v", fieldValue)
-	filterStr := fmt.Sprintf("%v", filter.Value)
-
-	switch filter.Operator {
-	case "=":
-		return fieldStr == filterStr
-	case "!=":
-		return fieldStr != filterStr
-	case "LIKE":
-		return strings.Contains(strings.ToLower(fieldStr), strings.ToLower(filterStr))
-	case "NOT LIKE":
-		return !strings.Contains(strings.ToLower(fieldStr), strings.ToLower(filterStr))
-	case ">":
-		return fieldStr > filterStr
-	case "<":
-		return fieldStr < filterStr
-	case ">=":
-		return fieldStr >= filterStr
-	case "<=":
-		return fieldStr <= filterStr
-	default:
-		return false
+// markInvalid records a value that does not match its data type on a result row
+func markInvalid(result map[string]interface{}, key string, err error) {
+	invalid, ok := result[InvalidValuesKey].(map[string]string)
+	if !ok {
+		invalid = make(map[string]string)
+		result[InvalidValuesKey] = invalid
 	}
+	invalid[key] = err.Error()
 }
 
-func sanitizeFieldName(field string) string {
-	// Map frontend field names to database column names
-	fieldMap := map[string]string{
-		"ID":              "a.id",
-		"Name":            "a.name",
-		"AssetTypeName":   "at.name",
-		"Model":           "a.model",
-		"SerialNumber":    "a.serial_number",
-		"OrderNo":         "a.order_no",
-		"LicenseNumber":   "a.license_number",
-		"Notes":           "a.notes",
-		"PurchasedAt":     "a.purchased_at",
-		"CurrentAssignee": "p.name",
-		"Email":           "p.email",
-		"Phone":           "p.phone",
-		"PersonName":      "p.name",
-		"PersonEmail":     "p.email",
-		"PersonPhone":     "p.phone",
-	}
-
-	if mapped, ok := fieldMap[field]; ok {
-		return mapped
-	}
-
-	// Property and attribute fields are prefixed with prop_ or attr_
-	// These cannot be filtered in the main query and should be ignored
-	// (they need to be filtered at the application level after loading)
-	return ""
-}
+// customFieldTypes maps the names of active properties or attributes to their data types
+func (r *ReportRepository) customFieldTypes(ctx context.Context, table string) (map[string]models.DataType, error) {
+	var fields []struct {
+		Name     string          `db:"name"`
+		DataType models.DataType `db:"data_type"`
+	}
+	query := `SELECT name, data_type FROM ` + table + ` WHERE deleted_at IS NULL`
+	if err := r.db.SelectContext(ctx, &fields, query); err != nil {
+		return nil, err
+	}
 
-func (r *ReportRepository) getAssetProperties(ctx context.Context, assetID interface{}) (map[string]interface{}, error) {
-	query := `
-		SELECT prop.name, ap.value
-		FROM assets_properties ap
-		JOIN properties prop ON ap.property_id = prop.id
-		WHERE ap.asset_id = ?
-	`
+	dataTypes := make(map[string]models.DataType, len(fields))
+	for _, field := range fields {
+		dataTypes[field.Name] = field.DataType
+	}
+	return dataTypes, nil
+}
 
-	rows, err := r.db.QueryxContext(ctx, query, assetID)
-	if err != nil {
-		return nil, err
+// reportID reads a row ID, which the driver returns as int64 or, over the text protocol, as a string
+func reportID(v interface{}) (int64, bool) {
+	switch id := v.(type) {
+	case int64:
+		return id, true
+	case string:
+		n, err := strconv.ParseInt(id, 10, 64)
+		return n, err == nil
+	default:
+		return 0, false
 	}
-	defer rows.Close()
+}
 
-	properties := make(map[string]interface{})
-	for rows.Next() {
-		var name string
-		var value []byte
-		if err := rows.Scan(&name, &value); err != nil {
-			continue
-		}
-		// Convert byte array to string for TEXT columns
-		properties["prop_"+name] = string(value)
+// Base columns of each report in output order; property and attribute columns follow
+var (
+	assetReportColumns = []models.ReportColumn{
+		{Key: "id", Label: "ID"},
+		{Key: "tag", Label: "Tag"},
+		{Key: "name", Label: "Name"},
+		{Key: "asset_type_id", Label: "Asset Type ID"},
+		{Key: "asset_type_name", Label: "Asset Type"},
+		{Key: "status", Label: "Status"},
+		{Key: "model", Label: "Model"},
+		{Key: "serial_number", Label: "Serial Number"},
+		{Key: "order_no", Label: "Order No"},
+		{Key: "license_number", Label: "License Number"},
+		{Key: "purchased_at", Label: "Purchased At"},
+		{Key: "purchase_cost", Label: "Purchase Cost"},
+		{Key: "currency", Label: "Currency"},
+		{Key: "current_assignee_id", Label: "Current Assignee ID"},
+		{Key: "current_assignee", Label: "Current Assignee"},
+		{Key: "location_id", Label: "Location ID"},
+		{Key: "location", Label: "Location"},
+		{Key: "notes", Label: "Notes"},
+		{Key: "created_at", Label: "Created At"},
+		{Key: "updated_at", Label: "Updated At"},
+		{Key: "deleted_at", Label: "Deleted At"},
+	}
+	personReportColumns = []models.ReportColumn{
+		{Key: "id", Label: "ID"},
+		{Key: "name", Label: "Name"},
+		{Key: "email", Label: "Email"},
+		{Key: "phone", Label: "Phone"},
+		{Key: "is_active", Label: "Active"},
+		{Key: "left_at", Label: "Left At"},
+		{Key: "created_at", Label: "Created At"},
+		{Key: "updated_at", Label: "Updated At"},
+		{Key: "deleted_at", Label: "Deleted At"},
+	}
+	multipleAssetsReportColumns = []models.ReportColumn{
+		{Key: "id", Label: "ID"},
+		{Key: "name", Label: "Name"},
+		{Key: "email", Label: "Email"},
+		{Key: "phone", Label: "Phone"},
+		{Key: "asset_count", Label: "Asset Count"},
+		{Key: "created_at", Label: "Created At"},
+		{Key: "updated_at", Label: "Updated At"},
+		{Key: "deleted_at", Label: "Deleted At"},
 	}
+)
 
-	return properties, nil
+// AssetReportColumns returns the columns of the asset report, including one per property
+func (r *ReportRepository) AssetReportColumns(ctx context.Context) ([]models.ReportColumn, error) {
+	return r.reportColumns(ctx, assetReportColumns, "properties", "prop_")
 }
 
-func (r *ReportRepository) getPersonAttributes(ctx context.Context, personID interface{}) (map[string]interface{}, error) {
-	query := `
-		SELECT attr.name, pa.value
-		FROM persons_attributes pa
-		JOIN attributes attr ON pa.attribute_id = attr.id
-		WHERE pa.person_id = ?
-	`
+// PersonReportColumns returns the columns of the person report, including one per attribute
+func (r *ReportRepository) PersonReportColumns(ctx context.Context) ([]models.ReportColumn, error) {
+	return r.reportColumns(ctx, personReportColumns, "attributes", "attr_")
+}
 
-	rows, err := r.db.QueryxContext(ctx, query, personID)
-	if err != nil {
+// MultipleAssetsReportColumns returns the columns of the multiple assets report, including one per attribute
+func (r *ReportRepository) MultipleAssetsReportColumns(ctx context.Context) ([]models.ReportColumn, error) {
+	return r.reportColumns(ctx, multipleAssetsReportColumns, "attributes", "attr_")
+}
+
+// reportColumns appends a column for each active property or attribute, ordered by name
+func (r *ReportRepository) reportColumns(ctx context.Context, base []models.ReportColumn, table, prefix string) ([]models.ReportColumn, error) {
+	var names []string
+	query := `SELECT name FROM ` + table + ` WHERE deleted_at IS NULL ORDER BY name`
+	if err := r.db.SelectContext(ctx, &names, query); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	attributes := make(map[string]interface{})
-	for rows.Next() {
-		var name string
-		var value []byte
-		if err := rows.Scan(&name, &value); err != nil {
-			continue
-		}
-		// Convert byte array to string for TEXT columns
-		attributes["attr_"+name] = string(value)
+	columns := append([]models.ReportColumn{}, base...)
+	for _, name := range names {
+		columns = append(columns, models.ReportColumn{Key: prefix + name, Label: name})
 	}
-
-	return attributes, nil
+	return columns, nil
 }
@@ -0,0 +1,527 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"assetManager/internal/datatype"
+	"assetManager/internal/models"
+)
+
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// maxFilterDepth limits how deeply filter groups may be nested
+const maxFilterDepth = 10
+
+type FilterCondition struct {
+	Field         string      `json:"Field"`
+	Operator      string      `json:"Operator"`
+	Value         interface{} `json:"Value"`
+	LogicOperator string      `json:"LogicOperator"` // AND or OR, only used in flat filter lists
+}
+
+// FilterGroup is a node of a filter tree. Its conditions and nested groups are joined with Logic.
+type FilterGroup struct {
+	Logic      string            `json:"Logic"` // AND or OR, defaults to AND
+	Conditions []FilterCondition `json:"Conditions"`
+	Groups     []FilterGroup     `json:"Groups"`
+}
+
+// FlatFilterGroup converts a flat filter list into a tree. As in SQL, AND binds tighter than OR.
+func FlatFilterGroup(filters []FilterCondition) *FilterGroup {
+	if len(filters) == 0 {
+		return nil
+	}
+
+	root := &FilterGroup{Logic: "OR"}
+	current := FilterGroup{Logic: "AND"}
+	for i, filter := range filters {
+		current.Conditions = append(current.Conditions, filter)
+		// Each filter's LogicOperator joins it with the next one
+		if i < len(filters)-1 && strings.EqualFold(filter.LogicOperator, "OR") {
+			root.Groups = append(root.Groups, current)
+			current = FilterGroup{Logic: "AND"}
+		}
+	}
+	root.Groups = append(root.Groups, current)
+	return root
+}
+
+// buildFilterClause compiles a filter tree into a WHERE expression for the given report entity.
+// dataTypes maps property or attribute names to their data types, so that their text values
+// are compared as numbers, booleans or dates.
+func buildFilterClause(group *FilterGroup, entityType string, dataTypes map[string]models.DataType) (string, []interface{}, error) {
+	if group == nil {
+		return "", nil, nil
+	}
+	return buildGroupClause(*group, entityType, dataTypes, 1)
+}
+
+func buildGroupClause(group FilterGroup, entityType string, dataTypes map[string]models.DataType, depth int) (string, []interface{}, error) {
+	if depth > maxFilterDepth {
+		return "", nil, fmt.Errorf("%w: groups may be nested at most %d levels deep", ErrInvalidFilter, maxFilterDepth)
+	}
+
+	logic := strings.ToUpper(group.Logic)
+	switch logic {
+	case "":
+		logic = "AND"
+	case "AND", "OR":
+	default:
+		return "", nil, fmt.Errorf("%w: logic must be AND or OR, got %q", ErrInvalidFilter, group.Logic)
+	}
+
+	var clauses []string
+	var args []interface{}
+
+	for _, condition := range group.Conditions {
+		clause, clauseArgs, err := buildWhereClause(condition, entityType, dataTypes)
+		if err != nil {
+			return "", nil, err
+		}
+		if clause == "" {
+			continue
+		}
+		clauses = append(clauses, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	for _, child := range group.Groups {
+		clause, clauseArgs, err := buildGroupClause(child, entityType, dataTypes, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+		if clause == "" {
+			continue
+		}
+		clauses = append(clauses, clause)
+		args = append(args, clauseArgs...)
+	}
+
+	if len(clauses) == 0 {
+		return "", nil, nil
+	}
+
+	return "(" + strings.Join(clauses, " "+logic+" ") + ")", args, nil
+}
+
+func buildWhereClause(filter FilterCondition, entityType string, dataTypes map[string]models.DataType) (string, []interface{}, error) {
+	// Property and attribute conditions test the value rows of the current record
+	if subquery, name, ok := customFieldSubquery(filter.Field, entityType); ok {
+		switch filter.Operator {
+		case "IS NULL":
+			// A missing or empty value counts as NULL
+			return fmt.Sprintf("NOT EXISTS (%s AND cv.value IS NOT NULL AND cv.value != '')", subquery), []interface{}{name}, nil
+		case "IS NOT NULL":
+			return fmt.Sprintf("EXISTS (%s AND cv.value IS NOT NULL AND cv.value != '')", subquery), []interface{}{name}, nil
+		}
+
+		column := "cv.value"
+		guard, expr, typed := typedValueExpr(dataTypes[name])
+		if typed && typedOperators[strings.ToUpper(filter.Operator)] {
+			var err error
+			if filter, err = normalizeFilterValue(filter, dataTypes[name]); err != nil {
+				return "", nil, err
+			}
+			column = expr
+		}
+
+		clause, args, err := buildComparison(column, filter)
+		if err != nil {
+			return "", nil, err
+		}
+		if column != "cv.value" {
+			// Stored values that do not parse as the data type never match
+			clause = guard + " AND " + clause
+		}
+		return fmt.Sprintf("EXISTS (%s AND %s)", subquery, clause), append([]interface{}{name}, args...), nil
+	}
+
+	if op := strings.ToUpper(filter.Operator); op == "IN SUBTREE" || op == "NOT IN SUBTREE" {
+		return buildSubtreeClause(filter, entityType)
+	}
+
+	field := sanitizeFieldName(filter.Field, entityType)
+	if field == "" {
+		return "", nil, fmt.Errorf("%w: unknown field %q", ErrInvalidFilter, filter.Field)
+	}
+	return buildComparison(field, filter)
+}
+
+// buildSubtreeClause matches assets currently placed at a location or anywhere below it.
+// NOT IN SUBTREE also matches assets without a location.
+func buildSubtreeClause(filter FilterCondition, entityType string) (string, []interface{}, error) {
+	op := strings.ToUpper(filter.Operator)
+	if entityType != "asset" || filter.Field != "LocationID" {
+		return "", nil, fmt.Errorf("%w: %s is only supported on the LocationID field of assets", ErrInvalidFilter, op)
+	}
+	locationID, err := strconv.ParseInt(strings.TrimSpace(fmt.Sprint(filter.Value)), 10, 64)
+	if err != nil || locationID <= 0 {
+		return "", nil, fmt.Errorf("%w: %s requires a location ID, got %v", ErrInvalidFilter, op, filter.Value)
+	}
+
+	if op == "NOT IN SUBTREE" {
+		return "(cl.location_id IS NULL OR cl.location_id NOT IN (" + locationSubtreeQuery + "))", []interface{}{locationID}, nil
+	}
+	return "cl.location_id IN (" + locationSubtreeQuery + ")", []interface{}{locationID}, nil
+}
+
+// buildComparison compiles a single operator against a column
+func buildComparison(column string, filter FilterCondition) (string, []interface{}, error) {
+	switch op := strings.ToUpper(filter.Operator); op {
+	case "=", "!=", ">", "<", ">=", "<=":
+		return fmt.Sprintf("%s %s ?", column, op), []interface{}{filter.Value}, nil
+	case "LIKE", "NOT LIKE":
+		return fmt.Sprintf("%s %s ?", column, op), []interface{}{fmt.Sprintf("%%%v%%", filter.Value)}, nil
+	case "STARTS WITH":
+		return fmt.Sprintf("%s LIKE ?", column), []interface{}{escapeLike(fmt.Sprint(filter.Value)) + "%"}, nil
+	case "ENDS WITH":
+		return fmt.Sprintf("%s LIKE ?", column), []interface{}{"%" + escapeLike(fmt.Sprint(filter.Value))}, nil
+	case "REGEXP":
+		pattern, ok := filter.Value.(string)
+		if !ok || pattern == "" {
+			return "", nil, fmt.Errorf("%w: %s on %s requires a pattern", ErrInvalidFilter, op, filter.Field)
+		}
+		return fmt.Sprintf("%s REGEXP ?", column), []interface{}{pattern}, nil
+	case "IN", "NOT IN":
+		values := filterValues(filter.Value)
+		if len(values) == 0 {
+			return "", nil, fmt.Errorf("%w: %s on %s requires at least one value", ErrInvalidFilter, op, filter.Field)
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return fmt.Sprintf("%s %s (%s)", column, op, placeholders), values, nil
+	case "BETWEEN":
+		values := filterValues(filter.Value)
+		if len(values) != 2 {
+			return "", nil, fmt.Errorf("%w: BETWEEN on %s requires exactly two values", ErrInvalidFilter, filter.Field)
+		}
+		return fmt.Sprintf("%s BETWEEN ? AND ?", column), values, nil
+	case "WITHIN LAST", "WITHIN NEXT":
+		from, to, err := relativeDateRange(op, filter.Value, time.Now())
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: %s on %s: %v", ErrInvalidFilter, op, filter.Field, err)
+		}
+		return fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), []interface{}{from, to}, nil
+	case "IS NULL":
+		return fmt.Sprintf("%s IS NULL", column), nil, nil
+	case "IS NOT NULL":
+		return fmt.Sprintf("%s IS NOT NULL", column), nil, nil
+	default:
+		return "", nil, fmt.Errorf("%w: unsupported operator %q", ErrInvalidFilter, filter.Operator)
+	}
+}
+
+// typedOperators compare values by their data type rather than as text
+var typedOperators = map[string]bool{
+	"=": true, "!=": true, ">": true, "<": true, ">=": true, "<=": true,
+	"IN": true, "NOT IN": true, "BETWEEN": true, "WITHIN LAST": true, "WITHIN NEXT": true,
+}
+
+// typedValueExpr returns a guard accepting only well-formed stored values of the data type and
+// the expression converting cv.value for comparison. Strings and enums compare as text.
+// The patterns match the forms accepted by datatype.Parse.
+func typedValueExpr(dataType models.DataType) (string, string, bool) {
+	switch dataType {
+	case models.DataTypeInt:
+		return `TRIM(cv.value) REGEXP '^[+-]{0,1}[0-9]+$'`, "CAST(TRIM(cv.value) AS SIGNED)", true
+	case models.DataTypeDecimal:
+		return `TRIM(cv.value) REGEXP '^[+-]{0,1}([0-9]+([.][0-9]*){0,1}|[.][0-9]+)([eE][+-]{0,1}[0-9]+){0,1}$'`,
+			"CAST(TRIM(cv.value) AS DECIMAL(65,20))", true
+	case models.DataTypeBoolean:
+		return `LOWER(TRIM(cv.value)) IN ('true', '1', 'yes', 'on', 'false', '0', 'no', 'off')`,
+			"(LOWER(TRIM(cv.value)) IN ('true', '1', 'yes', 'on'))", true
+	case models.DataTypeDate:
+		return `TRIM(cv.value) REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}$'`, "CAST(TRIM(cv.value) AS DATE)", true
+	case models.DataTypeDatetime:
+		return `TRIM(cv.value) REGEXP '^[0-9]{4}-[0-9]{2}-[0-9]{2}([T ][0-9]{2}:[0-9]{2}.*){0,1}$'`,
+			"CAST(REPLACE(LEFT(TRIM(cv.value), 19), 'T', ' ') AS DATETIME)", true
+	default:
+		return "", "", false
+	}
+}
+
+// normalizeFilterValue parses the filter value(s) as the data type and converts them to SQL arguments
+func normalizeFilterValue(filter FilterCondition, dataType models.DataType) (FilterCondition, error) {
+	op := strings.ToUpper(filter.Operator)
+	if op == "WITHIN LAST" || op == "WITHIN NEXT" {
+		if dataType != models.DataTypeDate && dataType != models.DataTypeDatetime {
+			return filter, fmt.Errorf("%w: %s requires a date field, %s is %s", ErrInvalidFilter, op, filter.Field, dataType)
+		}
+		return filter, nil
+	}
+
+	convert := func(v interface{}) (interface{}, error) {
+		parsed, err := datatype.Parse(dataType, fmt.Sprint(v))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFilter, filter.Field, err)
+		}
+		switch val := parsed.(type) {
+		case bool:
+			if val {
+				return 1, nil
+			}
+			return 0, nil
+		case time.Time:
+			if dataType == models.DataTypeDate {
+				return val.Format(datatype.DateLayout), nil
+			}
+			return val.Format(datatype.DatetimeLayout), nil
+		default:
+			return val, nil
+		}
+	}
+
+	if op == "IN" || op == "NOT IN" || op == "BETWEEN" {
+		values := filterValues(filter.Value)
+		converted := make([]interface{}, len(values))
+		for i, v := range values {
+			c, err := convert(v)
+			if err != nil {
+				return filter, err
+			}
+			converted[i] = c
+		}
+		filter.Value = converted
+		return filter, nil
+	}
+
+	value, err := convert(filter.Value)
+	if err != nil {
+		return filter, err
+	}
+	filter.Value = value
+	return filter, nil
+}
+
+// sortReport orders results by a result key. Property and attribute values are compared by
+// their data type; missing and invalid values always sort last.
+func sortReport(results []map[string]interface{}, sort *ReportSort, entityType string, dataTypes map[string]models.DataType) error {
+	if sort == nil || sort.Field == "" {
+		return nil
+	}
+
+	base, prefix := personReportColumns, "attr_"
+	if entityType == "asset" {
+		base, prefix = assetReportColumns, "prop_"
+	}
+
+	var dataType models.DataType
+	switch {
+	case strings.HasPrefix(sort.Field, prefix):
+		dataType = dataTypes[strings.TrimPrefix(sort.Field, prefix)]
+	case hasColumn(base, sort.Field):
+		dataType = baseColumnTypes[sort.Field]
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidFilter, sort.Field)
+	}
+
+	keys := make([]interface{}, len(results))
+	for i, result := range results {
+		keys[i] = sortKey(result[sort.Field], dataType)
+	}
+
+	indexes := make([]int, len(results))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	slices.SortStableFunc(indexes, func(i, j int) int {
+		a, b := keys[i], keys[j]
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return 1
+		case b == nil:
+			return -1
+		}
+		if sort.Desc {
+			return datatype.Compare(b, a)
+		}
+		return datatype.Compare(a, b)
+	})
+
+	sorted := make([]map[string]interface{}, len(results))
+	for i, index := range indexes {
+		sorted[i] = results[index]
+	}
+	copy(results, sorted)
+	return nil
+}
+
+// baseColumnTypes lists base report columns that the driver may return as text but sort as numbers
+var baseColumnTypes = map[string]models.DataType{
+	"id":                  models.DataTypeInt,
+	"asset_type_id":       models.DataTypeInt,
+	"current_assignee_id": models.DataTypeInt,
+	"location_id":         models.DataTypeInt,
+	"purchase_cost":       models.DataTypeDecimal,
+	"asset_count":         models.DataTypeInt,
+}
+
+// sortKey converts a result value for sorting, returning nil for missing and invalid values
+func sortKey(value interface{}, dataType models.DataType) interface{} {
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if text == "" {
+		return nil
+	}
+	if dataType == "" {
+		return text
+	}
+	parsed, err := datatype.Parse(dataType, text)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+func hasColumn(columns []models.ReportColumn, key string) bool {
+	for _, col := range columns {
+		if col.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// customFieldSubquery returns the subquery selecting the value rows of a prop_ field of an
+// asset report or an attr_ field of a person report, aliased as cv, and the name to bind to it
+func customFieldSubquery(field, entityType string) (string, string, bool) {
+	switch {
+	case strings.HasPrefix(field, "prop_") && entityType == "asset":
+		return `SELECT 1 FROM assets_properties cv
+			JOIN properties cf ON cv.property_id = cf.id
+			WHERE cv.asset_id = a.id AND cv.deleted_at IS NULL AND cf.name = ?`, strings.TrimPrefix(field, "prop_"), true
+	case strings.HasPrefix(field, "attr_") && entityType == "person":
+		return `SELECT 1 FROM persons_attributes cv
+			JOIN attributes cf ON cv.attribute_id = cf.id
+			WHERE cv.person_id = p.id AND cv.deleted_at IS NULL AND cf.name = ?`, strings.TrimPrefix(field, "attr_"), true
+	default:
+		return "", "", false
+	}
+}
+
+// reportFields maps the filter fields of each report entity to database columns. The asset
+// report joins the current assignee as p, so person fields filter assets by their assignee.
+var reportFields = map[string]map[string]string{
+	"asset": {
+		"ID":              "a.id",
+		"Tag":             "a.tag",
+		"Name":            "a.name",
+		"AssetTypeName":   "at.name",
+		"Status":          "a.status",
+		"Model":           "a.model",
+		"SerialNumber":    "a.serial_number",
+		"OrderNo":         "a.order_no",
+		"LicenseNumber":   "a.license_number",
+		"Notes":           "a.notes",
+		"PurchasedAt":     "a.purchased_at",
+		"PurchaseCost":    "a.purchase_cost",
+		"Currency":        "a.currency",
+		"CurrentAssignee": "p.name",
+		"LocationID":      "cl.location_id",
+		"Location":        "loc.name",
+		"Email":           "p.email",
+		"Phone":           "p.phone",
+		"PersonName":      "p.name",
+		"PersonEmail":     "p.email",
+		"PersonPhone":     "p.phone",
+		"PersonLeftAt":    "p.left_at",
+	},
+	"person": {
+		"Email":        "p.email",
+		"Phone":        "p.phone",
+		"PersonName":   "p.name",
+		"PersonEmail":  "p.email",
+		"PersonPhone":  "p.phone",
+		"PersonLeftAt": "p.left_at",
+	},
+}
+
+// sanitizeFieldName maps a filter field of the report entity to its database column, "" if the
+// entity has no such field. Property and attribute fields are handled by customFieldSubquery.
+func sanitizeFieldName(field, entityType string) string {
+	return reportFields[entityType][field]
+}
+
+// filterValues returns the values of a list operator, given as a JSON array or a comma-separated string
+func filterValues(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return v
+	case string:
+		var values []interface{}
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+		return values
+	default:
+		return []interface{}{v}
+	}
+}
+
+// escapeLike escapes the LIKE wildcards in a literal value
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+var relativePeriodPattern = regexp.MustCompile(`^(\d+)\s*(d|days?|w|weeks?|m|months?|y|years?)?$`)
+
+// relativeDateRange returns the half-open date range [from, to) covered by "WITHIN LAST" or
+// "WITHIN NEXT" a period such as 30, "30d", "2 weeks", "6m" or "1y". Plain numbers are days.
+// The range always includes today.
+func relativeDateRange(op string, value interface{}, now time.Time) (string, string, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	sign := 1
+	if op == "WITHIN LAST" {
+		sign = -1
+	}
+	shifted, err := AddPeriod(today, fmt.Sprint(value), sign)
+	if err != nil {
+		return "", "", err
+	}
+
+	const layout = "2006-01-02"
+	tomorrow := today.AddDate(0, 0, 1)
+	if op == "WITHIN LAST" {
+		return shifted.Format(layout), tomorrow.Format(layout), nil
+	}
+	return today.Format(layout), shifted.AddDate(0, 0, 1).Format(layout), nil
+}
+
+// AddPeriod moves t forward (sign 1) or back (sign -1) by a period such as 30, "30d",
+// "2 weeks", "6m" or "1y". Plain numbers are days.
+func AddPeriod(t time.Time, period string, sign int) (time.Time, error) {
+	period = strings.ToLower(strings.TrimSpace(period))
+	match := relativePeriodPattern.FindStringSubmatch(period)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("invalid period %q", period)
+	}
+	amount, err := strconv.Atoi(match[1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid period %q", period)
+	}
+
+	n := sign * amount
+	switch match[2][:min(1, len(match[2]))] {
+	case "w":
+		return t.AddDate(0, 0, 7*n), nil
+	case "m":
+		return t.AddDate(0, n, 0), nil
+	case "y":
+		return t.AddDate(n, 0, 0), nil
+	default:
+		return t.AddDate(0, 0, n), nil
+	}
+}
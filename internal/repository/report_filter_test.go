@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"assetManager/internal/models"
+)
+
+func TestBuildFilterClause_FlatFilters(t *testing.T) {
+	filters := []FilterCondition{
+		{Field: "AssetTypeName", Operator: "=", Value: "Laptop", LogicOperator: "OR"},
+		{Field: "prop_RAM", Operator: "=", Value: "16", LogicOperator: "AND"},
+		{Field: "prop_Color", Operator: "IS NULL"},
+	}
+
+	clause, args, err := buildFilterClause(FlatFilterGroup(filters), "asset", nil)
+	if err != nil {
+		t.Fatalf("buildFilterClause failed: %v", err)
+	}
+
+	// AND binds tighter than OR, and property conditions are part of the same expression
+	if !strings.HasPrefix(clause, "((at.name = ?) OR (EXISTS (") {
+		t.Errorf("Unexpected clause start: %s", clause)
+	}
+	if !strings.Contains(clause, "AND cv.value = ?) AND NOT EXISTS (") {
+		t.Errorf("Expected property conditions joined in SQL, got %s", clause)
+	}
+	want := []interface{}{"Laptop", "RAM", "16", "Color"}
+	if !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+}
+
+func TestBuildFilterClause_NestedGroups(t *testing.T) {
+	filter := &FilterGroup{
+		Logic:      "AND",
+		Conditions: []FilterCondition{{Field: "CurrentAssignee", Operator: "IS NULL"}},
+		Groups: []FilterGroup{{
+			Logic: "or",
+			Conditions: []FilterCondition{
+				{Field: "AssetTypeName", Operator: "=", Value: "Laptop"},
+				{Field: "AssetTypeName", Operator: "=", Value: "Tablet"},
+			},
+		}},
+	}
+
+	clause, args, err := buildFilterClause(filter, "asset", nil)
+	if err != nil {
+		t.Fatalf("buildFilterClause failed: %v", err)
+	}
+	if want := "(p.name IS NULL AND (at.name = ? OR at.name = ?))"; clause != want {
+		t.Errorf("Expected %s, got %s", want, clause)
+	}
+	if want := []interface{}{"Laptop", "Tablet"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+}
+
+func TestBuildFilterClause_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter FilterGroup
+	}{
+		{"unknown logic", FilterGroup{Logic: "XOR"}},
+		{"unknown operator", FilterGroup{Conditions: []FilterCondition{{Field: "Name", Operator: "~"}}}},
+		{"between needs two values", FilterGroup{Conditions: []FilterCondition{{Field: "Name", Operator: "BETWEEN", Value: "a"}}}},
+		{"in needs values", FilterGroup{Conditions: []FilterCondition{{Field: "Name", Operator: "IN", Value: []interface{}{}}}}},
+		{"bad period", FilterGroup{Conditions: []FilterCondition{{Field: "PurchasedAt", Operator: "WITHIN LAST", Value: "soon"}}}},
+		{"unknown field", FilterGroup{Conditions: []FilterCondition{{Field: "Colour", Operator: "=", Value: "red"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := buildFilterClause(&tt.filter, "asset", nil); !errors.Is(err, ErrInvalidFilter) {
+				t.Errorf("Expected ErrInvalidFilter, got %v", err)
+			}
+		})
+	}
+}
+
+func TestBuildComparison_Operators(t *testing.T) {
+	tests := []struct {
+		filter     FilterCondition
+		wantClause string
+		wantArgs   []interface{}
+	}{
+		{FilterCondition{Operator: "IN", Value: []interface{}{"A", "B"}}, "c IN (?, ?)", []interface{}{"A", "B"}},
+		{FilterCondition{Operator: "NOT IN", Value: "A, B"}, "c NOT IN (?, ?)", []interface{}{"A", "B"}},
+		{FilterCondition{Operator: "BETWEEN", Value: []interface{}{"2024-01-01", "2024-12-31"}}, "c BETWEEN ? AND ?", []interface{}{"2024-01-01", "2024-12-31"}},
+		{FilterCondition{Operator: "STARTS WITH", Value: "50%"}, "c LIKE ?", []interface{}{`50\%%`}},
+		{FilterCondition{Operator: "ENDS WITH", Value: "_x"}, "c LIKE ?", []interface{}{`%\_x`}},
+		{FilterCondition{Operator: "REGEXP", Value: "^SN-[0-9]+$"}, "c REGEXP ?", []interface{}{"^SN-[0-9]+$"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter.Operator, func(t *testing.T) {
+			clause, args, err := buildComparison("c", tt.filter)
+			if err != nil {
+				t.Fatalf("buildComparison failed: %v", err)
+			}
+			if clause != tt.wantClause || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("Expected %s %v, got %s %v", tt.wantClause, tt.wantArgs, clause, args)
+			}
+		})
+	}
+}
+
+func TestRelativeDateRange(t *testing.T) {
+	now := time.Date(2024, 3, 15, 14, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		op       string
+		value    interface{}
+		from, to string
+	}{
+		{"WITHIN LAST", "30d", "2024-02-14", "2024-03-16"},
+		{"WITHIN LAST", float64(7), "2024-03-08", "2024-03-16"},
+		{"WITHIN LAST", "2 weeks", "2024-03-01", "2024-03-16"},
+		{"WITHIN NEXT", "1m", "2024-03-15", "2024-04-16"},
+		{"WITHIN NEXT", "1y", "2024-03-15", "2025-03-16"},
+	}
+
+	for _, tt := range tests {
+		from, to, err := relativeDateRange(tt.op, tt.value, now)
+		if err != nil {
+			t.Fatalf("relativeDateRange(%s, %v) failed: %v", tt.op, tt.value, err)
+		}
+		if from != tt.from || to != tt.to {
+			t.Errorf("relativeDateRange(%s, %v) = [%s, %s), want [%s, %s)", tt.op, tt.value, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestCustomFieldScope(t *testing.T) {
+	// Attributes only apply to the person report and properties to the asset report
+	if _, _, err := buildWhereClause(FilterCondition{Field: "attr_Department", Operator: "=", Value: "IT"}, "asset", nil); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected attribute filter to be rejected for assets, got %v", err)
+	}
+	if _, _, err := buildWhereClause(FilterCondition{Field: "prop_RAM", Operator: "=", Value: "8"}, "person", nil); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected property filter to be rejected for persons, got %v", err)
+	}
+
+	clause, args, err := buildWhereClause(FilterCondition{Field: "attr_Department", Operator: "LIKE", Value: "IT"}, "person", nil)
+	if err != nil {
+		t.Fatalf("buildWhereClause failed: %v", err)
+	}
+	if !strings.Contains(clause, "cv.person_id = p.id") || !strings.Contains(clause, "cv.value LIKE ?") {
+		t.Errorf("Unexpected clause %s", clause)
+	}
+	if want := []interface{}{"Department", "%IT%"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+}
+
+func TestBuildWhereClause_TypedValues(t *testing.T) {
+	dataTypes := map[string]models.DataType{"RAM": models.DataTypeInt, "Warranty": models.DataTypeDate}
+
+	clause, args, err := buildWhereClause(FilterCondition{Field: "prop_RAM", Operator: ">", Value: "8"}, "asset", dataTypes)
+	if err != nil {
+		t.Fatalf("buildWhereClause failed: %v", err)
+	}
+	if !strings.Contains(clause, "REGEXP") || !strings.Contains(clause, "CAST(TRIM(cv.value) AS SIGNED) > ?") {
+		t.Errorf("Expected guarded numeric comparison, got %s", clause)
+	}
+	if want := []interface{}{"RAM", int64(8)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+
+	// Text operators still work on the raw value
+	clause, _, err = buildWhereClause(FilterCondition{Field: "prop_RAM", Operator: "LIKE", Value: "16"}, "asset", dataTypes)
+	if err != nil || !strings.Contains(clause, "cv.value LIKE ?") {
+		t.Errorf("Expected text comparison for LIKE, got %s (%v)", clause, err)
+	}
+
+	if _, _, err := buildWhereClause(FilterCondition{Field: "prop_RAM", Operator: ">", Value: "lots"}, "asset", dataTypes); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected invalid int filter value to be rejected, got %v", err)
+	}
+	if _, _, err := buildWhereClause(FilterCondition{Field: "prop_Warranty", Operator: "<", Value: "31/12/2024"}, "asset", dataTypes); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected invalid date filter value to be rejected, got %v", err)
+	}
+}
+
+func TestSortReport_TypedValues(t *testing.T) {
+	results := []map[string]interface{}{
+		{"name": "a", "prop_RAM": "8"},
+		{"name": "b", "prop_RAM": "lots"},
+		{"name": "c", "prop_RAM": "16"},
+		{"name": "d"},
+		{"name": "e", "prop_RAM": "4"},
+	}
+	dataTypes := map[string]models.DataType{"RAM": models.DataTypeInt}
+
+	if err := sortReport(results, &ReportSort{Field: "prop_RAM", Desc: true}, "asset", dataTypes); err != nil {
+		t.Fatalf("sortReport failed: %v", err)
+	}
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result["name"].(string))
+	}
+	if got := strings.Join(names, ""); got != "caebd" {
+		t.Errorf("Expected numeric descending order with invalid and missing last, got %s", got)
+	}
+
+	if err := sortReport(results, &ReportSort{Field: "attr_Department"}, "asset", dataTypes); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected unknown sort field to be rejected, got %v", err)
+	}
+}
+
+func TestBuildFilterClause_LocationSubtree(t *testing.T) {
+	filter := &FilterGroup{Conditions: []FilterCondition{{Field: "LocationID", Operator: "in subtree", Value: "7"}}}
+
+	clause, args, err := buildFilterClause(filter, "asset", nil)
+	if err != nil {
+		t.Fatalf("buildFilterClause failed: %v", err)
+	}
+	if !strings.HasPrefix(clause, "(cl.location_id IN (WITH RECURSIVE subtree AS (") {
+		t.Errorf("Unexpected clause: %s", clause)
+	}
+	if want := []interface{}{int64(7)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Expected args %v, got %v", want, args)
+	}
+
+	filter.Conditions[0].Operator = "NOT IN SUBTREE"
+	clause, _, err = buildFilterClause(filter, "asset", nil)
+	if err != nil {
+		t.Fatalf("buildFilterClause failed: %v", err)
+	}
+	if !strings.HasPrefix(clause, "((cl.location_id IS NULL OR cl.location_id NOT IN (") {
+		t.Errorf("Expected assets without location to match, got %s", clause)
+	}
+
+	for _, bad := range []FilterCondition{
+		{Field: "LocationID", Operator: "IN SUBTREE", Value: "Berlin"},
+		{Field: "Name", Operator: "IN SUBTREE", Value: 7},
+	} {
+		_, _, err := buildFilterClause(&FilterGroup{Conditions: []FilterCondition{bad}}, "asset", nil)
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("Expected invalid filter error for %+v, got %v", bad, err)
+		}
+	}
+	if _, _, err := buildFilterClause(filter, "person", nil); !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("Expected subtree filters to be rejected for persons, got %v", err)
+	}
+}
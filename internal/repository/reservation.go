@@ -0,0 +1,495 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/auth"
+	"assetManager/internal/availability"
+	"assetManager/internal/lifecycle"
+	"assetManager/internal/models"
+)
+
+var (
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation was picked up or cancelled")
+	ErrReservationEnded     = errors.New("reservation has ended")
+	ErrReservationAssetType = errors.New("asset is not of the reserved type")
+	ErrFeedNotFound         = errors.New("calendar feed not found")
+)
+
+// ReservationConflictError lists the reservations, assignments and shortages that prevent a
+// reservation
+type ReservationConflictError struct {
+	Conflicts []models.ReservationConflict
+}
+
+func (e *ReservationConflictError) Error() string {
+	return fmt.Sprintf("%d conflicting bookings", len(e.Conflicts))
+}
+
+// reservationColumns selects a reservation joined with the names of its asset, asset type and
+// person. The type of a specific asset reservation is the type of the asset.
+const reservationColumns = `r.id, r.asset_id, r.asset_type_id, r.person_id, r.starts_at, r.ends_at, r.status,
+	r.assignment_id, COALESCE(r.notes, '') as notes, r.created_at, r.updated_at,
+	COALESCE(a.tag, '') as asset_tag, COALESCE(a.name, '') as asset_name,
+	COALESCE(at.name, '') as asset_type_name, COALESCE(p.name, '') as person_name`
+
+// reservationJoins are the joins reservationColumns needs
+const reservationJoins = `LEFT JOIN assets a ON r.asset_id = a.id
+	LEFT JOIN asset_types at ON at.id = COALESCE(r.asset_type_id, a.asset_type_id)
+	LEFT JOIN persons p ON r.person_id = p.id`
+
+// ReservationFilter narrows the reservations listed. Zero fields match everything.
+type ReservationFilter struct {
+	AssetID          int64
+	PersonID         int64
+	EndsAfter        time.Time // Only reservations ending after this time
+	IncludeCancelled bool
+}
+
+// ReservationRepository handles reservation data operations
+type ReservationRepository struct {
+	db *sqlx.DB
+}
+
+// NewReservationRepository creates a new reservation repository
+func NewReservationRepository(db *sqlx.DB) *ReservationRepository {
+	return &ReservationRepository{db: db}
+}
+
+// GetByID retrieves a reservation by ID
+func (r *ReservationRepository) GetByID(ctx context.Context, id int64) (*models.Reservation, error) {
+	var res models.Reservation
+	query := `SELECT ` + reservationColumns + `
+			  FROM reservations r
+			  ` + reservationJoins + `
+			  WHERE r.id = ?`
+	err := r.db.GetContext(ctx, &res, query, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrReservationNotFound
+	}
+	return &res, err
+}
+
+// List retrieves the reservations matching the filter, soonest first. A type reservation
+// matches an asset filter once that asset was picked up for it.
+func (r *ReservationRepository) List(ctx context.Context, filter ReservationFilter) ([]models.Reservation, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.AssetID != 0 {
+		conditions = append(conditions, "r.asset_id = ?")
+		args = append(args, filter.AssetID)
+	}
+	if filter.PersonID != 0 {
+		conditions = append(conditions, "r.person_id = ?")
+		args = append(args, filter.PersonID)
+	}
+	if !filter.EndsAfter.IsZero() {
+		conditions = append(conditions, "r.ends_at > ?")
+		args = append(args, filter.EndsAfter)
+	}
+	if !filter.IncludeCancelled {
+		conditions = append(conditions, "r.status != 'cancelled'")
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var reservations []models.Reservation
+	query := `SELECT ` + reservationColumns + `
+			  FROM reservations r
+			  ` + reservationJoins + `
+			  ` + where + `
+			  ORDER BY r.starts_at, r.id`
+	err := r.db.SelectContext(ctx, &reservations, query, args...)
+	return reservations, err
+}
+
+// Create books the asset, or any asset of the type, of the reservation for its person. It fails
+// with a *ReservationConflictError if the asset is reserved or held by a person during the
+// window, or if too few assets of the type would be left for the reservations of the type.
+func (r *ReservationRepository) Create(ctx context.Context, res *models.Reservation) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the asset, then its type so reservations drawing on the same pool are checked one at
+	// a time
+	typeID, err := lockReservationTarget(ctx, tx, res)
+	if err != nil {
+		return err
+	}
+	if err := checkPersonActive(ctx, tx, res.PersonID); err != nil {
+		return err
+	}
+
+	conflicts, err := reservationConflicts(ctx, tx, res, typeID)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ReservationConflictError{Conflicts: conflicts}
+	}
+
+	query := `INSERT INTO reservations (asset_id, asset_type_id, person_id, starts_at, ends_at, notes)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := tx.ExecContext(ctx, query, res.AssetID, res.AssetTypeID, res.PersonID, res.StartsAt, res.EndsAt, res.Notes)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	res.ID = id
+	res.Status = models.ReservationStatusReserved
+	return nil
+}
+
+// Cancel cancels a reservation that was not picked up and returns it as it was before
+func (r *ReservationRepository) Cancel(ctx context.Context, id int64) (*models.Reservation, error) {
+	before, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if before.Status != models.ReservationStatusReserved {
+		return nil, ErrReservationNotActive
+	}
+	query := `UPDATE reservations SET status = 'cancelled' WHERE id = ? AND status = 'reserved'`
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return nil, err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, err
+	} else if rows == 0 {
+		return nil, ErrReservationNotActive
+	}
+	return before, nil
+}
+
+// Pickup converts a reservation into a loan of the asset to the person of the reservation, due
+// back when the reservation ends. assetID picks the asset for a reservation of any asset of a
+// type and is ignored for a specific asset. The asset is deployed as on CheckOut. It returns the
+// reservation as it was before and the outcome of lending the asset.
+func (r *ReservationRepository) Pickup(ctx context.Context, id, assetID int64, transitions lifecycle.Transitions, changedBy models.AssetStatusChange) (*models.Reservation, *models.CheckOut, error) {
+	// Timestamps are stored with second precision, truncate so comparisons match what is stored
+	now := time.Now().Truncate(time.Second)
+
+	before, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if before.AssetID != nil {
+		assetID = *before.AssetID
+	}
+
+	var checkedOut *models.CheckOut
+	err = withAssetLock(ctx, r.db, assetID, func(tx *sqlx.Tx) error {
+		var res models.Reservation
+		query := `SELECT id, asset_id, asset_type_id, person_id, starts_at, ends_at, status, COALESCE(notes, '') as notes
+				  FROM reservations WHERE id = ? FOR UPDATE`
+		if err := tx.GetContext(ctx, &res, query, id); err != nil {
+			return err
+		}
+		if res.Status != models.ReservationStatusReserved {
+			return ErrReservationNotActive
+		}
+		if !now.Before(res.EndsAt) {
+			return ErrReservationEnded
+		}
+		if res.AssetID == nil {
+			var typeID int64
+			if err := tx.GetContext(ctx, &typeID, `SELECT asset_type_id FROM assets WHERE id = ?`, assetID); err != nil {
+				return err
+			}
+			if res.AssetTypeID == nil || *res.AssetTypeID != typeID {
+				return ErrReservationAssetType
+			}
+		}
+
+		// The loan runs from now, which may be before the reservation starts, and checkOut refuses
+		// it if the asset is reserved for someone else before the reservation ends
+		notes := fmt.Sprintf("Picked up for reservation #%d", res.ID)
+		if res.Notes != "" {
+			notes += ": " + res.Notes
+		}
+		checkedOut, err = checkOut(ctx, tx, models.CheckOutRequest{
+			AssetID:  assetID,
+			PersonID: res.PersonID,
+			DueAt:    models.NewNullTime(res.EndsAt),
+			Notes:    notes,
+		}, now, res.ID, transitions, changedBy)
+		if err != nil {
+			return err
+		}
+
+		query = `UPDATE reservations SET status = 'picked_up', asset_id = ?, assignment_id = ? WHERE id = ?`
+		_, err = tx.ExecContext(ctx, query, assetID, checkedOut.Loan.ID, res.ID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return before, checkedOut, nil
+}
+
+// FeedKey returns the key granting access to a calendar feed, such as "assets/12", generating
+// one the first time the feed is asked for
+func (r *ReservationRepository) FeedKey(ctx context.Context, feed string) (string, error) {
+	key, err := auth.NewFeedKey()
+	if err != nil {
+		return "", err
+	}
+	// A concurrent request may have generated the key in the meantime, so keep the stored one
+	query := `INSERT IGNORE INTO calendar_feeds (feed, feed_key) VALUES (?, ?)`
+	if _, err := r.db.ExecContext(ctx, query, feed, key); err != nil {
+		return "", err
+	}
+	return r.GetFeedKey(ctx, feed)
+}
+
+// GetFeedKey returns the key of a calendar feed, or ErrFeedNotFound if none was generated yet
+func (r *ReservationRepository) GetFeedKey(ctx context.Context, feed string) (string, error) {
+	var key string
+	err := r.db.GetContext(ctx, &key, `SELECT feed_key FROM calendar_feeds WHERE feed = ?`, feed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrFeedNotFound
+	}
+	return key, err
+}
+
+// RegenerateFeedKey replaces the key of a calendar feed with a new one, revoking the address
+// holding the old key
+func (r *ReservationRepository) RegenerateFeedKey(ctx context.Context, feed string) (string, error) {
+	key, err := auth.NewFeedKey()
+	if err != nil {
+		return "", err
+	}
+	query := `INSERT INTO calendar_feeds (feed, feed_key) VALUES (?, ?)
+			  ON DUPLICATE KEY UPDATE feed_key = VALUES(feed_key)`
+	if _, err := r.db.ExecContext(ctx, query, feed, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// lockReservationTarget locks the asset of a specific asset reservation and the asset type the
+// reservation draws on, and returns the ID of that type
+func lockReservationTarget(ctx context.Context, tx *sqlx.Tx, res *models.Reservation) (int64, error) {
+	if res.AssetID != nil {
+		var asset struct {
+			TypeID int64              `db:"asset_type_id"`
+			Status models.AssetStatus `db:"status"`
+		}
+		query := `SELECT asset_type_id, status FROM assets WHERE id = ? AND deleted_at IS NULL FOR UPDATE`
+		err := tx.GetContext(ctx, &asset, query, *res.AssetID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrAssetNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !asset.Status.IsAssignable() {
+			return 0, ErrAssetNotAssignable
+		}
+		var typeID int64
+		err = tx.GetContext(ctx, &typeID, `SELECT id FROM asset_types WHERE id = ? FOR UPDATE`, asset.TypeID)
+		return typeID, err
+	}
+
+	var typeID int64
+	err := tx.GetContext(ctx, &typeID, `SELECT id FROM asset_types WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, *res.AssetTypeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrAssetTypeNotFound
+	}
+	return typeID, err
+}
+
+// conflictRow is a reservation or assignment that overlaps a window
+type conflictRow struct {
+	ID         int64           `db:"id"`
+	AssetID    int64           `db:"asset_id"`
+	PersonName string          `db:"person_name"`
+	From       time.Time       `db:"starts_at"`
+	To         models.NullTime `db:"ends_at"`
+}
+
+// reservationConflicts finds what prevents a reservation drawing on the assets of a type: for a
+// specific asset, the other reservations of the asset and the persons holding it during the
+// window, and otherwise the first moment at which too few assets of the type would be left
+func reservationConflicts(ctx context.Context, q queryer, res *models.Reservation, typeID int64) ([]models.ReservationConflict, error) {
+	if res.AssetID != nil {
+		conflicts, err := assetReservationConflicts(ctx, q, *res.AssetID, res.StartsAt, models.NewNullTime(res.EndsAt), res.ID)
+		if err != nil || len(conflicts) > 0 {
+			return conflicts, err
+		}
+		conflicts, err = assetAssignmentConflicts(ctx, q, *res.AssetID, *res)
+		if err != nil || len(conflicts) > 0 {
+			return conflicts, err
+		}
+	}
+
+	window := availability.Interval{Start: res.StartsAt, End: res.EndsAt}
+	var assets []struct {
+		ID     int64              `db:"id"`
+		Status models.AssetStatus `db:"status"`
+	}
+	query := `SELECT id, status FROM assets WHERE asset_type_id = ? AND deleted_at IS NULL`
+	if err := q.SelectContext(ctx, &assets, query, typeID); err != nil {
+		return nil, err
+	}
+	var pool []int64
+	for _, a := range assets {
+		if a.Status.IsAssignable() {
+			pool = append(pool, a.ID)
+		}
+	}
+
+	// Assets are blocked by their own reservations and while a person holds them
+	var blocking []conflictRow
+	query = `SELECT r.id, r.asset_id, r.starts_at, r.ends_at
+			 FROM reservations r
+			 JOIN assets a ON r.asset_id = a.id
+			 WHERE a.asset_type_id = ? AND r.status = 'reserved' AND r.id != ?
+			 AND r.starts_at < ? AND r.ends_at > ?
+			 UNION ALL
+			 SELECT aa.id, aa.asset_id, aa.effective_from as starts_at, aa.effective_to as ends_at
+			 FROM asset_assignments aa
+			 JOIN assets a ON aa.asset_id = a.id
+			 JOIN persons p ON aa.person_id = p.id
+			 WHERE a.asset_type_id = ? AND aa.deleted_at IS NULL AND p.is_stock = FALSE
+			 AND aa.effective_from < ? AND (aa.effective_to IS NULL OR aa.effective_to > ?)`
+	err := q.SelectContext(ctx, &blocking, query,
+		typeID, res.ID, res.EndsAt, res.StartsAt,
+		typeID, res.EndsAt, res.StartsAt)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[int64][]availability.Interval)
+	for _, b := range blocking {
+		end := window.End
+		if b.To.Valid {
+			end = b.To.Time
+		}
+		blocked[b.AssetID] = append(blocked[b.AssetID], availability.Interval{Start: b.From, End: end})
+	}
+
+	// Reservations of any asset of the type each need one asset while they run
+	var demanding []conflictRow
+	query = `SELECT id, starts_at, ends_at FROM reservations
+			 WHERE asset_type_id = ? AND asset_id IS NULL AND status = 'reserved' AND id != ?
+			 AND starts_at < ? AND ends_at > ?`
+	if err := q.SelectContext(ctx, &demanding, query, typeID, res.ID, res.EndsAt, res.StartsAt); err != nil {
+		return nil, err
+	}
+	demand := make([]availability.Interval, 0, len(demanding)+1)
+	for _, d := range demanding {
+		demand = append(demand, availability.Interval{Start: d.From, End: d.To.Time})
+	}
+
+	if res.AssetID != nil {
+		blocked[*res.AssetID] = append(blocked[*res.AssetID], window)
+	} else {
+		demand = append(demand, window)
+	}
+	if at, short := availability.Shortfall(window, pool, blocked, demand); short {
+		return []models.ReservationConflict{{Kind: models.ReservationConflictCapacity, From: at}}, nil
+	}
+	return nil, nil
+}
+
+// assetReservationConflicts finds the reservations of an asset other than excludeID overlapping
+// the window from from until to, open-ended if to is null
+func assetReservationConflicts(ctx context.Context, q queryer, assetID int64, from time.Time, to models.NullTime, excludeID int64) ([]models.ReservationConflict, error) {
+	var end interface{}
+	if to.Valid {
+		end = to.Time
+	}
+	var rows []conflictRow
+	query := `SELECT r.id, r.asset_id, COALESCE(p.name, '') as person_name, r.starts_at, r.ends_at
+			  FROM reservations r
+			  LEFT JOIN persons p ON r.person_id = p.id
+			  WHERE r.asset_id = ? AND r.status = 'reserved' AND r.id != ?
+			  AND (? IS NULL OR r.starts_at < ?) AND r.ends_at > ?
+			  ORDER BY r.starts_at`
+	if err := q.SelectContext(ctx, &rows, query, assetID, excludeID, end, end, from); err != nil {
+		return nil, err
+	}
+	return conflictsOf(models.ReservationConflictReservation, rows), nil
+}
+
+// checkReserved returns a *ReservationConflictError if the asset of an assignment is reserved,
+// other than by reservation excludeID, while the assignment runs. Loans run until they are due.
+// Assignments to stock pools do not take the asset from anyone and are never refused.
+func checkReserved(ctx context.Context, q queryer, aa *models.AssetAssignment, excludeID int64) error {
+	var isStock bool
+	if err := q.GetContext(ctx, &isStock, `SELECT is_stock FROM persons WHERE id = ?`, aa.PersonID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrPersonNotFound
+		}
+		return err
+	}
+	if isStock {
+		return nil
+	}
+
+	to := aa.EffectiveTo
+	if aa.DueAt.Valid && (!to.Valid || aa.DueAt.Time.Before(to.Time)) {
+		to = aa.DueAt
+	}
+	conflicts, err := assetReservationConflicts(ctx, q, aa.AssetID, aa.EffectiveFrom.Time, to, excludeID)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ReservationConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// assetAssignmentConflicts finds the assignments of an asset to persons other than stock pools
+// overlapping the window of a reservation
+func assetAssignmentConflicts(ctx context.Context, q queryer, assetID int64, res models.Reservation) ([]models.ReservationConflict, error) {
+	var rows []conflictRow
+	query := `SELECT aa.id, aa.asset_id, COALESCE(p.name, '') as person_name,
+			  aa.effective_from as starts_at, aa.effective_to as ends_at
+			  FROM asset_assignments aa
+			  JOIN persons p ON aa.person_id = p.id
+			  WHERE aa.asset_id = ? AND aa.deleted_at IS NULL AND p.is_stock = FALSE
+			  AND aa.effective_from < ? AND (aa.effective_to IS NULL OR aa.effective_to > ?)
+			  ORDER BY aa.effective_from`
+	if err := q.SelectContext(ctx, &rows, query, assetID, res.EndsAt, res.StartsAt); err != nil {
+		return nil, err
+	}
+	return conflictsOf(models.ReservationConflictAssignment, rows), nil
+}
+
+// conflictsOf turns overlapping reservations or assignments into conflicts of the given kind
+func conflictsOf(kind models.ReservationConflictKind, rows []conflictRow) []models.ReservationConflict {
+	conflicts := make([]models.ReservationConflict, len(rows))
+	for i, row := range rows {
+		conflicts[i] = models.ReservationConflict{
+			Kind:       kind,
+			ID:         row.ID,
+			AssetID:    row.AssetID,
+			PersonName: row.PersonName,
+			From:       row.From,
+			To:         row.To,
+		}
+	}
+	return conflicts
+}
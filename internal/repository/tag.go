@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"assetManager/internal/tags"
+)
+
+var ErrDuplicateTag = errors.New("asset tag already in use")
+
+// maxTagAttempts bounds how many generated tags are skipped because they were taken by hand
+const maxTagAttempts = 10
+
+// nextTag generates the next free tag from the tag pattern of an asset type. The counter row of
+// the pattern stays locked until the surrounding transaction ends, so concurrent creations never
+// receive the same number.
+func nextTag(ctx context.Context, q queryer, assetTypeID int64, date time.Time) (string, error) {
+	var text string
+	query := `SELECT COALESCE(tag_pattern, '') FROM asset_types WHERE id = ?`
+	if err := q.GetContext(ctx, &text, query, assetTypeID); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	if text == "" {
+		text = tags.DefaultPattern
+	}
+	pattern, err := tags.Parse(text)
+	if err != nil {
+		return "", err
+	}
+	scope := pattern.Scope(date)
+
+	for attempt := 0; attempt < maxTagAttempts; attempt++ {
+		// LAST_INSERT_ID(expr) hands the new counter value back as the insert ID
+		query := `INSERT INTO tag_sequences (scope, last_value) VALUES (?, LAST_INSERT_ID(1))
+				  ON DUPLICATE KEY UPDATE last_value = LAST_INSERT_ID(last_value + 1)`
+		result, err := q.ExecContext(ctx, query, scope)
+		if err != nil {
+			return "", err
+		}
+		seq, err := result.LastInsertId()
+		if err != nil {
+			return "", err
+		}
+
+		tag := pattern.Format(scope, seq)
+		taken, err := tagTaken(ctx, q, tag, 0)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return tag, nil
+		}
+	}
+	return "", ErrDuplicateTag
+}
+
+// tagTaken reports whether an asset other than excludeID carries the tag, including deleted ones
+func tagTaken(ctx context.Context, q queryer, tag string, excludeID int64) (bool, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM assets WHERE tag = ? AND id <> ?`
+	err := q.GetContext(ctx, &count, query, tag, excludeID)
+	return count > 0, err
+}
+
+// isDuplicateKey reports whether err is a MySQL unique key violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
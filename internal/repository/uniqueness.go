@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+
+	"assetManager/internal/models"
+	"assetManager/internal/uniqueness"
+)
+
+// uniqueLockTimeout is how many seconds a write waits for the uniqueness lock of its entity
+const uniqueLockTimeout = 10
+
+var ErrUniqueLockTimeout = errors.New("timed out waiting for the uniqueness lock")
+
+// UniqueConflictError is returned by writes that would give a record a value a uniqueness rule
+// requires to be unique while another record already has it
+type UniqueConflictError struct {
+	Rule     uniqueness.Rule
+	Value    string
+	ID       int64       // ID of the record holding the value
+	Existing interface{} // *models.Asset or *models.Person holding the value
+}
+
+func (e *UniqueConflictError) Error() string {
+	return e.Rule.Message(e.Value, e.ID)
+}
+
+// withUniqueLock runs fn in a transaction. If there are uniqueness rules for the entity, it
+// holds the named lock of the entity until the transaction committed, so a value checked inside
+// fn cannot be taken by another write in the meantime. Named locks belong to the connection, so
+// the transaction runs on a dedicated one.
+func withUniqueLock(ctx context.Context, db *sqlx.DB, rules uniqueness.Rules, entity uniqueness.Entity, fn func(tx *sqlx.Tx) error) error {
+	conn, err := db.Connx(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if len(rules.For(entity)) > 0 {
+		name := "asset_manager.unique." + string(entity)
+		var locked sql.NullInt64
+		if err := conn.GetContext(ctx, &locked, `SELECT GET_LOCK(?, ?)`, name, uniqueLockTimeout); err != nil {
+			return err
+		}
+		if !locked.Valid || locked.Int64 != 1 {
+			return ErrUniqueLockTimeout
+		}
+		// Released after the transaction ended, even if the request was cancelled, as the
+		// connection goes back to the pool
+		defer conn.ExecContext(context.Background(), `DO RELEASE_LOCK(?)`, name)
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkUniqueAsset returns a *UniqueConflictError if another asset has a value of the asset that
+// the rules require to be unique
+func checkUniqueAsset(ctx context.Context, q queryer, rules uniqueness.Rules, asset *models.Asset) error {
+	for _, rule := range rules.For(uniqueness.EntityAsset) {
+		existing, err := findAssetConflict(ctx, q, rule, asset)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return &UniqueConflictError{Rule: rule, Value: rule.AssetValue(asset), ID: existing.ID, Existing: existing}
+		}
+	}
+	return nil
+}
+
+// checkUniquePerson returns a *UniqueConflictError if another person has a value of the person
+// that the rules require to be unique
+func checkUniquePerson(ctx context.Context, q queryer, rules uniqueness.Rules, person *models.Person) error {
+	for _, rule := range rules.For(uniqueness.EntityPerson) {
+		existing, err := findPersonConflict(ctx, q, rule, person)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return &UniqueConflictError{Rule: rule, Value: rule.PersonValue(person), ID: existing.ID, Existing: existing}
+		}
+	}
+	return nil
+}
@@ -25,7 +25,7 @@ func NewUserRepository(db *sqlx.DB) *UserRepository {
 // GetByID retrieves a user by ID
 func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at, deleted_at 
+	query := `SELECT id, username, email, password_hash, role, is_active, created_at, updated_at, deleted_at 
 			  FROM users WHERE id = ? AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &user, query, id)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -37,7 +37,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, e
 // GetByUsername retrieves a user by username
 func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at, deleted_at 
+	query := `SELECT id, username, email, password_hash, role, is_active, created_at, updated_at, deleted_at 
 			  FROM users WHERE username = ? AND deleted_at IS NULL`
 	err := r.db.GetContext(ctx, &user, query, username)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -49,7 +49,7 @@ func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*m
 // GetAll retrieves all users
 func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	query := `SELECT id, username, email, password_hash, is_active, created_at, updated_at, deleted_at 
+	query := `SELECT id, username, email, password_hash, role, is_active, created_at, updated_at, deleted_at 
 			  FROM users WHERE deleted_at IS NULL ORDER BY username`
 	err := r.db.SelectContext(ctx, &users, query)
 	return users, err
@@ -57,8 +57,8 @@ func (r *UserRepository) GetAll(ctx context.Context) ([]models.User, error) {
 
 // Create creates a new user
 func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
-	query := `INSERT INTO users (username, email, password_hash, is_active) VALUES (?, ?, ?, ?)`
-	result, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.PasswordHash, user.IsActive)
+	query := `INSERT INTO users (username, email, password_hash, role, is_active) VALUES (?, ?, ?, ?, ?)`
+	result, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.PasswordHash, user.Role, user.IsActive)
 	if err != nil {
 		return err
 	}
@@ -72,9 +72,9 @@ func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
 
 // Update updates an existing user
 func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
-	query := `UPDATE users SET username = ?, email = ?, is_active = ?, updated_at = NOW() 
+	query := `UPDATE users SET username = ?, email = ?, role = ?, is_active = ?, updated_at = NOW() 
 			  WHERE id = ? AND deleted_at IS NULL`
-	_, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.IsActive, user.ID)
+	_, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.Role, user.IsActive, user.ID)
 	return err
 }
 
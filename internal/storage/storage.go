@@ -0,0 +1,77 @@
+// Package storage keeps the content of uploaded files in a pluggable backend, either a local
+// directory or an S3-compatible object store.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"assetManager/internal/config"
+)
+
+var (
+	ErrNotFound   = errors.New("stored object not found")
+	ErrInvalidKey = errors.New("invalid storage key")
+)
+
+// Backend stores file content under keys such as "sha256/ab/ab12..."
+type Backend interface {
+	// Put stores size bytes read from r under key, replacing any previous content
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open returns the content stored under key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// New creates the backend selected in the configuration
+func New(cfg config.AttachmentsConfig) (Backend, error) {
+	switch cfg.Storage {
+	case "", "local":
+		return NewLocal(cfg.Local.Path)
+	case "s3":
+		return NewS3(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown attachment storage %q, must be local or s3", cfg.Storage)
+	}
+}
+
+// validKeyPattern allows the relative slash-separated keys this package hands out
+var validKeyPattern = regexp.MustCompile(`^[A-Za-z0-9._-]+(/[A-Za-z0-9._-]+)*$`)
+
+// checkKey rejects keys that could escape the storage root
+func checkKey(key string) error {
+	if !validKeyPattern.MatchString(key) {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
+
+// ContentKey returns the key content with the given SHA-256 hex digest is stored under. Identical
+// uploads share one stored object.
+func ContentKey(sha256Hex string) string {
+	return "sha256/" + sha256Hex[:2] + "/" + sha256Hex
+}
+
+// DetectContentType determines the MIME type of a file from its first bytes, falling back to
+// the file name extension for formats that sniff only as generic binary or zip data, such as
+// Office documents
+func DetectContentType(fileName string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	if sniffed == "application/octet-stream" || sniffed == "application/zip" {
+		if byExtension := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); byExtension != "" {
+			return byExtension
+		}
+	}
+	return sniffed
+}
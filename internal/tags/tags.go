@@ -0,0 +1,92 @@
+// Package tags generates human-friendly asset tags from patterns such as LPT-{YYYY}-{seq:5}
+package tags
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPattern numbers assets of types without their own pattern
+const DefaultPattern = "AST-{seq:6}"
+
+// MaxLength is the longest tag that can be stored
+const MaxLength = 100
+
+// maxPatternLength keeps expanded tags below MaxLength
+const maxPatternLength = 50
+
+var (
+	ErrNoSequence       = errors.New("tag pattern must contain exactly one {seq} or {seq:N}")
+	ErrUnknownToken     = errors.New("unknown tag pattern token")
+	ErrInvalidSeqDigits = errors.New("sequence digits must be between 1 and 12")
+	ErrPatternTooLong   = errors.New("tag pattern is too long")
+)
+
+var tokenPattern = regexp.MustCompile(`\{([A-Za-z]+)(?::(\d+))?\}`)
+
+// dateTokens maps the date tokens of a pattern to their Go time layout
+var dateTokens = map[string]string{
+	"YYYY": "2006",
+	"YY":   "06",
+	"MM":   "01",
+	"DD":   "02",
+}
+
+// Pattern is a parsed tag pattern. Date tokens are expanded with the creation date, the
+// sequence counts up separately for every expansion, so {YYYY} restarts the numbering each year.
+type Pattern struct {
+	text   string
+	digits int // Zero padding of the sequence number, 0 for none
+}
+
+// Parse checks a tag pattern. Literal text is kept as is and the tokens {YYYY}, {YY}, {MM},
+// {DD} and {seq} or {seq:N}, zero-padded to N digits, are replaced.
+func Parse(text string) (Pattern, error) {
+	if len(text) > maxPatternLength {
+		return Pattern{}, ErrPatternTooLong
+	}
+	p := Pattern{text: text}
+	sequences := 0
+	for _, match := range tokenPattern.FindAllStringSubmatch(text, -1) {
+		name, digits := match[1], match[2]
+		if name == "seq" {
+			sequences++
+			if digits != "" {
+				n, err := strconv.Atoi(digits)
+				if err != nil || n < 1 || n > 12 {
+					return Pattern{}, ErrInvalidSeqDigits
+				}
+				p.digits = n
+			}
+			continue
+		}
+		if _, ok := dateTokens[name]; !ok || digits != "" {
+			return Pattern{}, fmt.Errorf("%w: %s", ErrUnknownToken, match[0])
+		}
+	}
+	if sequences != 1 {
+		return Pattern{}, ErrNoSequence
+	}
+	return p, nil
+}
+
+// Scope returns the pattern with its date tokens expanded for the given date. Tags of the same
+// scope share one sequence.
+func (p Pattern) Scope(date time.Time) string {
+	return tokenPattern.ReplaceAllStringFunc(p.text, func(token string) string {
+		match := tokenPattern.FindStringSubmatch(token)
+		if match[1] == "seq" {
+			return "{seq}"
+		}
+		return date.Format(dateTokens[match[1]])
+	})
+}
+
+// Format returns the tag with the given sequence number within a scope
+func (p Pattern) Format(scope string, seq int64) string {
+	return strings.Replace(scope, "{seq}", fmt.Sprintf("%0*d", p.digits, seq), 1)
+}
@@ -0,0 +1,62 @@
+package tags
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	valid := []string{"LPT-{YYYY}-{seq:5}", "{seq}", "MON/{YY}{MM}{DD}/{seq:3}", DefaultPattern}
+	for _, text := range valid {
+		if _, err := Parse(text); err != nil {
+			t.Errorf("Parse(%q) failed: %v", text, err)
+		}
+	}
+
+	invalid := []struct {
+		text string
+		want error
+	}{
+		{"LPT-{YYYY}", ErrNoSequence},
+		{"{seq}-{seq}", ErrNoSequence},
+		{"LPT-{seq:0}", ErrInvalidSeqDigits},
+		{"LPT-{seq:13}", ErrInvalidSeqDigits},
+		{"LPT-{HH}-{seq}", ErrUnknownToken},
+		{"LPT-{YYYY:2}-{seq}", ErrUnknownToken},
+		{"LPT-{seq}-0123456789012345678901234567890123456789012345", ErrPatternTooLong},
+	}
+	for _, tt := range invalid {
+		if _, err := Parse(tt.text); !errors.Is(err, tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestScopeAndFormat(t *testing.T) {
+	date := time.Date(2025, 3, 7, 15, 4, 5, 0, time.UTC)
+	tests := []struct {
+		pattern   string
+		wantScope string
+		seq       int64
+		wantTag   string
+	}{
+		{"LPT-{YYYY}-{seq:5}", "LPT-2025-{seq}", 42, "LPT-2025-00042"},
+		{"MON/{YY}{MM}{DD}/{seq:3}", "MON/250307/{seq}", 7, "MON/250307/007"},
+		{"{seq}", "{seq}", 123456, "123456"},
+		{"AST-{seq:3}", "AST-{seq}", 12345, "AST-12345"},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.pattern)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.pattern, err)
+		}
+		scope := p.Scope(date)
+		if scope != tt.wantScope {
+			t.Errorf("%q: scope = %q, want %q", tt.pattern, scope, tt.wantScope)
+		}
+		if tag := p.Format(scope, tt.seq); tag != tt.wantTag {
+			t.Errorf("%q: tag = %q, want %q", tt.pattern, tag, tt.wantTag)
+		}
+	}
+}
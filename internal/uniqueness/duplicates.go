@@ -0,0 +1,220 @@
+package uniqueness
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Match tells how closely the values of a group of likely duplicates agree
+type Match string
+
+const (
+	MatchExact      Match = "exact"      // Equal ignoring case and surrounding spaces
+	MatchNormalized Match = "normalized" // Equal after normalizing, such as without separators
+	MatchFuzzy      Match = "fuzzy"      // Similar, such as one typo apart
+)
+
+// minFuzzyLength keeps short values, where one edit changes a lot, out of fuzzy matching
+const minFuzzyLength = 6
+
+// Item is a record to check for duplicates with the value of the compared field
+type Item struct {
+	ID    int64
+	Value string
+}
+
+// Group is a set of records whose values likely describe the same thing
+type Group struct {
+	Match Match
+	Value string  // Value of the first record
+	IDs   []int64 // Ascending
+}
+
+// Matcher compares the values of one field
+type Matcher struct {
+	normalize func(string) string
+	fold      func(string) string // Maps normalized values that are easily confused to one value
+	edits     bool                // Values one insertion, deletion, substitution or swap apart match
+}
+
+var (
+	// SerialNumbers ignore case, separators and characters that look alike, such as O and 0
+	SerialNumbers = Matcher{normalize: normalizeCode, fold: foldLookalikes}
+	// Emails ignore case and a +suffix of the local part
+	Emails = Matcher{normalize: normalizeEmail}
+	// Names ignore case, punctuation and word order and match one typo apart
+	Names = Matcher{normalize: normalizeName, edits: true}
+)
+
+// Find groups items with matching values. Items with an empty value are skipped.
+func (m Matcher) Find(items []Item) []Group {
+	sorted := make([]Item, 0, len(items))
+	for _, item := range items {
+		if m.normalize(item.Value) != "" {
+			sorted = append(sorted, item)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	// Union-find over the items, joining every pair of items whose values match
+	parent := make([]int, len(sorted))
+	for i := range parent {
+		parent[i] = i
+	}
+	var root func(int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+	join := func(indexes []int) {
+		for _, i := range indexes[1:] {
+			if a, b := root(indexes[0]), root(i); a != b {
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	byNormalized := make(map[string][]int)
+	for i, item := range sorted {
+		key := m.normalize(item.Value)
+		byNormalized[key] = append(byNormalized[key], i)
+	}
+	for _, indexes := range byNormalized {
+		join(indexes)
+	}
+
+	if m.fold != nil {
+		byFolded := make(map[string][]int)
+		for key, indexes := range byNormalized {
+			folded := m.fold(key)
+			byFolded[folded] = append(byFolded[folded], indexes[0])
+		}
+		for _, indexes := range byFolded {
+			join(indexes)
+		}
+	}
+
+	if m.edits {
+		// Values one edit apart share a key after deleting at most one character from each, so
+		// only those candidates need to be compared
+		byDeletion := make(map[string][]string)
+		for key := range byNormalized {
+			runes := []rune(key)
+			if len(runes) < minFuzzyLength {
+				continue
+			}
+			byDeletion[key] = append(byDeletion[key], key)
+			for i := range runes {
+				deleted := string(runes[:i]) + string(runes[i+1:])
+				byDeletion[deleted] = append(byDeletion[deleted], key)
+			}
+		}
+		for _, keys := range byDeletion {
+			for i := 0; i < len(keys); i++ {
+				for j := i + 1; j < len(keys); j++ {
+					if keys[i] != keys[j] && oneEditApart(keys[i], keys[j]) {
+						join([]int{byNormalized[keys[i]][0], byNormalized[keys[j]][0]})
+					}
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range sorted {
+		r := root(i)
+		members[r] = append(members[r], i)
+	}
+
+	var groups []Group
+	for r, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		group := Group{Match: MatchExact, Value: strings.TrimSpace(sorted[r].Value)}
+		first := sorted[indexes[0]].Value
+		for _, i := range indexes {
+			group.IDs = append(group.IDs, sorted[i].ID)
+			switch value := sorted[i].Value; {
+			case m.normalize(value) != m.normalize(first):
+				group.Match = MatchFuzzy
+			case group.Match == MatchExact && !strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(first)):
+				group.Match = MatchNormalized
+			}
+		}
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].IDs[0] < groups[j].IDs[0] })
+	return groups
+}
+
+// normalizeCode keeps only the letters and digits of a code, in upper case
+func normalizeCode(value string) string {
+	var b strings.Builder
+	for _, r := range value {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// lookalikes maps characters of normalized codes that are often misread to one of them
+var lookalikes = strings.NewReplacer("O", "0", "Q", "0", "I", "1", "L", "1", "Z", "2", "S", "5", "B", "8")
+
+// foldLookalikes replaces the characters of a normalized code that are easily confused
+func foldLookalikes(code string) string {
+	return lookalikes.Replace(code)
+}
+
+// normalizeEmail lower-cases an email address and drops a +suffix of its local part
+func normalizeEmail(value string) string {
+	email := strings.ToLower(strings.TrimSpace(value))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	if base, _, found := strings.Cut(local, "+"); found && base != "" {
+		local = base
+	}
+	return local + "@" + domain
+}
+
+// normalizeName lower-cases a name and sorts its words, so "Doe, John" equals "john doe"
+func normalizeName(value string) string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// oneEditApart reports whether a can be turned into b by inserting, deleting or substituting one
+// character or by swapping two adjacent characters
+func oneEditApart(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) > len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb)-len(ra) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(ra) && ra[i] == rb[i] {
+		i++
+	}
+	if len(ra) == len(rb) {
+		if i == len(ra) {
+			return false // Equal
+		}
+		if string(ra[i+1:]) == string(rb[i+1:]) {
+			return true // Substitution
+		}
+		return i+1 < len(ra) && ra[i] == rb[i+1] && ra[i+1] == rb[i] && string(ra[i+2:]) == string(rb[i+2:])
+	}
+	return string(ra[i:]) == string(rb[i+1:]) // Insertion
+}
@@ -0,0 +1,148 @@
+// Package uniqueness defines which asset and person fields must be unique and finds likely
+// duplicates among existing records.
+package uniqueness
+
+import (
+	"fmt"
+	"strings"
+
+	"assetManager/internal/config"
+	"assetManager/internal/models"
+)
+
+// Entity is the kind of record a rule applies to
+type Entity string
+
+const (
+	EntityAsset  Entity = "asset"
+	EntityPerson Entity = "person"
+)
+
+// Scope limits the records a value has to be unique among
+type Scope string
+
+const (
+	ScopeGlobal    Scope = "global"
+	ScopeAssetType Scope = "asset_type" // Assets of the same type only
+)
+
+// field is a field uniqueness rules can apply to
+type field struct {
+	name   string // JSON name of the field
+	label  string
+	asset  func(*models.Asset) string
+	person func(*models.Person) string
+}
+
+// fields lists the fields of each entity that can be made unique, keyed by column name
+var fields = map[Entity]map[string]field{
+	EntityAsset: {
+		"serial_number":  {name: "SerialNumber", label: "Serial number", asset: func(a *models.Asset) string { return a.SerialNumber }},
+		"order_no":       {name: "OrderNo", label: "Order number", asset: func(a *models.Asset) string { return a.OrderNo }},
+		"license_number": {name: "LicenseNumber", label: "License number", asset: func(a *models.Asset) string { return a.LicenseNumber }},
+		"name":           {name: "Name", label: "Name", asset: func(a *models.Asset) string { return a.Name }},
+	},
+	EntityPerson: {
+		"email": {name: "Email", label: "Email", person: func(p *models.Person) string { return p.Email }},
+		"name":  {name: "Name", label: "Name", person: func(p *models.Person) string { return p.Name }},
+		"phone": {name: "Phone", label: "Phone", person: func(p *models.Person) string { return p.Phone }},
+	},
+}
+
+// Rule requires the values of a field to be unique among the non-deleted records of an entity,
+// ignoring case and surrounding spaces. Empty values are exempt.
+type Rule struct {
+	Entity Entity `json:"Entity"`
+	Field  string `json:"Field"` // Column name, such as serial_number
+	Scope  Scope  `json:"Scope"`
+}
+
+// Rules is the set of uniqueness rules in effect
+type Rules []Rule
+
+// New builds the rules from configuration
+func New(cfg []config.UniquenessRule) (Rules, error) {
+	rules := make(Rules, 0, len(cfg))
+	for _, c := range cfg {
+		rule := Rule{Entity: Entity(c.Entity), Field: c.Field, Scope: Scope(c.Scope)}
+		if rule.Scope == "" {
+			rule.Scope = ScopeGlobal
+		}
+		entityFields, ok := fields[rule.Entity]
+		if !ok {
+			return nil, fmt.Errorf("unknown entity %q, must be asset or person", c.Entity)
+		}
+		if _, ok := entityFields[rule.Field]; !ok {
+			return nil, fmt.Errorf("field %q of %s cannot be made unique", c.Field, c.Entity)
+		}
+		switch {
+		case rule.Scope == ScopeAssetType && rule.Entity != EntityAsset:
+			return nil, fmt.Errorf("scope asset_type only applies to assets")
+		case rule.Scope != ScopeGlobal && rule.Scope != ScopeAssetType:
+			return nil, fmt.Errorf("unknown scope %q, must be global or asset_type", c.Scope)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// For returns the rules of an entity
+func (r Rules) For(entity Entity) Rules {
+	var rules Rules
+	for _, rule := range r {
+		if rule.Entity == entity {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Column returns the validated column name of the rule's field, or "" for unknown fields
+func (r Rule) Column() string {
+	if _, ok := fields[r.Entity][r.Field]; !ok {
+		return ""
+	}
+	return r.Field
+}
+
+// Name returns the JSON name of the rule's field, as used in responses and import columns
+func (r Rule) Name() string {
+	return fields[r.Entity][r.Field].name
+}
+
+// AssetValue returns the trimmed value of the rule's field of an asset
+func (r Rule) AssetValue(asset *models.Asset) string {
+	f := fields[r.Entity][r.Field]
+	if f.asset == nil {
+		return ""
+	}
+	return strings.TrimSpace(f.asset(asset))
+}
+
+// PersonValue returns the trimmed value of the rule's field of a person
+func (r Rule) PersonValue(person *models.Person) string {
+	f := fields[r.Entity][r.Field]
+	if f.person == nil {
+		return ""
+	}
+	return strings.TrimSpace(f.person(person))
+}
+
+// Key identifies a value within the rule's scope, so two records conflict if their keys are equal.
+// assetTypeID is ignored by global rules.
+func (r Rule) Key(value string, assetTypeID int64) string {
+	key := r.Field + "\x00" + strings.ToLower(strings.TrimSpace(value))
+	if r.Scope == ScopeAssetType {
+		key += fmt.Sprintf("\x00%d", assetTypeID)
+	}
+	return key
+}
+
+// Message describes a conflict with the record of the given ID
+func (r Rule) Message(value string, id int64) string {
+	msg := fmt.Sprintf("%s %q is already used by %s %d", fields[r.Entity][r.Field].label, value, r.Entity, id)
+	if r.Scope == ScopeAssetType {
+		msg += " of the same asset type"
+	}
+	return msg
+}
@@ -0,0 +1,123 @@
+package uniqueness
+
+import (
+	"reflect"
+	"testing"
+
+	"assetManager/internal/config"
+	"assetManager/internal/models"
+)
+
+func TestNew(t *testing.T) {
+	rules, err := New(config.DefaultConfig().Uniqueness.Rules)
+	if err != nil {
+		t.Fatalf("Expected the default rules to be valid, got %v", err)
+	}
+	if len(rules.For(EntityAsset)) != 1 || len(rules.For(EntityPerson)) != 1 {
+		t.Errorf("Expected one asset and one person rule, got %+v", rules)
+	}
+	if rules.For(EntityPerson)[0].Scope != ScopeGlobal {
+		t.Errorf("Expected rules without a scope to be global, got %q", rules.For(EntityPerson)[0].Scope)
+	}
+
+	invalid := []config.UniquenessRule{
+		{Entity: "location", Field: "name"},
+		{Entity: "asset", Field: "notes"},
+		{Entity: "person", Field: "email", Scope: "asset_type"},
+		{Entity: "asset", Field: "serial_number", Scope: "location"},
+	}
+	for _, rule := range invalid {
+		if _, err := New([]config.UniquenessRule{rule}); err == nil {
+			t.Errorf("Expected rule %+v to be rejected", rule)
+		}
+	}
+}
+
+func TestRuleKey(t *testing.T) {
+	perType := Rule{Entity: EntityAsset, Field: "serial_number", Scope: ScopeAssetType}
+	asset := &models.Asset{SerialNumber: "  PF4X2Z "}
+	if value := perType.AssetValue(asset); value != "PF4X2Z" {
+		t.Errorf("Expected the trimmed serial number, got %q", value)
+	}
+	if perType.Key("PF4X2Z", 1) != perType.Key("pf4x2z", 1) {
+		t.Error("Expected keys to ignore case")
+	}
+	if perType.Key("PF4X2Z", 1) == perType.Key("PF4X2Z", 2) {
+		t.Error("Expected keys of different asset types to differ")
+	}
+	global := Rule{Entity: EntityAsset, Field: "serial_number", Scope: ScopeGlobal}
+	if global.Key("PF4X2Z", 1) != global.Key("PF4X2Z", 2) {
+		t.Error("Expected global keys to ignore the asset type")
+	}
+	if (Rule{Entity: EntityAsset, Field: "id; DROP TABLE assets"}).Column() != "" {
+		t.Error("Expected unknown fields to have no column")
+	}
+}
+
+func TestFindSerialNumbers(t *testing.T) {
+	groups := SerialNumbers.Find([]Item{
+		{ID: 5, Value: "pf-4x2z"},
+		{ID: 1, Value: "PF4X2Z"},
+		{ID: 2, Value: "C02XK0ABJGH5"},
+		{ID: 3, Value: "CO2XKOABJGH5"},
+		{ID: 4, Value: "SN000001"},
+		{ID: 6, Value: "SN000002"},
+		{ID: 7, Value: ""},
+		{ID: 8, Value: "5CG1234"},
+		{ID: 9, Value: " 5cg1234"},
+	})
+	want := []Group{
+		{Match: MatchNormalized, Value: "PF4X2Z", IDs: []int64{1, 5}},
+		{Match: MatchFuzzy, Value: "C02XK0ABJGH5", IDs: []int64{2, 3}},
+		{Match: MatchExact, Value: "5CG1234", IDs: []int64{8, 9}},
+	}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("Expected %+v, got %+v", want, groups)
+	}
+}
+
+func TestFindEmails(t *testing.T) {
+	groups := Emails.Find([]Item{
+		{ID: 1, Value: "Jane.Doe@example.com"},
+		{ID: 2, Value: "jane.doe+laptops@example.com"},
+		{ID: 3, Value: "jane.roe@example.com"},
+	})
+	want := []Group{{Match: MatchNormalized, Value: "Jane.Doe@example.com", IDs: []int64{1, 2}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("Expected %+v, got %+v", want, groups)
+	}
+}
+
+func TestFindNames(t *testing.T) {
+	groups := Names.Find([]Item{
+		{ID: 1, Value: "John Smith"},
+		{ID: 2, Value: "Smith, John"},
+		{ID: 3, Value: "Jon Smith"},
+		{ID: 4, Value: "Jane Smith"},
+		{ID: 5, Value: "Al Li"},
+		{ID: 6, Value: "Al Lu"},
+	})
+	want := []Group{{Match: MatchFuzzy, Value: "John Smith", IDs: []int64{1, 2, 3}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Errorf("Expected %+v, got %+v", want, groups)
+	}
+}
+
+func TestOneEditApart(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"john smith", "jon smith", true},
+		{"john smith", "jonh smith", true},
+		{"john smith", "john smyth", true},
+		{"john smith", "john smith", false},
+		{"john smith", "jane smith", false},
+		{"abc", "abcde", false},
+	}
+	for _, c := range cases {
+		if got := oneEditApart(c.a, c.b); got != c.want {
+			t.Errorf("oneEditApart(%q, %q) = %v, want %v", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package validation enforces the property schema of asset types on asset property values and
+// checks other asset fields shared by the API and imports.
+package validation
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"assetManager/internal/datatype"
+	"assetManager/internal/models"
+	"assetManager/internal/tags"
+)
+
+var (
+	ErrNegativeCost    = errors.New("purchase cost must not be negative")
+	ErrInvalidCurrency = errors.New("a purchase cost needs a three letter currency code such as EUR")
+	ErrInvalidTag      = errors.New("a tag must be at most 100 characters without spaces")
+)
+
+// AssetProperties checks property values written to an asset against the schema of its asset
+// type. existing holds the values currently stored for the asset, keyed by property ID, and
+// updates the values being written; an empty value clears a property.
+//
+// It returns the writes to perform: the updates in canonical form followed by the defaults of
+// schema properties the asset has no value for. A property that is not part of the schema, a
+// value that does not match the property's data type or a required property left without a value
+// is reported as a field error.
+func AssetProperties(schema []models.AssetTypeProperty, existing map[int64]string, updates []models.AssetProperty) ([]models.AssetProperty, []models.FieldError) {
+	byID := make(map[int64]models.AssetTypeProperty, len(schema))
+	for _, atp := range schema {
+		byID[atp.PropertyID] = atp
+	}
+
+	var writes []models.AssetProperty
+	var errs []models.FieldError
+	values := make(map[int64]string, len(existing)+len(updates))
+	for id, value := range existing {
+		values[id] = value
+	}
+	supplied := make(map[int64]bool, len(updates))
+
+	for _, ap := range updates {
+		if supplied[ap.PropertyID] {
+			errs = append(errs, models.FieldError{Field: ap.PropertyName, FieldID: ap.PropertyID, Value: ap.Value,
+				Error: "value supplied more than once"})
+			continue
+		}
+		write, fieldErr := canonicalValue(byID, ap)
+		if fieldErr != nil {
+			errs = append(errs, *fieldErr)
+			continue
+		}
+		supplied[ap.PropertyID] = true
+		values[ap.PropertyID] = write.Value
+		writes = append(writes, write)
+	}
+
+	for _, atp := range schema {
+		if values[atp.PropertyID] == "" && !supplied[atp.PropertyID] && atp.DefaultValue != "" {
+			values[atp.PropertyID] = atp.DefaultValue
+			writes = append(writes, withDefinition(atp.PropertyID, atp.DefaultValue, atp))
+		}
+		if atp.IsRequired && values[atp.PropertyID] == "" {
+			errs = append(errs, models.FieldError{Field: atp.PropertyName, FieldID: atp.PropertyID, Error: "value is required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return writes, nil
+}
+
+// AssetProperty checks a single property value written to an asset against the schema of its
+// asset type and returns it in canonical form. Clearing a required property is an error.
+func AssetProperty(schema []models.AssetTypeProperty, ap models.AssetProperty) (models.AssetProperty, *models.FieldError) {
+	byID := make(map[int64]models.AssetTypeProperty, len(schema))
+	for _, atp := range schema {
+		byID[atp.PropertyID] = atp
+	}
+	write, fieldErr := canonicalValue(byID, ap)
+	if fieldErr != nil {
+		return models.AssetProperty{}, fieldErr
+	}
+	if write.Value == "" && byID[ap.PropertyID].IsRequired {
+		return models.AssetProperty{}, &models.FieldError{Field: write.PropertyName, FieldID: ap.PropertyID, Error: "value is required"}
+	}
+	return write, nil
+}
+
+// Schema checks the entries of an asset type schema: every property may appear once and default
+// values must match the property's data type. Defaults are returned in canonical form.
+func Schema(schema []models.AssetTypeProperty) ([]models.AssetTypeProperty, []models.FieldError) {
+	var errs []models.FieldError
+	seen := make(map[int64]bool, len(schema))
+	result := make([]models.AssetTypeProperty, 0, len(schema))
+	for _, atp := range schema {
+		if seen[atp.PropertyID] {
+			errs = append(errs, models.FieldError{Field: atp.PropertyName, FieldID: atp.PropertyID, Error: "property listed more than once"})
+			continue
+		}
+		seen[atp.PropertyID] = true
+
+		canonical, err := datatype.Canonical(atp.DataType, atp.EnumOptions, atp.DefaultValue)
+		if err != nil {
+			errs = append(errs, models.FieldError{Field: atp.PropertyName, FieldID: atp.PropertyID, Value: atp.DefaultValue,
+				Error: "invalid default: " + err.Error()})
+			continue
+		}
+		atp.DefaultValue = canonical
+		result = append(result, atp)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return result, nil
+}
+
+// canonicalValue checks that a property belongs to the schema and its value matches the data type
+func canonicalValue(byID map[int64]models.AssetTypeProperty, ap models.AssetProperty) (models.AssetProperty, *models.FieldError) {
+	atp, ok := byID[ap.PropertyID]
+	if !ok {
+		return models.AssetProperty{}, &models.FieldError{Field: ap.PropertyName, FieldID: ap.PropertyID, Value: ap.Value,
+			Error: "property does not apply to this asset type"}
+	}
+	canonical, err := datatype.Canonical(atp.DataType, atp.EnumOptions, ap.Value)
+	if err != nil {
+		return models.AssetProperty{}, &models.FieldError{Field: atp.PropertyName, FieldID: ap.PropertyID, Value: ap.Value, Error: err.Error()}
+	}
+	return withDefinition(ap.PropertyID, canonical, atp), nil
+}
+
+// withDefinition builds an asset property write carrying the property's name and data type
+func withDefinition(propertyID int64, value string, atp models.AssetTypeProperty) models.AssetProperty {
+	return models.AssetProperty{
+		PropertyID:   propertyID,
+		Value:        value,
+		PropertyName: atp.PropertyName,
+		DataType:     atp.DataType,
+	}
+}
+
+// Tag checks a tag given for an asset, trimming surrounding space. An empty tag is valid: new
+// assets get a generated one and updates keep the current one.
+func Tag(asset *models.Asset) error {
+	asset.Tag = strings.TrimSpace(asset.Tag)
+	if len(asset.Tag) > tags.MaxLength || strings.ContainsFunc(asset.Tag, unicode.IsSpace) {
+		return ErrInvalidTag
+	}
+	return nil
+}
+
+// PurchaseCost checks the purchase cost and currency of an asset, normalizing the currency to an
+// upper case ISO 4217 code
+func PurchaseCost(asset *models.Asset) error {
+	asset.Currency = strings.ToUpper(strings.TrimSpace(asset.Currency))
+	if asset.PurchaseCost == nil {
+		return nil
+	}
+	if *asset.PurchaseCost < 0 {
+		return ErrNegativeCost
+	}
+	if len(asset.Currency) != 3 {
+		return ErrInvalidCurrency
+	}
+	for _, r := range asset.Currency {
+		if r < 'A' || r > 'Z' {
+			return ErrInvalidCurrency
+		}
+	}
+	return nil
+}
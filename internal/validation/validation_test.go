@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+
+	"assetManager/internal/models"
+)
+
+func testSchema() []models.AssetTypeProperty {
+	return []models.AssetTypeProperty{
+		{PropertyID: 1, PropertyName: "RAM", DataType: models.DataTypeInt, IsRequired: true},
+		{PropertyID: 2, PropertyName: "Colour", DataType: models.DataTypeString, DefaultValue: "Black"},
+		{PropertyID: 3, PropertyName: "Warranty", DataType: models.DataTypeBoolean},
+	}
+}
+
+func TestAssetProperties_Create(t *testing.T) {
+	writes, errs := AssetProperties(testSchema(), nil, []models.AssetProperty{
+		{PropertyID: 1, Value: "016"},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if len(writes) != 2 {
+		t.Fatalf("Expected value and default to be written, got %+v", writes)
+	}
+	if writes[0].PropertyID != 1 || writes[0].Value != "16" {
+		t.Errorf("Expected canonical RAM value, got %+v", writes[0])
+	}
+	if writes[1].PropertyID != 2 || writes[1].Value != "Black" {
+		t.Errorf("Expected default colour, got %+v", writes[1])
+	}
+}
+
+func TestAssetProperties_Errors(t *testing.T) {
+	_, errs := AssetProperties(testSchema(), nil, []models.AssetProperty{
+		{PropertyID: 3, Value: "maybe"},
+		{PropertyID: 9, Value: "x"},
+	})
+
+	got := make(map[int64]bool)
+	for _, e := range errs {
+		got[e.FieldID] = true
+	}
+	for _, id := range []int64{1, 3, 9} {
+		if !got[id] {
+			t.Errorf("Expected an error for property %d, got %v", id, errs)
+		}
+	}
+}
+
+func TestAssetProperties_Update(t *testing.T) {
+	existing := map[int64]string{1: "8", 2: "Silver"}
+
+	writes, errs := AssetProperties(testSchema(), existing, []models.AssetProperty{{PropertyID: 2, Value: ""}})
+	if len(errs) > 0 {
+		t.Fatalf("Expected clearing an optional property to succeed, got %v", errs)
+	}
+	if len(writes) != 1 || writes[0].Value != "" {
+		t.Errorf("Expected the clear to be written without applying the default, got %+v", writes)
+	}
+
+	if _, errs := AssetProperties(testSchema(), existing, []models.AssetProperty{{PropertyID: 1, Value: ""}}); len(errs) != 1 {
+		t.Errorf("Expected clearing a required property to fail, got %v", errs)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema, errs := Schema([]models.AssetTypeProperty{
+		{PropertyID: 1, DataType: models.DataTypeInt, DefaultValue: "+4"},
+		{PropertyID: 3, DataType: models.DataTypeBoolean},
+	})
+	if len(errs) > 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if schema[0].DefaultValue != "4" {
+		t.Errorf("Expected canonical default, got %q", schema[0].DefaultValue)
+	}
+
+	_, errs = Schema([]models.AssetTypeProperty{
+		{PropertyID: 1, DataType: models.DataTypeInt, DefaultValue: "four"},
+		{PropertyID: 3, DataType: models.DataTypeBoolean},
+		{PropertyID: 3, DataType: models.DataTypeBoolean},
+	})
+	if len(errs) != 2 {
+		t.Errorf("Expected 2 errors, got %v", errs)
+	}
+}
+
+func TestAssetProperty(t *testing.T) {
+	ap, err := AssetProperty(testSchema(), models.AssetProperty{PropertyID: 3, Value: "yes"})
+	if err != nil || ap.Value != "true" {
+		t.Errorf("Expected canonical boolean, got %+v (%v)", ap, err)
+	}
+	if _, err := AssetProperty(testSchema(), models.AssetProperty{PropertyID: 1, Value: ""}); err == nil {
+		t.Error("Expected clearing a required property to fail")
+	}
+	if _, err := AssetProperty(testSchema(), models.AssetProperty{PropertyID: 9, Value: "x"}); err == nil {
+		t.Error("Expected a property outside the schema to fail")
+	}
+}
+
+func TestTag(t *testing.T) {
+	asset := models.Asset{Tag: " LPT-2025-00042 "}
+	if err := Tag(&asset); err != nil || asset.Tag != "LPT-2025-00042" {
+		t.Errorf("Expected a trimmed tag, got %q (%v)", asset.Tag, err)
+	}
+	for _, tag := range []string{"LPT 42", strings.Repeat("X", 101)} {
+		if err := Tag(&models.Asset{Tag: tag}); err != ErrInvalidTag {
+			t.Errorf("Expected tag %q to fail, got %v", tag, err)
+		}
+	}
+	if err := Tag(&models.Asset{}); err != nil {
+		t.Errorf("Expected assets without a tag to pass, got %v", err)
+	}
+}
+
+func TestPurchaseCost(t *testing.T) {
+	cost := 1299.0
+	asset := models.Asset{PurchaseCost: &cost, Currency: " eur "}
+	if err := PurchaseCost(&asset); err != nil || asset.Currency != "EUR" {
+		t.Errorf("Expected a normalized currency, got %q (%v)", asset.Currency, err)
+	}
+
+	asset.Currency = ""
+	if err := PurchaseCost(&asset); err != ErrInvalidCurrency {
+		t.Errorf("Expected a cost without currency to fail, got %v", err)
+	}
+	negative := -1.0
+	asset = models.Asset{PurchaseCost: &negative, Currency: "USD"}
+	if err := PurchaseCost(&asset); err != ErrNegativeCost {
+		t.Errorf("Expected a negative cost to fail, got %v", err)
+	}
+	if err := PurchaseCost(&models.Asset{}); err != nil {
+		t.Errorf("Expected assets without a cost to pass, got %v", err)
+	}
+}
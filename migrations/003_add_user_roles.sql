@@ -0,0 +1,8 @@
+-- Migration: 003_add_user_roles
+-- Description: Add role column to users for role-based access control
+
+ALTER TABLE users
+ADD COLUMN role ENUM('admin', 'editor', 'auditor') NOT NULL DEFAULT 'auditor' AFTER password_hash;
+
+-- Existing users previously had unrestricted access, keep them as admins
+UPDATE users SET role = 'admin';
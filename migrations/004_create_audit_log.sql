@@ -0,0 +1,18 @@
+-- Migration: 004_create_audit_log
+-- Description: Record every mutation with the acting user and a before/after diff
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id BIGINT NULL,
+    username VARCHAR(100) NOT NULL DEFAULT '',
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    asset_id BIGINT NULL,
+    action ENUM('create', 'update', 'delete') NOT NULL,
+    changes JSON,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_log_entity (entity_type, entity_id),
+    INDEX idx_audit_log_asset_id (asset_id),
+    INDEX idx_audit_log_user_id (user_id),
+    INDEX idx_audit_log_created_at (created_at)
+);
@@ -0,0 +1,25 @@
+-- Migration: 005_create_asset_type_properties
+-- Description: Scope properties to the asset types they apply to, with required flag, order and default
+
+CREATE TABLE IF NOT EXISTS asset_type_properties (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    asset_type_id BIGINT NOT NULL,
+    property_id BIGINT NOT NULL,
+    is_required BOOLEAN NOT NULL DEFAULT FALSE,
+    display_order INT NOT NULL DEFAULT 0,
+    default_value TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (asset_type_id) REFERENCES asset_types(id),
+    FOREIGN KEY (property_id) REFERENCES properties(id),
+    UNIQUE KEY uq_asset_type_properties (asset_type_id, property_id),
+    INDEX idx_asset_type_properties_property_id (property_id)
+);
+
+-- Keep existing data usable: every property already used by an asset of a type applies to that type
+INSERT IGNORE INTO asset_type_properties (asset_type_id, property_id)
+SELECT DISTINCT a.asset_type_id, ap.property_id
+FROM assets_properties ap
+JOIN assets a ON ap.asset_id = a.id
+JOIN properties p ON ap.property_id = p.id
+WHERE ap.deleted_at IS NULL AND p.deleted_at IS NULL;
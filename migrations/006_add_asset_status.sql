@@ -0,0 +1,33 @@
+-- Migration: 006_add_asset_status
+-- Description: Add an explicit lifecycle status to assets and record every status transition
+
+ALTER TABLE assets
+ADD COLUMN status ENUM('ordered', 'in_stock', 'deployed', 'in_repair', 'lost_stolen', 'retired', 'disposed')
+    NOT NULL DEFAULT 'in_stock' AFTER asset_type_id,
+ADD INDEX idx_assets_status (status);
+
+-- Assets currently held by a real person are deployed, everything else starts in stock
+UPDATE assets a
+JOIN asset_assignments aa ON aa.asset_id = a.id
+    AND aa.deleted_at IS NULL
+    AND aa.effective_from <= NOW()
+    AND (aa.effective_to IS NULL OR aa.effective_to > NOW())
+JOIN persons p ON aa.person_id = p.id AND p.name != 'Unassigned'
+SET a.status = 'deployed';
+
+CREATE TABLE IF NOT EXISTS asset_status_history (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    asset_id BIGINT NOT NULL,
+    from_status VARCHAR(20) NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    user_id BIGINT NULL,
+    username VARCHAR(100) NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (asset_id) REFERENCES assets(id),
+    INDEX idx_asset_status_history_asset_id (asset_id, changed_at)
+);
+
+-- Record the initial status of existing assets
+INSERT INTO asset_status_history (asset_id, from_status, to_status, reason, changed_at)
+SELECT id, NULL, status, 'Initial status', created_at FROM assets;
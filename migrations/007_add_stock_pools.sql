@@ -0,0 +1,16 @@
+-- Migration: 007_add_stock_pools
+-- Description: Model stock explicitly as persons flagged as stock pools instead of the magic 'Unassigned' person
+
+ALTER TABLE persons
+ADD COLUMN is_stock BOOLEAN NOT NULL DEFAULT FALSE AFTER phone,
+ADD INDEX idx_persons_is_stock (is_stock);
+
+-- The 'Unassigned' person seeded by 001_initial_schema becomes the main stock pool. Its assignments
+-- are kept and from now on count as periods in stock.
+UPDATE persons SET is_stock = TRUE, name = 'Main Stock', updated_at = NOW()
+WHERE id = (SELECT id FROM (SELECT MIN(id) AS id FROM persons WHERE name = 'Unassigned') AS seeded);
+
+-- Make sure there is always a stock pool to return assets to
+INSERT INTO persons (name, email, phone, is_stock)
+SELECT 'Main Stock', '', '', TRUE FROM DUAL
+WHERE NOT EXISTS (SELECT 1 FROM persons WHERE is_stock = TRUE AND deleted_at IS NULL);
@@ -0,0 +1,34 @@
+-- Migration: 008_create_locations
+-- Description: Add a location hierarchy (sites, buildings, rooms, racks) and track where each asset is placed
+
+CREATE TABLE IF NOT EXISTS locations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    parent_id BIGINT NULL,
+    name VARCHAR(255) NOT NULL,
+    kind ENUM('site', 'building', 'floor', 'room', 'rack', 'other') NOT NULL DEFAULT 'other',
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    FOREIGN KEY (parent_id) REFERENCES locations(id),
+    INDEX idx_locations_parent_id (parent_id),
+    INDEX idx_locations_deleted_at (deleted_at)
+);
+
+-- Placement history, one open-ended row per asset for its current location
+CREATE TABLE IF NOT EXISTS asset_locations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    asset_id BIGINT NOT NULL,
+    location_id BIGINT NOT NULL,
+    effective_from TIMESTAMP NOT NULL,
+    effective_to TIMESTAMP NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    FOREIGN KEY (asset_id) REFERENCES assets(id),
+    FOREIGN KEY (location_id) REFERENCES locations(id),
+    INDEX idx_asset_locations_asset_id (asset_id, effective_from),
+    INDEX idx_asset_locations_location_id (location_id),
+    INDEX idx_asset_locations_deleted_at (deleted_at)
+);
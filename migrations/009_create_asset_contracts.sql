@@ -0,0 +1,30 @@
+-- Migration: 009_create_asset_contracts
+-- Description: Track warranties, support contracts and leases per asset, and the expiry alerts raised for them
+
+CREATE TABLE IF NOT EXISTS asset_contracts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    asset_id BIGINT NOT NULL,
+    kind ENUM('warranty', 'support', 'lease') NOT NULL,
+    vendor VARCHAR(255),
+    contract_number VARCHAR(255),
+    starts_at DATE NULL,
+    ends_at DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    FOREIGN KEY (asset_id) REFERENCES assets(id),
+    INDEX idx_asset_contracts_asset_id (asset_id),
+    INDEX idx_asset_contracts_ends_at (ends_at),
+    INDEX idx_asset_contracts_deleted_at (deleted_at)
+);
+
+-- One alert per contract and end date, raised by the daily expiry job
+CREATE TABLE IF NOT EXISTS contract_alerts (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    contract_id BIGINT NOT NULL,
+    ends_at DATE NOT NULL,
+    raised_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (contract_id) REFERENCES asset_contracts(id),
+    UNIQUE KEY uk_contract_alerts_contract_ends (contract_id, ends_at)
+);
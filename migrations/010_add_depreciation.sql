@@ -0,0 +1,11 @@
+-- Migration: 010_add_depreciation
+-- Description: Add purchase cost and currency to assets, and the depreciation method and useful life to asset types
+
+ALTER TABLE assets
+ADD COLUMN purchase_cost DECIMAL(15,2) NULL AFTER purchased_at,
+ADD COLUMN currency CHAR(3) NULL AFTER purchase_cost;
+
+-- Asset types without a useful life are not depreciated
+ALTER TABLE asset_types
+ADD COLUMN depreciation_method ENUM('straight_line', 'declining_balance') NOT NULL DEFAULT 'straight_line' AFTER description,
+ADD COLUMN useful_life_months INT NULL AFTER depreciation_method;
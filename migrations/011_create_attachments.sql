@@ -0,0 +1,22 @@
+-- Migration: 011_create_attachments
+-- Description: Store metadata of files attached to assets, persons and assignments; the content lives in the configured storage backend
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    entity_type ENUM('asset', 'person', 'assignment') NOT NULL,
+    entity_id BIGINT NOT NULL,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    description TEXT,
+    uploaded_by BIGINT NULL,
+    uploaded_by_name VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL,
+    INDEX idx_attachments_entity (entity_type, entity_id),
+    INDEX idx_attachments_sha256 (sha256),
+    INDEX idx_attachments_deleted_at (deleted_at)
+);
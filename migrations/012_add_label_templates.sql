@@ -0,0 +1,7 @@
+-- Migration: 012_add_label_templates
+-- Description: Add the label template to asset types: the barcode symbology, what it encodes and the text lines printed next to it
+
+ALTER TABLE asset_types
+ADD COLUMN label_code ENUM('qr', 'code128') NOT NULL DEFAULT 'qr' AFTER useful_life_months,
+ADD COLUMN label_content ENUM('url', 'id') NOT NULL DEFAULT 'url' AFTER label_code,
+ADD COLUMN label_text VARCHAR(500) NOT NULL DEFAULT '{name}' AFTER label_content;
@@ -0,0 +1,29 @@
+-- Migration: 013_add_asset_tags
+-- Description: Add unique human-friendly asset tags generated from a pattern per asset type, with one counter per expanded pattern
+
+ALTER TABLE asset_types
+ADD COLUMN tag_pattern VARCHAR(50) NULL AFTER description;
+
+-- Tags stay unique across deleted assets so a sticker never points to two assets
+ALTER TABLE assets
+ADD COLUMN tag VARCHAR(100) NULL AFTER asset_type_id,
+ADD UNIQUE INDEX uq_assets_tag (tag);
+
+-- scope is the tag pattern with its date tokens expanded, such as LPT-2025-{seq}
+CREATE TABLE IF NOT EXISTS tag_sequences (
+    scope VARCHAR(100) PRIMARY KEY,
+    last_value BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Tag existing assets with the default pattern AST-{seq:6}, numbered by their ID
+UPDATE assets SET tag = CONCAT('AST-', IF(id < 1000000, LPAD(id, 6, '0'), id)) WHERE tag IS NULL;
+
+INSERT INTO tag_sequences (scope, last_value)
+SELECT 'AST-{seq}', COALESCE(MAX(id), 0) FROM assets
+ON DUPLICATE KEY UPDATE last_value = GREATEST(last_value, VALUES(last_value));
+
+-- Labels can encode the tag for scanners using GET /api/assets/by-tag/:tag
+ALTER TABLE asset_types
+MODIFY COLUMN label_content ENUM('url', 'id', 'tag') NOT NULL DEFAULT 'url';
@@ -0,0 +1,6 @@
+-- Migration: 014_add_person_merges
+-- Description: Point persons merged into another person to the surviving record
+
+ALTER TABLE persons
+ADD COLUMN merged_into_id BIGINT NULL AFTER is_stock,
+ADD FOREIGN KEY (merged_into_id) REFERENCES persons(id);
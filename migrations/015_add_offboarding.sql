@@ -0,0 +1,10 @@
+-- Migration: 015_add_offboarding
+-- Description: Track persons who left and the condition assets were returned in
+
+ALTER TABLE persons
+ADD COLUMN is_active BOOLEAN NOT NULL DEFAULT TRUE AFTER is_stock,
+ADD COLUMN left_at DATE NULL AFTER is_active;
+
+ALTER TABLE asset_assignments
+ADD COLUMN return_condition ENUM('good', 'fair', 'damaged') NULL AFTER notes,
+ADD COLUMN return_notes TEXT NULL AFTER return_condition;
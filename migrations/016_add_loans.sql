@@ -0,0 +1,7 @@
+-- Migration: 016_add_loans
+-- Description: Track when loaned assets are due back and when borrowers were last reminded
+
+ALTER TABLE asset_assignments
+ADD COLUMN due_at TIMESTAMP NULL AFTER effective_to,
+ADD COLUMN reminded_at TIMESTAMP NULL AFTER due_at,
+ADD INDEX idx_asset_assignments_due_at (due_at);
@@ -0,0 +1,24 @@
+-- Migration: 017_create_reservations
+-- Description: Reserve a specific asset, or any asset of a type, for a person over a time window
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    asset_id BIGINT NULL,
+    asset_type_id BIGINT NULL,
+    person_id BIGINT NOT NULL,
+    starts_at TIMESTAMP NOT NULL,
+    ends_at TIMESTAMP NOT NULL,
+    status ENUM('reserved', 'picked_up', 'cancelled') NOT NULL DEFAULT 'reserved',
+    assignment_id BIGINT NULL,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (asset_id) REFERENCES assets(id),
+    FOREIGN KEY (asset_type_id) REFERENCES asset_types(id),
+    FOREIGN KEY (person_id) REFERENCES persons(id),
+    FOREIGN KEY (assignment_id) REFERENCES asset_assignments(id),
+    INDEX idx_reservations_asset_id (asset_id),
+    INDEX idx_reservations_asset_type_id (asset_type_id),
+    INDEX idx_reservations_person_id (person_id),
+    INDEX idx_reservations_window (starts_at, ends_at)
+);
@@ -0,0 +1,9 @@
+-- Migration: 018_add_offboarding_returns
+-- Description: Tell returns made on offboarding apart from loan check-ins
+
+ALTER TABLE asset_assignments
+ADD COLUMN returned_on_offboarding BOOLEAN NOT NULL DEFAULT FALSE AFTER return_notes;
+
+-- Loans are checked in at the loan desk, so earlier returns of other assignments were offboardings
+UPDATE asset_assignments SET returned_on_offboarding = TRUE
+WHERE return_condition IS NOT NULL AND due_at IS NULL;
@@ -0,0 +1,9 @@
+-- Migration: 019_create_calendar_feeds
+-- Description: Random keys granting access to calendar feeds, which can be regenerated to revoke an address
+
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    feed VARCHAR(64) PRIMARY KEY,
+    feed_key CHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
@@ -34,8 +34,15 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
     }
 
     if (!response.ok) {
-      const error = await response.json().catch(() => ({ Error: 'Request failed' }));
-      throw new Error(error.Error || 'Request failed');
+      const body = await response.json().catch(() => ({ Error: 'Request failed' }));
+      let message = body.Error || 'Request failed';
+      // Validation errors name the offending property or attribute
+      if (Array.isArray(body.Errors) && body.Errors.length > 0) {
+        message += ': ' + body.Errors.map(e => (e.Field ? `${e.Field}: ${e.Error}` : e.Error)).join('; ');
+      }
+      const error = new Error(message);
+      error.details = body;
+      throw error;
     }
 
     if (response.status === 204) {
@@ -45,6 +52,65 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
     return response.json();
   }
 
+  async function upload(path, formData) {
+    const headers = {};
+
+    const token = getToken();
+    if (token) {
+      headers['Authorization'] = `Bearer ${token}`;
+    }
+
+    const response = await fetch(`${baseUrl}${path}`, { method: 'POST', headers, body: formData });
+
+    if (response.status === 401) {
+      if (onUnauthorized) {
+        onUnauthorized();
+      }
+      throw new Error('Unauthorized');
+    }
+
+    const body = await response.json().catch(() => ({ Error: 'Request failed' }));
+    if (!response.ok) {
+      const error = new Error(body.Error || 'Request failed');
+      error.details = body;
+      throw error;
+    }
+
+    return body;
+  }
+
+  async function download(method, path, data = null) {
+    const headers = { 'Content-Type': 'application/json' };
+
+    const token = getToken();
+    if (token) {
+      headers['Authorization'] = `Bearer ${token}`;
+    }
+
+    const options = { method, headers };
+    if (data) {
+      options.body = JSON.stringify(data);
+    }
+
+    const response = await fetch(`${baseUrl}${path}`, options);
+
+    if (response.status === 401) {
+      if (onUnauthorized) {
+        onUnauthorized();
+      }
+      throw new Error('Unauthorized');
+    }
+
+    if (!response.ok) {
+      const error = await response.json().catch(() => ({ Error: 'Request failed' }));
+      throw new Error(error.Error || 'Request failed');
+    }
+
+    const disposition = response.headers.get('Content-Disposition') || '';
+    const match = disposition.match(/filename="?([^"]+)"?/);
+    return { blob: await response.blob(), filename: match ? match[1] : 'report' };
+  }
+
   return {
     // Auth
     login: (username, password, remember) =>
@@ -67,14 +133,26 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
     createAssetType: (data) => request("POST", "/api/asset-types", data),
     updateAssetType: (id, data) => request("PUT", `/api/asset-types/${id}`, data),
     deleteAssetType: (id) => request("DELETE", `/api/asset-types/${id}`),
+    setAssetTypeProperties: (id, properties) => request("PUT", `/api/asset-types/${id}/properties`, properties),
 
     // Assets
     getAssets: () => request("GET", "/api/assets"),
-    getAssetsWithAssignments: (includeDeleted = false) =>
-      request("GET", `/api/assets/with-assignments${includeDeleted ? "?include_deleted=true" : ""}`),
+    getAssetsWithAssignments: (includeDeleted = false, statuses = [], asOf = "") => {
+      const params = new URLSearchParams();
+      if (includeDeleted) params.set("include_deleted", "true");
+      if (statuses.length > 0) params.set("status", statuses.join(","));
+      if (asOf) params.set("as_of", asOf);
+      const query = params.toString();
+      return request("GET", `/api/assets/with-assignments${query ? `?${query}` : ""}`);
+    },
+    getAssetLifecycle: () => request("GET", "/api/asset-statuses"),
+    changeAssetStatus: (id, status, reason) =>
+      request("POST", `/api/assets/${id}/status`, { Status: status, Reason: reason }),
+    getAssetStatusHistory: (id) => request("GET", `/api/assets/${id}/status-history`),
     getAsset: (id) => request("GET", `/api/assets/${id}`),
     getAssetsByType: (typeId) => request("GET", `/api/assets/by-type/${typeId}`),
     searchAssets: (query) => request("GET", `/api/assets/search?q=${encodeURIComponent(query)}`),
+    getAssetByTag: (tag) => request("GET", `/api/assets/by-tag/${encodeURIComponent(tag)}`),
     createAsset: (data) => request("POST", "/api/assets", data),
     updateAsset: (id, data) => request("PUT", `/api/assets/${id}`, data),
     deleteAsset: (id) => request("DELETE", `/api/assets/${id}`),
@@ -97,6 +175,10 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
     createPerson: (data) => request("POST", "/api/persons", data),
     updatePerson: (id, data) => request("PUT", `/api/persons/${id}`, data),
     deletePerson: (id) => request("DELETE", `/api/persons/${id}`),
+    mergePerson: (id, data) => request("POST", `/api/persons/${id}/merge`, data),
+    offboardPerson: (id, data) => request("POST", `/api/persons/${id}/offboard`, data),
+    getOffboardingChecklist: (id) => request("GET", `/api/persons/${id}/offboarding`),
+    exportOffboardingChecklist: (id, format) => download("GET", `/api/persons/${id}/offboarding?format=${format}`),
     getPersonAttributes: (id) => request("GET", `/api/persons/${id}/attributes`),
     setPersonAttribute: (id, data) => request("POST", `/api/persons/${id}/attributes`, data),
     deletePersonAttribute: (id, attrId) => request("DELETE", `/api/persons/${id}/attributes/${attrId}`),
@@ -111,8 +193,10 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
     // Assignments
     getAssetAssignments: (assetId) => request("GET", `/api/assignments/asset/${assetId}`),
     getCurrentAssetAssignment: (assetId) => request("GET", `/api/assignments/asset/${assetId}/current`),
+    getAssetTimeline: (assetId) => request("GET", `/api/assignments/asset/${assetId}/timeline`),
     getPersonAssignments: (personId) => request("GET", `/api/assignments/person/${personId}`),
-    getCurrentPersonAssignments: (personId) => request("GET", `/api/assignments/person/${personId}/current`),
+    getCurrentPersonAssignments: (personId, asOf = "") =>
+      request("GET", `/api/assignments/person/${personId}/current${asOf ? `?as_of=${encodeURIComponent(asOf)}` : ""}`),
     createAssignment: (data) => request("POST", "/api/assignments", data),
     assignAsset: (assetId, personId, notes, effectiveDate) =>
       request("POST", "/api/assignments/assign", {
@@ -121,14 +205,115 @@ export function createApiClient(baseUrl, getToken, onUnauthorized) {
         Notes: notes,
         EffectiveDate: effectiveDate,
       }),
-    unassignAsset: (assetId, effectiveDate) =>
-      request("POST", `/api/assignments/unassign/${assetId}`, { EffectiveDate: effectiveDate }),
+    unassignAsset: (assetId, effectiveDate, stockPoolId = 0) =>
+      request("POST", `/api/assignments/unassign/${assetId}`, { EffectiveDate: effectiveDate, StockPoolID: stockPoolId }),
+    getStockPools: () => request("GET", "/api/stock-pools"),
     updateAssignment: (id, data) => request("PUT", `/api/assignments/${id}`, data),
     endAssignment: (id, endDate) => request("POST", `/api/assignments/${id}/end`, { EndDate: endDate }),
     deleteAssignment: (id) => request("DELETE", `/api/assignments/${id}`),
+    getPendingAssignments: () => request("GET", "/api/assignments/pending"),
+    cancelAssignment: (id) => request("POST", `/api/assignments/${id}/cancel`),
+
+    // Loan desk
+    getLoans: () => request("GET", "/api/loans"),
+    checkOutAsset: (data) => request("POST", "/api/loans/check-out", data),
+    checkInAsset: (assetId, data) => request("POST", `/api/loans/check-in/${assetId}`, data),
+
+    // Reservations
+    getReservations: ({ assetId = 0, personId = 0, all = false } = {}) => {
+      const params = new URLSearchParams();
+      if (assetId) params.set("assetId", assetId);
+      if (personId) params.set("personId", personId);
+      if (all) params.set("all", "true");
+      const query = params.toString();
+      return request("GET", `/api/reservations${query ? `?${query}` : ""}`);
+    },
+    getReservation: (id) => request("GET", `/api/reservations/${id}`),
+    createReservation: (data) => request("POST", "/api/reservations", data),
+    cancelReservation: (id) => request("POST", `/api/reservations/${id}/cancel`),
+    pickupReservation: (id, data) => request("POST", `/api/reservations/${id}/pickup`, data),
+    getAssetCalendarFeed: (id) => request("GET", `/api/assets/${id}/calendar`),
+    getPersonCalendarFeed: (id) => request("GET", `/api/persons/${id}/calendar`),
+    regenerateAssetCalendarFeed: (id) => request("POST", `/api/assets/${id}/calendar/regenerate`),
+    regeneratePersonCalendarFeed: (id) => request("POST", `/api/persons/${id}/calendar/regenerate`),
+
+    // Locations
+    getLocations: () => request("GET", "/api/locations"),
+    getLocation: (id) => request("GET", `/api/locations/${id}`),
+    getLocationAssets: (id, includeSubtree = false) =>
+      request("GET", `/api/locations/${id}/assets${includeSubtree ? "?subtree=true" : ""}`),
+    createLocation: (data) => request("POST", "/api/locations", data),
+    updateLocation: (id, data) => request("PUT", `/api/locations/${id}`, data),
+    deleteLocation: (id) => request("DELETE", `/api/locations/${id}`),
+    getAssetLocation: (assetId) => request("GET", `/api/assets/${assetId}/location`),
+    getAssetLocationHistory: (assetId) => request("GET", `/api/assets/${assetId}/location-history`),
+    moveAsset: (assetId, locationId, notes, effectiveDate) =>
+      request("POST", `/api/assets/${assetId}/location`, {
+        LocationID: locationId,
+        Notes: notes,
+        EffectiveDate: effectiveDate,
+      }),
+    removeAssetLocation: (assetId) => request("DELETE", `/api/assets/${assetId}/location`),
+
+    // Attachments, entity is "assets", "persons" or "assignments"
+    getAttachments: (entity, id) => request("GET", `/api/${entity}/${id}/attachments`),
+    uploadAttachment: (entity, id, file, description = "") => {
+      const formData = new FormData();
+      formData.append("file", file);
+      formData.append("description", description);
+      return upload(`/api/${entity}/${id}/attachments`, formData);
+    },
+    downloadAttachment: (id) => download("GET", `/api/attachments/${id}/download`),
+    deleteAttachment: (id) => request("DELETE", `/api/attachments/${id}`),
+
+    // Labels
+    getLabelLayouts: () => request("GET", "/api/labels/layouts"),
+    printLabels: (data) => download("POST", "/api/labels", data),
+
+    // Warranties, support contracts and leases
+    getAssetContracts: (assetId) => request("GET", `/api/assets/${assetId}/contracts`),
+    createAssetContract: (assetId, data) => request("POST", `/api/assets/${assetId}/contracts`, data),
+    updateContract: (id, data) => request("PUT", `/api/contracts/${id}`, data),
+    deleteContract: (id) => request("DELETE", `/api/contracts/${id}`),
+    getExpiringContracts: (within = "30d") => request("GET", `/api/alerts/expiring?within=${encodeURIComponent(within)}`),
 
     // Reports
     executeCustomReport: (data) => request("POST", "/api/reports/custom", data),
     getMultipleAssetsReport: (assetTypeId) => request("GET", `/api/reports/multiple-assets?assetTypeId=${assetTypeId}`),
+    exportCustomReport: (data, format) => download("POST", `/api/reports/custom?format=${format}`, data),
+    exportMultipleAssetsReport: (assetTypeId, format) =>
+      download("GET", `/api/reports/multiple-assets?assetTypeId=${assetTypeId}&format=${format}`),
+    exportAssetListing: (format, includeDeleted = false, locationId = null) =>
+      download(
+        "GET",
+        `/api/reports/assets?format=${format}${includeDeleted ? "&include_deleted=true" : ""}${locationId ? `&location_id=${locationId}` : ""}`
+      ),
+    getAssetBookValue: (assetId, asOf = "") =>
+      request("GET", `/api/assets/${assetId}/book-value${asOf ? `?as_of=${asOf}` : ""}`),
+    getDepreciationReport: (asOf) => request("GET", `/api/reports/depreciation?as_of=${asOf}`),
+    exportDepreciationReport: (asOf, format) => download("GET", `/api/reports/depreciation?as_of=${asOf}&format=${format}`),
+    exportBookValues: (asOf, format) => download("GET", `/api/reports/book-values?as_of=${asOf}&format=${format}`),
+    getUpcomingChanges: (within = "30d") => request("GET", `/api/reports/upcoming-changes?within=${within}`),
+    exportUpcomingChanges: (within, format) =>
+      download("GET", `/api/reports/upcoming-changes?within=${within}&format=${format}`),
+    exportOverdueLoans: (format) => download("GET", `/api/reports/overdue-loans?format=${format}`),
+    getDuplicates: (match = "") => request("GET", `/api/maintenance/duplicates${match ? `?match=${match}` : ""}`),
+    exportPersonListing: (format, includeDeleted = false) =>
+      download("GET", `/api/reports/persons?format=${format}${includeDeleted ? "&include_deleted=true" : ""}`),
+
+    // Imports
+    importAssets: (file, dryRun = false) => {
+      const formData = new FormData();
+      formData.append('file', file);
+      return upload(`/api/import/assets${dryRun ? "?dry_run=true" : ""}`, formData);
+    },
+
+    // Audit log
+    getAuditLog: (filters = {}) => {
+      const params = new URLSearchParams(Object.entries(filters).filter(([, v]) => v !== '' && v != null));
+      const query = params.toString();
+      return request("GET", `/api/audit${query ? `?${query}` : ""}`);
+    },
+    getAssetAudit: (id) => request("GET", `/api/assets/${id}/audit`),
   };
 }
@@ -19,3 +19,6 @@ export { createApiClient } from './api/client.js';
 // Stores
 export { createAuthStore } from './stores/auth.js';
 export { createNotificationStore } from './stores/notifications.js';
+
+// Utilities
+export { assetStatuses, statusLabel, statusTag } from './utils/assetStatus.js';
@@ -0,0 +1,29 @@
+/**
+ * Asset lifecycle statuses in lifecycle order, with display labels and tag colours
+ */
+export const assetStatuses = [
+  { value: 'ordered', label: 'Ordered', color: 'is-light' },
+  { value: 'in_stock', label: 'In Stock', color: 'is-info' },
+  { value: 'deployed', label: 'Deployed', color: 'is-success' },
+  { value: 'in_repair', label: 'In Repair', color: 'is-warning' },
+  { value: 'lost_stolen', label: 'Lost/Stolen', color: 'is-danger' },
+  { value: 'retired', label: 'Retired', color: 'is-dark' },
+  { value: 'disposed', label: 'Disposed', color: 'is-dark' }
+];
+
+/**
+ * Returns the display label of a status
+ * @param {string} status - Status value such as "in_stock"
+ */
+export function statusLabel(status) {
+  return assetStatuses.find(s => s.value === status)?.label || status || '';
+}
+
+/**
+ * Returns an HTML tag for a status, for use in table cell renderers
+ * @param {string} status - Status value such as "in_stock"
+ */
+export function statusTag(status) {
+  const s = assetStatuses.find(s => s.value === status);
+  return `<span class="tag ${s?.color || 'is-light'}">${statusLabel(status)}</span>`;
+}
@@ -64,6 +64,26 @@ export function exportToCSV(data, filename = 'export.csv') {
   URL.revokeObjectURL(url);
 }
 
+/**
+ * Saves a downloaded file in the browser
+ * @param {Blob} blob - File contents
+ * @param {string} filename - Filename for the download
+ */
+export function downloadBlob(blob, filename) {
+  const link = document.createElement('a');
+  const url = URL.createObjectURL(blob);
+
+  link.setAttribute('href', url);
+  link.setAttribute('download', filename);
+  link.style.visibility = 'hidden';
+
+  document.body.appendChild(link);
+  link.click();
+  document.body.removeChild(link);
+
+  URL.revokeObjectURL(url);
+}
+
 /**
  * Escapes a value for CSV format
  * @param {string} value - Value to escape
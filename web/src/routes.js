@@ -5,6 +5,9 @@ import AssetDetail from './pages/AssetDetail.svelte';
 import Persons from './pages/Persons.svelte';
 import PersonDetail from './pages/PersonDetail.svelte';
 import Assignments from './pages/Assignments.svelte';
+import Loans from './pages/Loans.svelte';
+import Reservations from './pages/Reservations.svelte';
+import Locations from './pages/Locations.svelte';
 import AssetTypes from './pages/config/AssetTypes.svelte';
 import Properties from './pages/config/Properties.svelte';
 import Attributes from './pages/config/Attributes.svelte';
@@ -14,6 +17,9 @@ import AssetListing from './pages/reports/AssetListing.svelte';
 import PersonListing from './pages/reports/PersonListing.svelte';
 import CustomReport from './pages/reports/CustomReport.svelte';
 import MultipleAssets from './pages/reports/MultipleAssets.svelte';
+import Depreciation from './pages/reports/Depreciation.svelte';
+import Duplicates from './pages/reports/Duplicates.svelte';
+import UpcomingChanges from './pages/reports/UpcomingChanges.svelte';
 
 export const routes = {
   '/': Dashboard,
@@ -23,6 +29,9 @@ export const routes = {
   '/persons': Persons,
   '/persons/:id': PersonDetail,
   '/assignments': Assignments,
+  '/loans': Loans,
+  '/reservations': Reservations,
+  '/locations': Locations,
   '/config/asset-types': AssetTypes,
   '/config/properties': Properties,
   '/config/attributes': Attributes,
@@ -32,5 +41,8 @@ export const routes = {
   '/reports/persons': PersonListing,
   '/reports/custom': CustomReport,
   '/reports/multiple-assets': MultipleAssets,
+  '/reports/depreciation': Depreciation,
+  '/reports/duplicates': Duplicates,
+  '/reports/upcoming-changes': UpcomingChanges,
   '*': Dashboard,
 };